@@ -4,7 +4,8 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -16,6 +17,7 @@ import (
 	"github.com/thinkscotty/maggpi_go/internal/config"
 	"github.com/thinkscotty/maggpi_go/internal/database"
 	"github.com/thinkscotty/maggpi_go/internal/handlers"
+	"github.com/thinkscotty/maggpi_go/internal/logging"
 	"github.com/thinkscotty/maggpi_go/internal/scheduler"
 )
 
@@ -24,24 +26,37 @@ func main() {
 	configPath := flag.String("config", "./data/config.json", "Path to configuration file")
 	flag.Parse()
 
-	log.Println("Starting MaggPi...")
-
 	// Load configuration
 	cfg, err := config.Load(*configPath)
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("Failed to load configuration", err)
+	}
+	if err := logging.Setup(cfg.LogFormat, cfg.Debug); err != nil {
+		fatal("Invalid log format", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		fatal("Invalid configuration", err)
 	}
 
+	slog.Info("Starting MaggPi...")
+
 	// Initialize database
 	db, err := database.New(cfg.DatabasePath)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		fatal("Failed to initialize database", err)
 	}
 	defer db.Close()
 
+	// Refreshes cut short by a crash would otherwise stay in progress forever
+	if n, err := db.ResetInProgressStatuses(time.Minute); err != nil {
+		slog.Warn("Failed to reset interrupted refreshes", "error", err)
+	} else if n > 0 {
+		slog.Info("Reset refreshes interrupted by restart", "count", n)
+	}
+
 	// Seed default topics if database is empty
-	if err := seedDefaultTopics(db); err != nil {
-		log.Printf("Warning: failed to seed default topics: %v", err)
+	if err := db.SeedDefaultTopics(); err != nil {
+		slog.Warn("Failed to seed default topics", "error", err)
 	}
 
 	// Create scheduler
@@ -68,42 +83,63 @@ func main() {
 	})
 
 	if templatesDir == "" {
-		log.Fatal("Could not find templates directory")
+		fatal("Could not find templates directory", nil)
 	}
 	if staticDir == "" {
-		log.Fatal("Could not find static directory")
+		fatal("Could not find static directory", nil)
 	}
 
-	log.Printf("Using templates from: %s", templatesDir)
-	log.Printf("Using static files from: %s", staticDir)
+	slog.Info("Using web assets", "templates", templatesDir, "static", staticDir)
 
 	// Create handlers
 	h, err := handlers.New(db, sched, templatesDir)
 	if err != nil {
-		log.Fatalf("Failed to create handlers: %v", err)
+		fatal("Failed to create handlers", err)
 	}
 
 	// Create router
-	router := api.NewRouter(h, staticDir)
+	router := api.NewRouter(h, staticDir, cfg.APIRateLimit)
 
 	// Create server
 	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
 	server := &http.Server{
 		Addr:         addr,
 		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 60 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.ReadTimeout(),
+		WriteTimeout: cfg.WriteTimeout(),
+		IdleTimeout:  cfg.IdleTimeout(),
 	}
 
 	// Start scheduler
 	sched.Start()
 
+	// Listen on the Unix socket if configured, otherwise on host:port
+	listener, err := listen(cfg, addr)
+	if err != nil {
+		fatal("Failed to listen", err)
+	}
+
 	// Start server in goroutine
 	serverErrors := make(chan error, 1)
 	go func() {
-		log.Printf("Server listening on http://%s", addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		scheme := "http"
+		if cfg.TLSEnabled() {
+			scheme = "https"
+		}
+		if cfg.UnixSocket != "" {
+			slog.Info("Server listening", "address", "unix:"+cfg.UnixSocket, "tls", cfg.TLSEnabled())
+		} else {
+			slog.Info("Server listening", "address", scheme+"://"+addr)
+		}
+
+		var err error
+		if cfg.TLSEnabled() {
+			// Same as ListenAndServeTLS, on the listener opened above
+			err = server.ServeTLS(listener, cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			serverErrors <- err
 		}
 	}()
@@ -114,24 +150,59 @@ func main() {
 
 	select {
 	case err := <-serverErrors:
-		log.Printf("Server error, initiating shutdown: %v", err)
+		slog.Error("Server error, initiating shutdown", "error", err)
 	case <-quit:
 	}
 
-	log.Println("Shutting down...")
-
-	// Stop scheduler
-	sched.Stop()
+	slog.Info("Shutting down...")
 
-	// Graceful shutdown
+	// Graceful shutdown; the scheduler and the server share one 30 second budget
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// Stop scheduler, interrupting any refresh mid-scrape or mid-AI call
+	if err := sched.Shutdown(ctx); err != nil {
+		slog.Warn("Scheduler did not stop in time", "error", err)
+	}
+
 	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown: %v", err)
+		slog.Warn("Server forced to shutdown", "error", err)
+	}
+	if cfg.UnixSocket != "" {
+		if err := os.Remove(cfg.UnixSocket); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to remove socket", "path", cfg.UnixSocket, "error", err)
+		}
 	}
 
-	log.Println("Server stopped")
+	slog.Info("Server stopped")
+}
+
+// fatal logs a startup failure and exits
+func fatal(msg string, err error) {
+	if err != nil {
+		slog.Error(msg, "error", err)
+	} else {
+		slog.Error(msg)
+	}
+	os.Exit(1)
+}
+
+// listen opens the server's listener: a Unix domain socket when cfg.UnixSocket is set,
+// otherwise TCP on addr. A socket file left behind by an unclean exit is removed first.
+func listen(cfg *config.Config, addr string) (net.Listener, error) {
+	if cfg.UnixSocket == "" {
+		return net.Listen("tcp", addr)
+	}
+
+	if info, err := os.Stat(cfg.UnixSocket); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", cfg.UnixSocket)
+		}
+		if err := os.Remove(cfg.UnixSocket); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+	return net.Listen("unix", cfg.UnixSocket)
 }
 
 // findDir returns the first directory that exists
@@ -143,47 +214,3 @@ func findDir(paths []string) string {
 	}
 	return ""
 }
-
-// seedDefaultTopics adds the default topics if the database is empty
-func seedDefaultTopics(db *database.DB) error {
-	topics, err := db.GetTopics()
-	if err != nil {
-		return err
-	}
-
-	// Only seed if no topics exist
-	if len(topics) > 0 {
-		return nil
-	}
-
-	defaultTopics := []struct {
-		Name        string
-		Description string
-	}{
-		{
-			Name:        "World News",
-			Description: "Major international news and current events from around the globe. Focus on significant political developments, international relations, and major world events.",
-		},
-		{
-			Name:        "Formula 1",
-			Description: "Formula 1 racing news including race results, driver standings, team updates, technical regulations, and breaking news from the F1 paddock.",
-		},
-		{
-			Name:        "Science News",
-			Description: "Latest scientific discoveries and research breakthroughs across all fields including physics, biology, astronomy, climate science, and medical research.",
-		},
-		{
-			Name:        "Tech News",
-			Description: "Technology industry news including product launches, company updates, software releases, AI developments, and emerging tech trends.",
-		},
-	}
-
-	for _, t := range defaultTopics {
-		if _, err := db.CreateTopic(t.Name, t.Description); err != nil {
-			return fmt.Errorf("failed to create topic %s: %w", t.Name, err)
-		}
-		log.Printf("Created default topic: %s", t.Name)
-	}
-
-	return nil
-}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/thinkscotty/maggpi_go/internal/models"
+	"golang.org/x/time/rate"
+)
+
+// idleLimiterTTL is how long a client's limiter is kept after its last request
+const idleLimiterTTL = 10 * time.Minute
+
+// rateLimiter is a per-IP token bucket limiter
+type rateLimiter struct {
+	mu        sync.Mutex
+	clients   map[string]*clientLimiter
+	limit     rate.Limit
+	burst     int
+	lastSweep time.Time
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// newRateLimiter allows each client requestsPerMinute requests per minute,
+// with bursts of up to the same number
+func newRateLimiter(requestsPerMinute int) *rateLimiter {
+	return &rateLimiter{
+		clients:   make(map[string]*clientLimiter),
+		limit:     rate.Limit(float64(requestsPerMinute) / 60),
+		burst:     requestsPerMinute,
+		lastSweep: time.Now(),
+	}
+}
+
+// limiterFor returns the limiter for a client IP, creating it if needed
+func (rl *rateLimiter) limiterFor(ip string) *rate.Limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+
+	// Forget idle clients so the map doesn't grow forever
+	if now.Sub(rl.lastSweep) > idleLimiterTTL {
+		for key, c := range rl.clients {
+			if now.Sub(c.lastSeen) > idleLimiterTTL {
+				delete(rl.clients, key)
+			}
+		}
+		rl.lastSweep = now
+	}
+
+	c, ok := rl.clients[ip]
+	if !ok {
+		c = &clientLimiter{limiter: rate.NewLimiter(rl.limit, rl.burst)}
+		rl.clients[ip] = c
+	}
+	c.lastSeen = now
+	return c.limiter
+}
+
+// Middleware rejects requests over the limit with 429 Too Many Requests and a Retry-After header
+func (rl *rateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter := rl.limiterFor(clientIP(r))
+
+		reservation := limiter.Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			reservation.Cancel()
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(models.APIResponse{
+				Success: false,
+				Error:   "Too many requests, please slow down",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// peerAddrKey is the context key for the address of the connection a request came in on
+type peerAddrKey struct{}
+
+// capturePeerAddr records the address of the connection a request came in on. It must run
+// before middleware.RealIP, which replaces RemoteAddr with whatever the X-Real-IP and
+// X-Forwarded-For headers claim, so clients can't dodge the rate limit by varying them.
+func capturePeerAddr(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), peerAddrKey{}, r.RemoteAddr)))
+	})
+}
+
+// clientIP returns the IP address of the connection the request came in on, ignoring
+// forwarding headers
+func clientIP(r *http.Request) string {
+	addr, ok := r.Context().Value(peerAddrKey{}).(string)
+	if !ok {
+		addr = r.RemoteAddr
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// limitedHandler chains the middleware the router puts in front of the API
+func limitedHandler(requestsPerMinute int) http.Handler {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	return capturePeerAddr(middleware.RealIP(newRateLimiter(requestsPerMinute).Middleware(ok)))
+}
+
+func TestRateLimitIgnoresForwardingHeaders(t *testing.T) {
+	h := limitedHandler(3)
+
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/topics", nil)
+		req.RemoteAddr = "192.0.2.1:5000"
+		// A new forged address on every request must not buy a fresh limit
+		req.Header.Set("X-Forwarded-For", "198.51.100."+strconv.Itoa(i))
+		req.Header.Set("X-Real-IP", "203.0.113."+strconv.Itoa(i))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		want := http.StatusOK
+		if i >= 3 {
+			want = http.StatusTooManyRequests
+		}
+		if rec.Code != want {
+			t.Errorf("request %d: status %d, want %d", i, rec.Code, want)
+		}
+	}
+}
+
+func TestRateLimitPerConnectionAddress(t *testing.T) {
+	h := limitedHandler(1)
+
+	for _, addr := range []string{"192.0.2.1:5000", "192.0.2.2:5000"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/topics", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("first request from %s: status %d, want %d", addr, rec.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       string
+	}{
+		{"host and port", "192.0.2.1:5000", "192.0.2.1"},
+		{"IPv6", "[2001:db8::1]:5000", "2001:db8::1"},
+		{"no port", "192.0.2.1", "192.0.2.1"},
+		{"unix socket", "@", "@"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if got := clientIP(req); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,18 +1,25 @@
 package api
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/thinkscotty/maggpi_go/internal/handlers"
+	"github.com/thinkscotty/maggpi_go/internal/logging"
 )
 
-// NewRouter creates and configures the HTTP router
-func NewRouter(h *handlers.Handlers, staticDir string) *chi.Mux {
+// NewRouter creates and configures the HTTP router.
+// apiRateLimit is the number of internal API requests allowed per client per minute (0 disables the limit).
+func NewRouter(h *handlers.Handlers, staticDir string, apiRateLimit int) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Middleware
+	r.Use(middleware.RequestID)
+	r.Use(capturePeerAddr) // before RealIP, so the rate limit keys on the real connection
+	r.Use(middleware.RealIP)
+	r.Use(requestLogger)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Compress(5))
@@ -27,32 +34,78 @@ func NewRouter(h *handlers.Handlers, staticDir string) *chi.Mux {
 
 	// Internal API routes (for web UI)
 	r.Route("/api", func(r chi.Router) {
+		if apiRateLimit > 0 {
+			r.Use(newRateLimiter(apiRateLimit).Middleware)
+		}
+
 		// Topics
 		r.Get("/topics", h.GetTopics)
 		r.Post("/topics", h.CreateTopic)
 		r.Put("/topics/{id}", h.UpdateTopic)
 		r.Delete("/topics/{id}", h.DeleteTopic)
 		r.Post("/topics/reorder", h.ReorderTopics)
+		r.Post("/topics/suggest", h.SuggestTopics)
+		r.Post("/topics/refresh-all", h.RefreshAllTopics)
+		r.Patch("/topics/{id}/enabled", h.SetTopicEnabled)
 		r.Post("/topics/{id}/refresh", h.RefreshTopic)
+		r.Post("/topics/{id}/preview", h.PreviewRefresh)
+		r.Get("/topics/{id}/history", h.GetTopicHistory)
+		r.Get("/topics/{id}/archive", h.GetTopicArchive)
+		r.Get("/topics/{id}/tags", h.GetTopicTags)
 
 		// Sources
+		r.Get("/topics/{id}/sources", h.GetTopicSources)
 		r.Post("/topics/{id}/sources", h.AddSource)
+		r.Post("/topics/{id}/sources/bulk", h.AddSourcesBulk)
 		r.Delete("/topics/{id}/sources/{sourceId}", h.DeleteSource)
+		r.Post("/topics/{id}/sources/reactivate-all", h.ReactivateAllSources)
+		r.Post("/topics/{id}/sources/discover/preview", h.PreviewDiscovery)
+		r.Post("/sources/preview", h.PreviewSource)
+		r.Post("/sources/{sourceId}/reactivate", h.ReactivateSource)
+
+		// Stories
+		r.Post("/stories/{id}/translate", h.TranslateStory)
+
+		// Import
+		r.Post("/import/opml", h.ImportOPML)
 
 		// Settings
 		r.Get("/settings", h.GetSettings)
 		r.Put("/settings", h.UpdateSettings)
+		r.Post("/settings/test-gemini", h.TestGeminiKey)
+		r.Post("/settings/test-key", h.TestGeminiKey)
+		r.Get("/gemini/models", h.GetGeminiModels)
+
+		// Maintenance
+		r.Post("/maintenance", h.RunMaintenance)
 
 		// Status
+		r.Get("/jobs", h.GetJobs)
+		r.Get("/scheduler/stats", h.GetSchedulerStats)
+		r.Get("/scheduler/health", h.GetSchedulerHealth)
+		r.Get("/usage", h.GetUsage)
 		r.Get("/status", h.APIGetRefreshStatus)
+		r.Get("/status/stream", h.APIStatusStream)
 	})
 
 	// External API routes (for client devices)
 	r.Route("/v1", func(r chi.Router) {
 		r.Get("/stories", h.APIGetAllStories)
 		r.Get("/topics/{id}/stories", h.APIGetTopicStories)
+		r.Get("/topics/{id}/feed.json", h.APIGetTopicFeed)
 		r.Get("/topics", h.GetTopics)
 	})
 
 	return r
 }
+
+// requestLogger gives each request a logger tagged with its request ID, for handlers to
+// log with, and returns the ID in the X-Request-Id header so a client can quote it
+func requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := middleware.GetReqID(r.Context())
+		w.Header().Set(middleware.RequestIDHeader, id)
+		logger := slog.With("request_id", id)
+		next.ServeHTTP(w, r.WithContext(logging.WithLogger(r.Context(), logger)))
+	})
+}
@@ -2,8 +2,10 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // Config holds application configuration
@@ -13,19 +15,65 @@ type Config struct {
 	DataDir      string `json:"data_dir"`
 	DatabasePath string `json:"database_path"`
 	Debug        bool   `json:"debug"`
+	APIRateLimit int    `json:"api_rate_limit"` // internal API requests per client per minute, 0 to disable
+	UnixSocket   string `json:"unix_socket"`    // if set, listen on this Unix socket path instead of host:port
+	LogFormat    string `json:"log_format"`     // "text" or "json"
+	TLSCertFile  string `json:"tls_cert_file"`  // with TLSKeyFile, serve HTTPS using this PEM certificate (chain)
+	TLSKeyFile   string `json:"tls_key_file"`   // PEM private key for TLSCertFile
+	// Server timeouts in seconds, 0 for none. Slow endpoints such as the dry-run refresh
+	// extend the write timeout for themselves.
+	ReadTimeoutSeconds  int `json:"read_timeout_seconds"`
+	WriteTimeoutSeconds int `json:"write_timeout_seconds"`
+	IdleTimeoutSeconds  int `json:"idle_timeout_seconds"`
 }
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() Config {
 	return Config{
-		Port:         7979,
-		Host:         "0.0.0.0",
-		DataDir:      "./data",
-		DatabasePath: "./data/maggpi.db",
-		Debug:        false,
+		Port:                7979,
+		Host:                "0.0.0.0",
+		DataDir:             "./data",
+		DatabasePath:        "./data/maggpi.db",
+		Debug:               false,
+		APIRateLimit:        120,
+		LogFormat:           "text",
+		ReadTimeoutSeconds:  15,
+		WriteTimeoutSeconds: 60,
+		IdleTimeoutSeconds:  60,
 	}
 }
 
+// Validate checks settings that can't be used as given
+func (c *Config) Validate() error {
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return errors.New("tls_cert_file and tls_key_file must be set together")
+	}
+	if c.ReadTimeoutSeconds < 0 || c.WriteTimeoutSeconds < 0 || c.IdleTimeoutSeconds < 0 {
+		return errors.New("server timeouts must not be negative")
+	}
+	return nil
+}
+
+// TLSEnabled reports whether the server should serve HTTPS
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// ReadTimeout returns the server read timeout
+func (c *Config) ReadTimeout() time.Duration {
+	return time.Duration(c.ReadTimeoutSeconds) * time.Second
+}
+
+// WriteTimeout returns the server write timeout
+func (c *Config) WriteTimeout() time.Duration {
+	return time.Duration(c.WriteTimeoutSeconds) * time.Second
+}
+
+// IdleTimeout returns the server keep-alive idle timeout
+func (c *Config) IdleTimeout() time.Duration {
+	return time.Duration(c.IdleTimeoutSeconds) * time.Second
+}
+
 // Load loads configuration from a JSON file, creating it with defaults if it doesn't exist
 func Load(path string) (*Config, error) {
 	cfg := DefaultConfig()
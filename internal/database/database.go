@@ -2,18 +2,29 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/thinkscotty/maggpi_go/internal/models"
 	_ "modernc.org/sqlite"
 )
 
+// ErrTopicExists is returned when creating or renaming a topic to a name another topic
+// already has, ignoring case
+var ErrTopicExists = errors.New("a topic with that name already exists")
+
+// ErrSourceExists is returned when adding a source URL its topic already has
+var ErrSourceExists = errors.New("the topic already has that source")
+
 // DB wraps the SQLite database connection
 type DB struct {
 	conn *sql.DB
+	path string
 }
 
 // New creates a new database connection and initializes the schema
@@ -30,9 +41,9 @@ func New(dbPath string) (*DB, error) {
 
 	// Configure connection pool for stability
 	// SQLite works best with limited connections due to file locking
-	conn.SetMaxOpenConns(1)                  // SQLite only supports one writer at a time
-	conn.SetMaxIdleConns(1)                  // Keep one connection ready
-	conn.SetConnMaxLifetime(time.Hour)       // Reconnect after an hour to prevent stale connections
+	conn.SetMaxOpenConns(1)                   // SQLite only supports one writer at a time
+	conn.SetMaxIdleConns(1)                   // Keep one connection ready
+	conn.SetConnMaxLifetime(time.Hour)        // Reconnect after an hour to prevent stale connections
 	conn.SetConnMaxIdleTime(30 * time.Minute) // Close idle connections after 30 minutes
 
 	// Enable foreign keys and WAL mode for better performance
@@ -48,7 +59,7 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to set pragmas: %w", err)
 	}
 
-	db := &DB{conn: conn}
+	db := &DB{conn: conn, path: dbPath}
 	if err := db.migrate(); err != nil {
 		conn.Close()
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
@@ -62,6 +73,51 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
+// Maintain checkpoints and truncates the WAL file, then vacuums the database to
+// reclaim space left by deleted rows. Callers must make sure no refresh is writing.
+func (db *DB) Maintain() error {
+	before := db.fileSize()
+
+	if _, err := db.conn.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("wal checkpoint failed: %w", err)
+	}
+	if _, err := db.conn.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("vacuum failed: %w", err)
+	}
+	// VACUUM writes through the WAL, so truncate it again afterwards
+	if _, err := db.conn.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("wal checkpoint failed: %w", err)
+	}
+
+	slog.Info("Database maintenance complete", "before", formatBytes(before), "after", formatBytes(db.fileSize()))
+	return nil
+}
+
+// fileSize returns the combined size of the database and its WAL file
+func (db *DB) fileSize() int64 {
+	var total int64
+	for _, p := range []string{db.path, db.path + "-wal"} {
+		if info, err := os.Stat(p); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// formatBytes formats a byte count for logging
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 // migrate runs database migrations
 func (db *DB) migrate() error {
 	schema := `
@@ -70,6 +126,16 @@ func (db *DB) migrate() error {
 		name TEXT NOT NULL,
 		description TEXT NOT NULL,
 		position INTEGER NOT NULL DEFAULT 0,
+		cron_schedule TEXT DEFAULT '',
+		auto_refresh INTEGER DEFAULT 1,
+		auto_discover INTEGER DEFAULT 1,
+		sourcing_prompt TEXT,
+		summarizing_prompt TEXT,
+		story_retention_count INTEGER,
+		discover_source_count INTEGER,
+		enabled INTEGER DEFAULT 1,
+		story_language TEXT,
+		summary_length TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -83,6 +149,9 @@ func (db *DB) migrate() error {
 		is_active BOOLEAN DEFAULT TRUE,
 		failure_count INTEGER DEFAULT 0,
 		last_error TEXT DEFAULT '',
+		last_scraped_at DATETIME,
+		last_success_at DATETIME,
+		content_hash TEXT DEFAULT '',
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (topic_id) REFERENCES topics(id) ON DELETE CASCADE
 	);
@@ -98,10 +167,27 @@ func (db *DB) migrate() error {
 		image_url TEXT,
 		published_at DATETIME,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		embedding BLOB,
+		importance INTEGER DEFAULT 5,
+		summary_length TEXT DEFAULT '',
 		FOREIGN KEY (topic_id) REFERENCES topics(id) ON DELETE CASCADE,
 		FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE SET NULL
 	);
 
+	CREATE TABLE IF NOT EXISTS story_tags (
+		story_id INTEGER NOT NULL,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (story_id, tag),
+		FOREIGN KEY (story_id) REFERENCES stories(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS story_citations (
+		story_id INTEGER NOT NULL,
+		url TEXT NOT NULL,
+		PRIMARY KEY (story_id, url),
+		FOREIGN KEY (story_id) REFERENCES stories(id) ON DELETE CASCADE
+	);
+
 	CREATE TABLE IF NOT EXISTS settings (
 		id INTEGER PRIMARY KEY CHECK (id = 1),
 		refresh_interval_minutes INTEGER DEFAULT 120,
@@ -115,7 +201,55 @@ func (db *DB) migrate() error {
 		dashboard_title TEXT DEFAULT 'Dashboard',
 		dashboard_subtitle TEXT DEFAULT 'Your personalized news feed',
 		story_title_font_size REAL DEFAULT 1.0,
-		story_text_font_size REAL DEFAULT 0.9
+		story_text_font_size REAL DEFAULT 0.9,
+		quiet_hours_start TEXT DEFAULT '',
+		quiet_hours_end TEXT DEFAULT '',
+		provider TEXT DEFAULT 'gemini',
+		openai_base_url TEXT DEFAULT '',
+		openai_model TEXT DEFAULT '',
+		max_concurrent_refreshes INTEGER DEFAULT 1,
+		refresh_stagger_seconds INTEGER DEFAULT 30,
+		reddit_include_link_posts BOOLEAN DEFAULT FALSE,
+		reddit_min_words INTEGER DEFAULT 100,
+		reddit_min_score INTEGER DEFAULT 0,
+		reddit_max_age_hours INTEGER DEFAULT 0,
+		story_retention_count INTEGER DEFAULT 0,
+		story_retention_days INTEGER DEFAULT 0,
+		scrape_parallelism INTEGER DEFAULT 2,
+		webhook_url TEXT DEFAULT '',
+		daily_request_budget INTEGER DEFAULT 0,
+		daily_token_budget INTEGER DEFAULT 0,
+		boilerplate_patterns TEXT DEFAULT '',
+		headless_fallback BOOLEAN DEFAULT FALSE,
+		archive_stories BOOLEAN DEFAULT FALSE,
+		archive_retention_days INTEGER DEFAULT 90,
+		gemini_model TEXT DEFAULT 'gemini-2.0-flash',
+		max_source_chars INTEGER DEFAULT 10000,
+		max_prompt_chars INTEGER DEFAULT 60000,
+		summary_min_words INTEGER DEFAULT 75,
+		summary_max_words INTEGER DEFAULT 150,
+		prompt_token_price REAL DEFAULT 0.1,
+		output_token_price REAL DEFAULT 0.4,
+		gemini_timeout_seconds INTEGER DEFAULT 90,
+		search_grounding BOOLEAN DEFAULT TRUE,
+		openai_api_key TEXT DEFAULT '',
+		ollama_host TEXT DEFAULT 'http://localhost:11434',
+		ollama_model TEXT DEFAULT '',
+		ollama_timeout_seconds INTEGER DEFAULT 300,
+		gemini_temperature REAL DEFAULT 1.0,
+		gemini_top_p REAL DEFAULT 0.95,
+		gemini_max_output_tokens INTEGER DEFAULT 0,
+		gemini_safety_threshold TEXT DEFAULT '',
+		fallback_model TEXT DEFAULT '',
+		chunk_threshold_chars INTEGER DEFAULT 50000,
+		semantic_dedup BOOLEAN DEFAULT FALSE,
+		semantic_dedup_threshold REAL DEFAULT 0.88,
+		story_language TEXT DEFAULT '',
+		rank_by_importance BOOLEAN DEFAULT FALSE,
+		verify_discovered_sources BOOLEAN DEFAULT TRUE,
+		summary_length TEXT DEFAULT 'standard',
+		min_sources INTEGER DEFAULT 4,
+		max_sources INTEGER DEFAULT 8
 	);
 
 	CREATE TABLE IF NOT EXISTS refresh_status (
@@ -124,12 +258,70 @@ func (db *DB) migrate() error {
 		next_refresh DATETIME,
 		status TEXT DEFAULT 'pending',
 		error_message TEXT,
+		progress_stage TEXT DEFAULT '',
+		progress_percent INTEGER DEFAULT 0,
+		empty_refreshes INTEGER DEFAULT 0,
+		backoff_multiplier INTEGER DEFAULT 1,
+		FOREIGN KEY (topic_id) REFERENCES topics(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS refresh_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		topic_id INTEGER NOT NULL,
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME NOT NULL,
+		status TEXT NOT NULL,
+		stories_created INTEGER DEFAULT 0,
+		sources_scraped INTEGER DEFAULT 0,
+		sources_failed INTEGER DEFAULT 0,
+		error TEXT DEFAULT '',
+		prompt_tokens INTEGER DEFAULT 0,
+		output_tokens INTEGER DEFAULT 0,
+		cost REAL DEFAULT 0,
+		model TEXT DEFAULT '',
+		FOREIGN KEY (topic_id) REFERENCES topics(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS archived_stories (
+		id INTEGER PRIMARY KEY,
+		topic_id INTEGER NOT NULL,
+		source_id INTEGER,
+		title TEXT NOT NULL,
+		summary TEXT NOT NULL,
+		source_url TEXT NOT NULL,
+		source_title TEXT,
+		image_url TEXT,
+		published_at DATETIME,
+		created_at DATETIME,
+		importance INTEGER DEFAULT 5,
+		summary_length TEXT DEFAULT '',
+		archived_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (topic_id) REFERENCES topics(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS api_usage (
+		day TEXT PRIMARY KEY,
+		requests INTEGER DEFAULT 0,
+		tokens INTEGER DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS topic_usage (
+		day TEXT NOT NULL,
+		topic_id INTEGER NOT NULL,
+		requests INTEGER DEFAULT 0,
+		prompt_tokens INTEGER DEFAULT 0,
+		output_tokens INTEGER DEFAULT 0,
+		cost REAL DEFAULT 0,
+		PRIMARY KEY (day, topic_id),
 		FOREIGN KEY (topic_id) REFERENCES topics(id) ON DELETE CASCADE
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_stories_topic_id ON stories(topic_id);
 	CREATE INDEX IF NOT EXISTS idx_sources_topic_id ON sources(topic_id);
 	CREATE INDEX IF NOT EXISTS idx_stories_created_at ON stories(created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_story_tags_tag ON story_tags(tag);
+	CREATE INDEX IF NOT EXISTS idx_refresh_history_topic_id ON refresh_history(topic_id, id DESC);
+	CREATE INDEX IF NOT EXISTS idx_archived_stories_topic_id ON archived_stories(topic_id, created_at DESC);
 	`
 
 	if _, err := db.conn.Exec(schema); err != nil {
@@ -137,32 +329,343 @@ func (db *DB) migrate() error {
 	}
 
 	// Add new columns if they don't exist (for existing databases)
-	migrations := []string{
-		`ALTER TABLE settings ADD COLUMN dashboard_title TEXT DEFAULT 'Dashboard'`,
-		`ALTER TABLE settings ADD COLUMN dashboard_subtitle TEXT DEFAULT 'Your personalized news feed'`,
-		`ALTER TABLE settings ADD COLUMN story_title_font_size REAL DEFAULT 1.0`,
-		`ALTER TABLE settings ADD COLUMN story_text_font_size REAL DEFAULT 0.9`,
-		`ALTER TABLE sources ADD COLUMN is_active BOOLEAN DEFAULT TRUE`,
-		`ALTER TABLE sources ADD COLUMN failure_count INTEGER DEFAULT 0`,
-		`ALTER TABLE sources ADD COLUMN last_error TEXT DEFAULT ''`,
+	columns := []struct {
+		table, column, definition string
+	}{
+		{"settings", "dashboard_title", "TEXT DEFAULT 'Dashboard'"},
+		{"settings", "dashboard_subtitle", "TEXT DEFAULT 'Your personalized news feed'"},
+		{"settings", "story_title_font_size", "REAL DEFAULT 1.0"},
+		{"settings", "story_text_font_size", "REAL DEFAULT 0.9"},
+		{"settings", "quiet_hours_start", "TEXT DEFAULT ''"},
+		{"settings", "quiet_hours_end", "TEXT DEFAULT ''"},
+		{"settings", "provider", "TEXT DEFAULT 'gemini'"},
+		{"settings", "openai_base_url", "TEXT DEFAULT ''"},
+		{"settings", "openai_model", "TEXT DEFAULT ''"},
+		{"settings", "max_concurrent_refreshes", "INTEGER DEFAULT 1"},
+		{"settings", "refresh_stagger_seconds", "INTEGER DEFAULT 30"},
+		{"settings", "reddit_include_link_posts", "BOOLEAN DEFAULT FALSE"},
+		{"settings", "reddit_min_words", "INTEGER DEFAULT 100"},
+		{"settings", "reddit_min_score", "INTEGER DEFAULT 0"},
+		{"settings", "reddit_max_age_hours", "INTEGER DEFAULT 0"},
+		{"settings", "story_retention_count", "INTEGER DEFAULT 0"},
+		{"settings", "story_retention_days", "INTEGER DEFAULT 0"},
+		{"settings", "scrape_parallelism", "INTEGER DEFAULT 2"},
+		{"settings", "webhook_url", "TEXT DEFAULT ''"},
+		{"settings", "daily_request_budget", "INTEGER DEFAULT 0"},
+		{"settings", "daily_token_budget", "INTEGER DEFAULT 0"},
+		{"settings", "boilerplate_patterns", "TEXT DEFAULT ''"},
+		{"settings", "headless_fallback", "BOOLEAN DEFAULT FALSE"},
+		{"settings", "archive_stories", "BOOLEAN DEFAULT FALSE"},
+		{"settings", "archive_retention_days", "INTEGER DEFAULT 90"},
+		{"settings", "gemini_model", "TEXT DEFAULT 'gemini-2.0-flash'"},
+		{"settings", "max_source_chars", "INTEGER DEFAULT 10000"},
+		{"settings", "max_prompt_chars", "INTEGER DEFAULT 60000"},
+		{"settings", "summary_min_words", "INTEGER DEFAULT 75"},
+		{"settings", "summary_max_words", "INTEGER DEFAULT 150"},
+		{"settings", "prompt_token_price", "REAL DEFAULT 0.1"},
+		{"settings", "output_token_price", "REAL DEFAULT 0.4"},
+		{"settings", "gemini_timeout_seconds", "INTEGER DEFAULT 90"},
+		{"settings", "search_grounding", "BOOLEAN DEFAULT TRUE"},
+		{"settings", "openai_api_key", "TEXT DEFAULT ''"},
+		{"settings", "ollama_host", "TEXT DEFAULT 'http://localhost:11434'"},
+		{"settings", "ollama_model", "TEXT DEFAULT ''"},
+		{"settings", "ollama_timeout_seconds", "INTEGER DEFAULT 300"},
+		{"settings", "gemini_temperature", "REAL DEFAULT 1.0"},
+		{"settings", "gemini_top_p", "REAL DEFAULT 0.95"},
+		{"settings", "gemini_max_output_tokens", "INTEGER DEFAULT 0"},
+		{"settings", "gemini_safety_threshold", "TEXT DEFAULT ''"},
+		{"settings", "fallback_model", "TEXT DEFAULT ''"},
+		{"settings", "chunk_threshold_chars", "INTEGER DEFAULT 50000"},
+		{"settings", "semantic_dedup", "BOOLEAN DEFAULT FALSE"},
+		{"settings", "semantic_dedup_threshold", "REAL DEFAULT 0.88"},
+		{"settings", "story_language", "TEXT DEFAULT ''"},
+		{"settings", "rank_by_importance", "BOOLEAN DEFAULT FALSE"},
+		{"settings", "verify_discovered_sources", "BOOLEAN DEFAULT TRUE"},
+		{"settings", "summary_length", "TEXT DEFAULT 'standard'"},
+		{"settings", "min_sources", "INTEGER DEFAULT 4"},
+		{"settings", "max_sources", "INTEGER DEFAULT 8"},
+		{"sources", "is_active", "BOOLEAN DEFAULT TRUE"},
+		{"sources", "failure_count", "INTEGER DEFAULT 0"},
+		{"sources", "last_error", "TEXT DEFAULT ''"},
+		{"sources", "last_scraped_at", "DATETIME"},
+		{"sources", "last_success_at", "DATETIME"},
+		{"sources", "content_hash", "TEXT DEFAULT ''"},
+		{"refresh_status", "progress_stage", "TEXT DEFAULT ''"},
+		{"refresh_status", "progress_percent", "INTEGER DEFAULT 0"},
+		{"refresh_status", "empty_refreshes", "INTEGER DEFAULT 0"},
+		{"refresh_status", "backoff_multiplier", "INTEGER DEFAULT 1"},
+		{"topics", "cron_schedule", "TEXT DEFAULT ''"},
+		{"topics", "auto_refresh", "INTEGER DEFAULT 1"},
+		{"topics", "sourcing_prompt", "TEXT"},
+		{"topics", "summarizing_prompt", "TEXT"},
+		{"topics", "story_retention_count", "INTEGER"},
+		{"topics", "enabled", "INTEGER DEFAULT 1"},
+		{"topics", "story_language", "TEXT"},
+		{"topics", "summary_length", "TEXT"},
+		{"topics", "auto_discover", "INTEGER DEFAULT 1"},
+		{"topics", "discover_source_count", "INTEGER"},
+		{"refresh_history", "prompt_tokens", "INTEGER DEFAULT 0"},
+		{"refresh_history", "output_tokens", "INTEGER DEFAULT 0"},
+		{"refresh_history", "cost", "REAL DEFAULT 0"},
+		{"refresh_history", "model", "TEXT DEFAULT ''"},
+		{"stories", "embedding", "BLOB"},
+		{"stories", "importance", "INTEGER DEFAULT 5"},
+		{"archived_stories", "importance", "INTEGER DEFAULT 5"},
+		{"stories", "summary_length", "TEXT DEFAULT ''"},
+		{"archived_stories", "summary_length", "TEXT DEFAULT ''"},
+	}
+
+	for _, c := range columns {
+		if err := db.addColumnIfMissing(c.table, c.column, c.definition); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", c.table, c.column, err)
+		}
 	}
 
-	for _, migration := range migrations {
-		// Ignore errors for columns that already exist
-		db.conn.Exec(migration)
+	if err := db.uniqueTopicNames(); err != nil {
+		return fmt.Errorf("failed to make topic names unique: %w", err)
+	}
+	if err := db.uniqueSourceURLs(); err != nil {
+		return fmt.Errorf("failed to make source URLs unique: %w", err)
+	}
+	for _, table := range []string{"stories", "archived_stories"} {
+		if err := db.normalizePublishedTimes(table); err != nil {
+			return fmt.Errorf("failed to normalize %s publish times: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// storedTimeLayouts are the formats older versions wrote published_at in: time.Time's
+// String form, with a zone name or, for zones without one, the offset again
+var storedTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999 -0700 -0700",
+}
+
+// normalizePublishedTimes rewrites publish times stored by older versions, in the feed's
+// own time zone and Go's String format, as UTC in created_at's format so the two sort
+// together. Times that can't be read are cleared, falling back to created_at.
+func (db *DB) normalizePublishedTimes(table string) error {
+	rows, err := db.conn.Query(`
+		SELECT id, CAST(published_at AS TEXT) FROM ` + table + `
+		WHERE published_at IS NOT NULL AND published_at IS NOT datetime(published_at)
+	`)
+	if err != nil {
+		return err
+	}
+	fixed := make(map[int64]sql.NullString)
+	for rows.Next() {
+		var id int64
+		var stored string
+		if err := rows.Scan(&id, &stored); err != nil {
+			rows.Close()
+			return err
+		}
+		// Drop the monotonic clock reading time.Now() adds to its String form
+		stored, _, _ = strings.Cut(stored, " m=")
+		fixed[id] = sql.NullString{}
+		for _, layout := range storedTimeLayouts {
+			if t, err := time.Parse(layout, stored); err == nil {
+				fixed[id] = nullTime(t)
+				break
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
+	for id, published := range fixed {
+		if _, err := db.conn.Exec(`UPDATE `+table+` SET published_at = ? WHERE id = ?`, published, id); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// uniqueTopicNames adds the case-insensitive unique index on topic names. Databases from
+// before the index may hold duplicates, so those are renamed first by appending " (2)",
+// " (3)", ... to every topic but the oldest of each name.
+func (db *DB) uniqueTopicNames() error {
+	rows, err := db.conn.Query(`
+		SELECT t.id, t.name FROM topics t
+		WHERE EXISTS (SELECT 1 FROM topics o WHERE o.name = t.name COLLATE NOCASE AND o.id < t.id)
+		ORDER BY t.id
+	`)
+	if err != nil {
+		return err
+	}
+	type duplicate struct {
+		id   int64
+		name string
+	}
+	var duplicates []duplicate
+	for rows.Next() {
+		var d duplicate
+		if err := rows.Scan(&d.id, &d.name); err != nil {
+			rows.Close()
+			return err
+		}
+		duplicates = append(duplicates, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, d := range duplicates {
+		for n := 2; ; n++ {
+			name := fmt.Sprintf("%s (%d)", d.name, n)
+			taken, err := db.topicNameTaken(name, 0)
+			if err != nil {
+				return err
+			}
+			if taken {
+				continue
+			}
+			if _, err := db.conn.Exec(`UPDATE topics SET name = ? WHERE id = ?`, name, d.id); err != nil {
+				return err
+			}
+			slog.Info("Renamed duplicate topic", "topic_id", d.id, "topic", d.name, "new_name", name)
+			break
+		}
+	}
+
+	_, err = db.conn.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_topics_name ON topics(name COLLATE NOCASE)`)
+	return err
+}
+
+// uniqueSourceURLs adds the unique index on each topic's source URLs. Databases from
+// before the index may hold the same URL twice for a topic, so the extra copies are
+// deleted first, keeping a manual source over an AI one and then the oldest. Stories
+// from a deleted copy are moved to the kept source.
+func (db *DB) uniqueSourceURLs() error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// keep is the source kept for each duplicated URL
+	const keep = `(SELECT k.id FROM sources k WHERE k.topic_id = sources.topic_id AND k.url = sources.url
+		ORDER BY k.is_manual DESC, k.id ASC LIMIT 1)`
+	if _, err := tx.Exec(`
+		UPDATE stories SET source_id = (
+			SELECT ` + keep + ` FROM sources WHERE sources.id = stories.source_id
+		)
+		WHERE source_id IN (SELECT id FROM sources WHERE id != ` + keep + `)
+	`); err != nil {
+		return err
+	}
+	result, err := tx.Exec(`DELETE FROM sources WHERE id != ` + keep)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		slog.Info("Removed duplicate sources", "count", n)
+	}
+
+	if _, err := tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_sources_topic_url ON sources(topic_id, url)`); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addColumnIfMissing adds a column to a table unless it already exists
+func (db *DB) addColumnIfMissing(table, column, definition string) error {
+	exists, err := db.columnExists(table, column)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = db.conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// columnExists reports whether a table has a column with the given name
+func (db *DB) columnExists(table, column string) (bool, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
 // Topic operations
 
+// topicColumns lists the topic columns in the order expected by scanTopic
+const topicColumns = `id, name, description, position, cron_schedule, auto_refresh, auto_discover, sourcing_prompt,
+	summarizing_prompt, story_retention_count, discover_source_count, enabled, story_language, summary_length, created_at, updated_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanFunc adapts a function to rowScanner, letting a scan helper be reused
+// on rows that select extra columns after the ones it knows about
+type scanFunc func(dest ...interface{}) error
+
+func (f scanFunc) Scan(dest ...interface{}) error { return f(dest...) }
+
+// scanTopic scans a row selected with topicColumns into a Topic
+func scanTopic(row rowScanner) (models.Topic, error) {
+	var t models.Topic
+	var cronSchedule, sourcingPrompt, summarizingPrompt, storyLanguage, summaryLength sql.NullString
+	var autoRefresh, autoDiscover, enabled sql.NullBool
+	var retentionCount, sourceCount sql.NullInt64
+	err := row.Scan(&t.ID, &t.Name, &t.Description, &t.Position, &cronSchedule, &autoRefresh, &autoDiscover, &sourcingPrompt,
+		&summarizingPrompt, &retentionCount, &sourceCount, &enabled, &storyLanguage, &summaryLength, &t.CreatedAt, &t.UpdatedAt)
+	if cronSchedule.Valid {
+		t.CronSchedule = cronSchedule.String
+	}
+	t.AutoRefresh = !autoRefresh.Valid || autoRefresh.Bool
+	t.AutoDiscover = !autoDiscover.Valid || autoDiscover.Bool
+	t.Enabled = !enabled.Valid || enabled.Bool
+	if sourcingPrompt.Valid {
+		t.SourcingPrompt = sourcingPrompt.String
+	}
+	if summarizingPrompt.Valid {
+		t.SummarizingPrompt = summarizingPrompt.String
+	}
+	if retentionCount.Valid {
+		n := int(retentionCount.Int64)
+		t.StoryRetentionCount = &n
+	}
+	if sourceCount.Valid {
+		n := int(sourceCount.Int64)
+		t.DiscoverSourceCount = &n
+	}
+	if storyLanguage.Valid {
+		t.StoryLanguage = storyLanguage.String
+	}
+	if summaryLength.Valid {
+		t.SummaryLength = summaryLength.String
+	}
+	return t, err
+}
+
 // GetTopics returns all topics ordered by position
 func (db *DB) GetTopics() ([]models.Topic, error) {
-	rows, err := db.conn.Query(`
-		SELECT id, name, description, position, created_at, updated_at
-		FROM topics ORDER BY position ASC
-	`)
+	rows, err := db.conn.Query(`SELECT ` + topicColumns + ` FROM topics ORDER BY position ASC`)
 	if err != nil {
 		return nil, err
 	}
@@ -170,8 +673,8 @@ func (db *DB) GetTopics() ([]models.Topic, error) {
 
 	var topics []models.Topic
 	for rows.Next() {
-		var t models.Topic
-		if err := rows.Scan(&t.ID, &t.Name, &t.Description, &t.Position, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		t, err := scanTopic(rows)
+		if err != nil {
 			return nil, err
 		}
 		topics = append(topics, t)
@@ -179,13 +682,44 @@ func (db *DB) GetTopics() ([]models.Topic, error) {
 	return topics, rows.Err()
 }
 
+// GetTopicsWithMeta returns all topics ordered by position, each with its number of
+// stories and sources and when it was last refreshed, in one query
+func (db *DB) GetTopicsWithMeta() ([]models.TopicWithMeta, error) {
+	rows, err := db.conn.Query(`
+		SELECT ` + topicColumns + `, COALESCE(st.n, 0), COALESCE(so.n, 0), rs.last_refresh
+		FROM topics
+		LEFT JOIN (SELECT topic_id, COUNT(*) AS n FROM stories GROUP BY topic_id) st ON st.topic_id = topics.id
+		LEFT JOIN (SELECT topic_id, COUNT(*) AS n FROM sources GROUP BY topic_id) so ON so.topic_id = topics.id
+		LEFT JOIN refresh_status rs ON rs.topic_id = topics.id
+		ORDER BY position ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var topics []models.TopicWithMeta
+	for rows.Next() {
+		var tm models.TopicWithMeta
+		var lastRefresh sql.NullTime
+		t, err := scanTopic(scanFunc(func(dest ...interface{}) error {
+			return rows.Scan(append(dest, &tm.StoryCount, &tm.SourceCount, &lastRefresh)...)
+		}))
+		if err != nil {
+			return nil, err
+		}
+		tm.Topic = t
+		if lastRefresh.Valid && !lastRefresh.Time.IsZero() {
+			tm.LastRefresh = &lastRefresh.Time
+		}
+		topics = append(topics, tm)
+	}
+	return topics, rows.Err()
+}
+
 // GetTopic returns a single topic by ID
 func (db *DB) GetTopic(id int64) (*models.Topic, error) {
-	var t models.Topic
-	err := db.conn.QueryRow(`
-		SELECT id, name, description, position, created_at, updated_at
-		FROM topics WHERE id = ?
-	`, id).Scan(&t.ID, &t.Name, &t.Description, &t.Position, &t.CreatedAt, &t.UpdatedAt)
+	t, err := scanTopic(db.conn.QueryRow(`SELECT `+topicColumns+` FROM topics WHERE id = ?`, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -195,8 +729,39 @@ func (db *DB) GetTopic(id int64) (*models.Topic, error) {
 	return &t, nil
 }
 
-// CreateTopic creates a new topic
-func (db *DB) CreateTopic(name, description string) (*models.Topic, error) {
+// NormalizeTopicName trims a topic name and collapses runs of whitespace inside it to single spaces
+func NormalizeTopicName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// topicNameTaken reports whether a topic other than excludeID already has the name, ignoring case
+func (db *DB) topicNameTaken(name string, excludeID int64) (bool, error) {
+	var exists bool
+	err := db.conn.QueryRow(`SELECT EXISTS (SELECT 1 FROM topics WHERE name = ? COLLATE NOCASE AND id != ?)`,
+		name, excludeID).Scan(&exists)
+	return exists, err
+}
+
+// uniqueNameError maps a unique constraint violation on the topic name to ErrTopicExists,
+// for the rare case two requests race past the topicNameTaken check
+func uniqueNameError(err error) error {
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return ErrTopicExists
+	}
+	return err
+}
+
+// CreateTopic creates a new topic at the end of the list. The name is normalized first,
+// and ErrTopicExists is returned if another topic already has it. AutoRefresh and
+// AutoDiscover are stored as given, so new topics should start from models.NewTopic.
+func (db *DB) CreateTopic(t *models.Topic) (*models.Topic, error) {
+	t.Name = NormalizeTopicName(t.Name)
+	if taken, err := db.topicNameTaken(t.Name, 0); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, ErrTopicExists
+	}
+
 	// Get max position
 	var maxPos sql.NullInt64
 	db.conn.QueryRow("SELECT MAX(position) FROM topics").Scan(&maxPos)
@@ -206,25 +771,79 @@ func (db *DB) CreateTopic(name, description string) (*models.Topic, error) {
 	}
 
 	result, err := db.conn.Exec(`
-		INSERT INTO topics (name, description, position) VALUES (?, ?, ?)
-	`, name, description, position)
+		INSERT INTO topics (name, description, position, cron_schedule, auto_refresh, auto_discover, sourcing_prompt, summarizing_prompt,
+		                    story_retention_count, discover_source_count, story_language, summary_length)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, t.Name, t.Description, position, t.CronSchedule, t.AutoRefresh, t.AutoDiscover, nullIfEmpty(t.SourcingPrompt), nullIfEmpty(t.SummarizingPrompt),
+		t.StoryRetentionCount, t.DiscoverSourceCount, nullIfEmpty(t.StoryLanguage), nullIfEmpty(t.SummaryLength))
 	if err != nil {
-		return nil, err
+		return nil, uniqueNameError(err)
 	}
 
 	id, _ := result.LastInsertId()
 	return db.GetTopic(id)
 }
 
-// UpdateTopic updates an existing topic
-func (db *DB) UpdateTopic(id int64, name, description string) error {
+// defaultTopics are the topics a fresh install starts with
+var defaultTopics = []struct{ name, description string }{
+	{"World News", "Major international news and current events from around the globe. Focus on significant political developments, international relations, and major world events."},
+	{"Formula 1", "Formula 1 racing news including race results, driver standings, team updates, technical regulations, and breaking news from the F1 paddock."},
+	{"Science News", "Latest scientific discoveries and research breakthroughs across all fields including physics, biology, astronomy, climate science, and medical research."},
+	{"Tech News", "Technology industry news including product launches, company updates, software releases, AI developments, and emerging tech trends."},
+}
+
+// SeedDefaultTopics adds the default topics if the database has no topics yet. They have
+// no sources, so they find their own on their first refresh.
+func (db *DB) SeedDefaultTopics() error {
+	topics, err := db.GetTopics()
+	if err != nil {
+		return err
+	}
+	if len(topics) > 0 {
+		return nil
+	}
+
+	for _, d := range defaultTopics {
+		t := models.NewTopic(d.name, d.description)
+		if _, err := db.CreateTopic(&t); err != nil {
+			return fmt.Errorf("failed to create topic %s: %w", t.Name, err)
+		}
+		slog.Info("Created default topic", "topic", t.Name)
+	}
+	return nil
+}
+
+// UpdateTopic updates the editable fields of an existing topic. The name is normalized
+// first, and ErrTopicExists is returned if another topic already has it.
+func (db *DB) UpdateTopic(t *models.Topic) error {
+	t.Name = NormalizeTopicName(t.Name)
+	if taken, err := db.topicNameTaken(t.Name, t.ID); err != nil {
+		return err
+	} else if taken {
+		return ErrTopicExists
+	}
+
 	_, err := db.conn.Exec(`
-		UPDATE topics SET name = ?, description = ?, updated_at = CURRENT_TIMESTAMP
+		UPDATE topics SET name = ?, description = ?, cron_schedule = ?, auto_refresh = ?, auto_discover = ?, sourcing_prompt = ?,
+			summarizing_prompt = ?, story_retention_count = ?, discover_source_count = ?, story_language = ?, summary_length = ?,
+			updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
-	`, name, description, id)
+	`, t.Name, t.Description, t.CronSchedule, t.AutoRefresh, t.AutoDiscover, nullIfEmpty(t.SourcingPrompt), nullIfEmpty(t.SummarizingPrompt),
+		t.StoryRetentionCount, t.DiscoverSourceCount, nullIfEmpty(t.StoryLanguage), nullIfEmpty(t.SummaryLength), t.ID)
+	return uniqueNameError(err)
+}
+
+// SetTopicEnabled enables or disables all refreshes of a topic
+func (db *DB) SetTopicEnabled(id int64, enabled bool) error {
+	_, err := db.conn.Exec(`UPDATE topics SET enabled = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, enabled, id)
 	return err
 }
 
+// nullIfEmpty stores empty optional text as NULL
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
 // DeleteTopic deletes a topic and all its related data
 func (db *DB) DeleteTopic(id int64) error {
 	_, err := db.conn.Exec("DELETE FROM topics WHERE id = ?", id)
@@ -250,12 +869,35 @@ func (db *DB) ReorderTopics(topicIDs []int64) error {
 
 // Source operations
 
-// GetSourcesForTopic returns all sources for a topic
-func (db *DB) GetSourcesForTopic(topicID int64) ([]models.Source, error) {
-	rows, err := db.conn.Query(`
-		SELECT id, topic_id, url, name, is_manual, is_active, failure_count, last_error, created_at
-		FROM sources WHERE topic_id = ?
-	`, topicID)
+// sourceColumns lists the source columns in the order expected by scanSource
+const sourceColumns = `id, topic_id, url, name, is_manual, is_active, failure_count, last_error,
+	last_scraped_at, last_success_at, content_hash, created_at`
+
+// scanSource scans a row selected with sourceColumns into a Source
+func scanSource(row rowScanner) (models.Source, error) {
+	var s models.Source
+	var lastError, contentHash sql.NullString
+	var lastScraped, lastSuccess sql.NullTime
+	err := row.Scan(&s.ID, &s.TopicID, &s.URL, &s.Name, &s.IsManual, &s.IsActive, &s.FailureCount, &lastError,
+		&lastScraped, &lastSuccess, &contentHash, &s.CreatedAt)
+	if lastError.Valid {
+		s.LastError = lastError.String
+	}
+	if contentHash.Valid {
+		s.ContentHash = contentHash.String
+	}
+	if lastScraped.Valid {
+		s.LastScrapedAt = &lastScraped.Time
+	}
+	if lastSuccess.Valid {
+		s.LastSuccessAt = &lastSuccess.Time
+	}
+	return s, err
+}
+
+// querySources runs a source query and scans all resulting rows
+func (db *DB) querySources(query string, args ...interface{}) ([]models.Source, error) {
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -263,8 +905,8 @@ func (db *DB) GetSourcesForTopic(topicID int64) ([]models.Source, error) {
 
 	var sources []models.Source
 	for rows.Next() {
-		var s models.Source
-		if err := rows.Scan(&s.ID, &s.TopicID, &s.URL, &s.Name, &s.IsManual, &s.IsActive, &s.FailureCount, &s.LastError, &s.CreatedAt); err != nil {
+		s, err := scanSource(rows)
+		if err != nil {
 			return nil, err
 		}
 		sources = append(sources, s)
@@ -272,26 +914,39 @@ func (db *DB) GetSourcesForTopic(topicID int64) ([]models.Source, error) {
 	return sources, rows.Err()
 }
 
-// AddSource adds a new source to a topic
+// GetSourcesForTopic returns all sources for a topic
+func (db *DB) GetSourcesForTopic(topicID int64) ([]models.Source, error) {
+	return db.querySources(`SELECT `+sourceColumns+` FROM sources WHERE topic_id = ?`, topicID)
+}
+
+// GetSource returns a single source by ID, or nil if it doesn't exist
+func (db *DB) GetSource(id int64) (*models.Source, error) {
+	s, err := scanSource(db.conn.QueryRow(`SELECT `+sourceColumns+` FROM sources WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// AddSource adds a new source to a topic, returning ErrSourceExists if the topic already
+// has the URL
 func (db *DB) AddSource(topicID int64, url, name string, isManual bool) (*models.Source, error) {
 	result, err := db.conn.Exec(`
 		INSERT INTO sources (topic_id, url, name, is_manual, is_active, failure_count, last_error)
 		VALUES (?, ?, ?, ?, TRUE, 0, '')
 	`, topicID, url, name, isManual)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return nil, ErrSourceExists
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	id, _ := result.LastInsertId()
-	var s models.Source
-	err = db.conn.QueryRow(`
-		SELECT id, topic_id, url, name, is_manual, is_active, failure_count, last_error, created_at
-		FROM sources WHERE id = ?
-	`, id).Scan(&s.ID, &s.TopicID, &s.URL, &s.Name, &s.IsManual, &s.IsActive, &s.FailureCount, &s.LastError, &s.CreatedAt)
-	if err != nil {
-		return nil, err
-	}
-	return &s, nil
+	return db.GetSource(id)
 }
 
 // DeleteSource removes a source
@@ -315,110 +970,478 @@ func (db *DB) UpdateSourceStatus(sourceID int64, isActive bool, failureCount int
 	return err
 }
 
-// GetActiveSourcesForTopic returns only active sources for a topic
-func (db *DB) GetActiveSourcesForTopic(topicID int64) ([]models.Source, error) {
-	rows, err := db.conn.Query(`
-		SELECT id, topic_id, url, name, is_manual, is_active, failure_count, last_error, created_at
-		FROM sources WHERE topic_id = ? AND is_active = TRUE
-	`, topicID)
-	if err != nil {
-		return nil, err
+// RecordSourceScrape records when a source was last scraped and, if it succeeded, last succeeded
+func (db *DB) RecordSourceScrape(sourceID int64, success bool, at time.Time) error {
+	if success {
+		_, err := db.conn.Exec(`UPDATE sources SET last_scraped_at = ?, last_success_at = ? WHERE id = ?`, at, at, sourceID)
+		return err
 	}
-	defer rows.Close()
+	_, err := db.conn.Exec(`UPDATE sources SET last_scraped_at = ? WHERE id = ?`, at, sourceID)
+	return err
+}
 
-	var sources []models.Source
-	for rows.Next() {
-		var s models.Source
-		if err := rows.Scan(&s.ID, &s.TopicID, &s.URL, &s.Name, &s.IsManual, &s.IsActive, &s.FailureCount, &s.LastError, &s.CreatedAt); err != nil {
-			return nil, err
-		}
-		sources = append(sources, s)
-	}
-	return sources, rows.Err()
+// UpdateSourceContentHash stores the hash of the content last summarized from a source
+func (db *DB) UpdateSourceContentHash(sourceID int64, hash string) error {
+	_, err := db.conn.Exec(`UPDATE sources SET content_hash = ? WHERE id = ?`, hash, sourceID)
+	return err
+}
+
+// GetActiveSourcesForTopic returns only active sources for a topic
+func (db *DB) GetActiveSourcesForTopic(topicID int64) ([]models.Source, error) {
+	return db.querySources(`SELECT `+sourceColumns+` FROM sources WHERE topic_id = ? AND is_active = TRUE`, topicID)
 }
 
 // Story operations
 
-// GetStoriesForTopic returns recent stories for a topic
-func (db *DB) GetStoriesForTopic(topicID int64, limit int) ([]models.Story, error) {
-	rows, err := db.conn.Query(`
-		SELECT id, topic_id, source_id, title, summary, source_url, source_title, image_url, published_at, created_at
-		FROM stories WHERE topic_id = ?
-		ORDER BY created_at DESC LIMIT ?
-	`, topicID, limit)
+// Fields story lists can be sorted by, newest first
+const (
+	OrderByCreated   = "created_at"   // when the story was stored
+	OrderByPublished = "published_at" // when the source published it, falling back to when it was stored
+)
+
+// StoryOrder says how a story list is sorted
+type StoryOrder struct {
+	By           string // OrderByCreated or OrderByPublished; empty means OrderByCreated
+	ByImportance bool   // most important first, newest first within each score
+}
+
+// ParseOrderBy checks a requested sort field against the ones story lists allow. Empty
+// means OrderByCreated.
+func ParseOrderBy(field string) (string, error) {
+	switch field {
+	case "", OrderByCreated:
+		return OrderByCreated, nil
+	case OrderByPublished:
+		return OrderByPublished, nil
+	}
+	return "", fmt.Errorf("order_by must be %s or %s", OrderByCreated, OrderByPublished)
+}
+
+// storyOrder returns the ORDER BY terms for a story list. Only fixed column names are
+// returned, never the requested field itself, so nothing from a request reaches the SQL.
+func storyOrder(order StoryOrder) string {
+	terms := "created_at DESC"
+	if order.By == OrderByPublished {
+		terms = "COALESCE(published_at, created_at) DESC, created_at DESC"
+	}
+	if order.ByImportance {
+		terms = "importance DESC, " + terms
+	}
+	return terms
+}
+
+// GetStoriesForTopic returns recent stories for a topic
+func (db *DB) GetStoriesForTopic(topicID int64, limit int, order StoryOrder) ([]models.Story, error) {
+	return db.queryStories(`
+		SELECT `+storyFields+`
+		FROM stories WHERE topic_id = ?
+		ORDER BY `+storyOrder(order)+` LIMIT ?
+	`, topicID, limit)
+}
+
+// GetStory returns a story by ID, nil if there is none
+func (db *DB) GetStory(id int64) (*models.Story, error) {
+	s, err := scanStory(db.conn.QueryRow(`SELECT `+storyFields+` FROM stories WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	stories := []models.Story{s}
+	if err := db.loadStoryLists(stories); err != nil {
+		return nil, err
+	}
+	return &stories[0], nil
+}
+
+// GetStoriesForTopicBetween returns recent stories for a topic created between since
+// and until, inclusive
+func (db *DB) GetStoriesForTopicBetween(topicID int64, since, until time.Time, limit int, order StoryOrder) ([]models.Story, error) {
+	return db.queryStories(`
+		SELECT `+storyFields+`
+		FROM stories WHERE topic_id = ? AND created_at BETWEEN datetime(?) AND datetime(?)
+		ORDER BY `+storyOrder(order)+` LIMIT ?
+	`, topicID, sqliteTime(since), sqliteTime(until), limit)
+}
+
+// GetStoriesForTopicWithTag returns recent stories for a topic that carry tag, created
+// between since and until, inclusive
+func (db *DB) GetStoriesForTopicWithTag(topicID int64, tag string, since, until time.Time, limit int, order StoryOrder) ([]models.Story, error) {
+	return db.queryStories(`
+		SELECT `+storyFields+`
+		FROM stories WHERE topic_id = ? AND created_at BETWEEN datetime(?) AND datetime(?)
+			AND id IN (SELECT story_id FROM story_tags WHERE tag = ?)
+		ORDER BY `+storyOrder(order)+` LIMIT ?
+	`, topicID, sqliteTime(since), sqliteTime(until), tag, limit)
+}
+
+// GetTopicTagCounts returns the tags on a topic's stories with how many stories carry
+// each, most used first
+func (db *DB) GetTopicTagCounts(topicID int64) ([]models.TagCount, error) {
+	rows, err := db.conn.Query(`
+		SELECT t.tag, COUNT(*) FROM story_tags t
+		JOIN stories s ON s.id = t.story_id
+		WHERE s.topic_id = ?
+		GROUP BY t.tag
+		ORDER BY COUNT(*) DESC, t.tag ASC
+	`, topicID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var stories []models.Story
+	counts := []models.TagCount{}
 	for rows.Next() {
-		var s models.Story
-		var sourceID sql.NullInt64
-		var sourceTitle, imageURL sql.NullString
-		var publishedAt sql.NullTime
-		if err := rows.Scan(&s.ID, &s.TopicID, &sourceID, &s.Title, &s.Summary, &s.SourceURL, &sourceTitle, &imageURL, &publishedAt, &s.CreatedAt); err != nil {
+		var c models.TagCount
+		if err := rows.Scan(&c.Tag, &c.Count); err != nil {
 			return nil, err
 		}
-		if sourceID.Valid {
-			id := sourceID.Int64
-			s.SourceID = &id
-		}
-		if sourceTitle.Valid {
-			s.SourceTitle = sourceTitle.String
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// sqliteTimeLayout is the format CURRENT_TIMESTAMP stores times in, always UTC
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+// sqliteTime formats t the way CURRENT_TIMESTAMP stores times, for comparisons in SQL
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// nullTime formats t with sqliteTime for storing, or returns NULL for the zero time.
+// Stored times must share created_at's format and zone so they sort against it as text.
+func nullTime(t time.Time) sql.NullString {
+	return sql.NullString{String: sqliteTime(t), Valid: !t.IsZero()}
+}
+
+// queryStories runs a query selecting storyFields and scans the stories, with their tags
+// and citations
+func (db *DB) queryStories(query string, args ...interface{}) ([]models.Story, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stories []models.Story
+	for rows.Next() {
+		s, err := scanStory(rows)
+		if err != nil {
+			return nil, err
 		}
-		if imageURL.Valid {
-			s.ImageURL = imageURL.String
+		stories = append(stories, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := db.loadStoryLists(stories); err != nil {
+		return nil, err
+	}
+	return stories, nil
+}
+
+// loadStoryLists fills in the tags and citations of stories
+func (db *DB) loadStoryLists(stories []models.Story) error {
+	if err := db.loadStoryValues(stories, "story_tags", "tag", func(s *models.Story) *[]string { return &s.Tags }); err != nil {
+		return err
+	}
+	return db.loadStoryValues(stories, "story_citations", "url", func(s *models.Story) *[]string { return &s.Citations })
+}
+
+// loadStoryValues fills in one list field of stories from a table keyed by story_id,
+// with one query. Values keep the order they were inserted in.
+func (db *DB) loadStoryValues(stories []models.Story, table, column string, field func(*models.Story) *[]string) error {
+	if len(stories) == 0 {
+		return nil
+	}
+
+	index := make(map[int64]int, len(stories))
+	placeholders := make([]string, len(stories))
+	args := make([]interface{}, len(stories))
+	for i := range stories {
+		*field(&stories[i]) = []string{}
+		index[stories[i].ID] = i
+		placeholders[i] = "?"
+		args[i] = stories[i].ID
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT story_id, `+column+` FROM `+table+`
+		WHERE story_id IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY rowid
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var storyID int64
+		var value string
+		if err := rows.Scan(&storyID, &value); err != nil {
+			return err
 		}
-		if publishedAt.Valid {
-			s.PublishedAt = publishedAt.Time
+		if i, ok := index[storyID]; ok {
+			values := field(&stories[i])
+			*values = append(*values, value)
 		}
-		stories = append(stories, s)
 	}
-	return stories, rows.Err()
+	return rows.Err()
+}
+
+// scanStory scans a row of storyFields into a story
+func scanStory(row rowScanner) (models.Story, error) {
+	var s models.Story
+	var sourceID sql.NullInt64
+	var sourceTitle, imageURL, summaryLength sql.NullString
+	var publishedAt sql.NullTime
+	var importance sql.NullInt64
+	if err := row.Scan(&s.ID, &s.TopicID, &sourceID, &s.Title, &s.Summary, &s.SourceURL, &sourceTitle, &imageURL, &publishedAt, &s.CreatedAt,
+		&importance, &summaryLength); err != nil {
+		return s, err
+	}
+	s.Importance = 5
+	if importance.Valid {
+		s.Importance = int(importance.Int64)
+	}
+	if sourceID.Valid {
+		id := sourceID.Int64
+		s.SourceID = &id
+	}
+	if sourceTitle.Valid {
+		s.SourceTitle = sourceTitle.String
+	}
+	if imageURL.Valid {
+		s.ImageURL = imageURL.String
+	}
+	if publishedAt.Valid {
+		s.PublishedAt = publishedAt.Time
+	}
+	if summaryLength.Valid {
+		s.SummaryLength = summaryLength.String
+	}
+	return s, nil
 }
 
-// CreateStory creates a new story
+// CreateStory creates a new story along with its tags and citations
 func (db *DB) CreateStory(story *models.Story) error {
-	result, err := db.conn.Exec(`
-		INSERT INTO stories (topic_id, source_id, title, summary, source_url, source_title, image_url, published_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, story.TopicID, story.SourceID, story.Title, story.Summary, story.SourceURL, story.SourceTitle, story.ImageURL, story.PublishedAt)
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO stories (topic_id, source_id, title, summary, source_url, source_title, image_url, published_at, importance,
+		                     summary_length)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, story.TopicID, story.SourceID, story.Title, story.Summary, story.SourceURL, story.SourceTitle, story.ImageURL, nullTime(story.PublishedAt),
+		story.Importance, story.SummaryLength)
 	if err != nil {
 		return err
 	}
 	id, _ := result.LastInsertId()
+	for _, tag := range story.Tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO story_tags (story_id, tag) VALUES (?, ?)`, id, tag); err != nil {
+			return err
+		}
+	}
+	for _, citation := range story.Citations {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO story_citations (story_id, url) VALUES (?, ?)`, id, citation); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
 	story.ID = id
 	story.CreatedAt = time.Now()
 	return nil
 }
 
-// DeleteOldStories removes stories older than the given duration for a topic
-func (db *DB) DeleteOldStories(topicID int64, keepCount int) error {
-	_, err := db.conn.Exec(`
-		DELETE FROM stories WHERE topic_id = ? AND id NOT IN (
+// StoryExists reports whether a topic already has a story with the given title.
+// Titles are compared case-insensitively; many stories can share a source URL.
+func (db *DB) StoryExists(topicID int64, title string) (bool, error) {
+	var exists bool
+	err := db.conn.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM stories WHERE topic_id = ? AND title = ? COLLATE NOCASE)
+	`, topicID, title).Scan(&exists)
+	return exists, err
+}
+
+// UpdateStoryText replaces a story's title and summary and the length preset the summary was written with
+func (db *DB) UpdateStoryText(id int64, title, summary, summaryLength string) error {
+	_, err := db.conn.Exec(`UPDATE stories SET title = ?, summary = ?, summary_length = ? WHERE id = ?`, title, summary, summaryLength, id)
+	return err
+}
+
+// SetStoryEmbedding stores the embedding vector of a story, encoded as a blob
+func (db *DB) SetStoryEmbedding(storyID int64, embedding []byte) error {
+	_, err := db.conn.Exec(`UPDATE stories SET embedding = ? WHERE id = ?`, embedding, storyID)
+	return err
+}
+
+// GetRecentStoryEmbeddings returns the stored embeddings of a topic's newest stories,
+// skipping stories that have none
+func (db *DB) GetRecentStoryEmbeddings(topicID int64, limit int) ([]models.StoryEmbedding, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, title, embedding FROM stories
+		WHERE topic_id = ? AND embedding IS NOT NULL
+		ORDER BY created_at DESC, id DESC LIMIT ?
+	`, topicID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var embeddings []models.StoryEmbedding
+	for rows.Next() {
+		var e models.StoryEmbedding
+		if err := rows.Scan(&e.StoryID, &e.Title, &e.Embedding); err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, e)
+	}
+	return embeddings, rows.Err()
+}
+
+// DeleteOldStories removes all but a topic's keepCount newest stories,
+// moving them to the archive first if archive is set
+func (db *DB) DeleteOldStories(topicID int64, keepCount int, archive bool) error {
+	return db.removeStories(archive, `topic_id = ? AND id NOT IN (
 			SELECT id FROM stories WHERE topic_id = ? ORDER BY created_at DESC LIMIT ?
-		)
-	`, topicID, topicID, keepCount)
+		)`, topicID, topicID, keepCount)
+}
+
+// DeleteStoriesOlderThan removes a topic's stories created more than the given number of days ago,
+// moving them to the archive first if archive is set
+func (db *DB) DeleteStoriesOlderThan(topicID int64, days int, archive bool) error {
+	return db.removeStories(archive, `topic_id = ? AND created_at < datetime('now', ?)`,
+		topicID, fmt.Sprintf("-%d days", days))
+}
+
+// storyFields lists the story columns copied into the archive
+const storyFields = `id, topic_id, source_id, title, summary, source_url, source_title, image_url, published_at, created_at,
+	importance, summary_length`
+
+// removeStories deletes the stories matching where, copying them to archived_stories
+// in the same transaction if archive is set
+func (db *DB) removeStories(archive bool, where string, args ...interface{}) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if archive {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO archived_stories (`+storyFields+`)
+			SELECT `+storyFields+` FROM stories WHERE `+where, args...); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM stories WHERE `+where, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteArchivedStoriesOlderThan removes a topic's stories archived more than the given number of days ago
+func (db *DB) DeleteArchivedStoriesOlderThan(topicID int64, days int) error {
+	_, err := db.conn.Exec(`
+		DELETE FROM archived_stories WHERE topic_id = ? AND archived_at < datetime('now', ?)
+	`, topicID, fmt.Sprintf("-%d days", days))
 	return err
 }
 
+// GetArchivedStories returns a page of a topic's archived stories, most recently created first
+func (db *DB) GetArchivedStories(topicID int64, limit, offset int) ([]models.ArchivedStory, error) {
+	rows, err := db.conn.Query(`
+		SELECT `+storyFields+`, archived_at
+		FROM archived_stories WHERE topic_id = ?
+		ORDER BY created_at DESC LIMIT ? OFFSET ?
+	`, topicID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stories []models.ArchivedStory
+	for rows.Next() {
+		var s models.ArchivedStory
+		story, err := scanStory(scanFunc(func(dest ...interface{}) error {
+			return rows.Scan(append(dest, &s.ArchivedAt)...)
+		}))
+		if err != nil {
+			return nil, err
+		}
+		s.Story = story
+		stories = append(stories, s)
+	}
+	return stories, rows.Err()
+}
+
 // Settings operations
 
 // GetSettings returns the application settings
 func (db *DB) GetSettings() (*models.Settings, error) {
 	var s models.Settings
 	var sourcingPrompt, summarizingPrompt, apiKey, dashTitle, dashSubtitle sql.NullString
+	var quietStart, quietEnd, provider, openaiBaseURL, openaiModel, webhookURL sql.NullString
+	var boilerplatePatterns sql.NullString
 	var storyTitleFontSize, storyTextFontSize sql.NullFloat64
+	var maxConcurrent, staggerSeconds, redditMinWords, retentionCount, retentionDays sql.NullInt64
+	var scrapeParallelism, redditMinScore, redditMaxAge sql.NullInt64
+	var requestBudget, tokenBudget sql.NullInt64
+	var redditLinkPosts, headlessFallback, archiveStories sql.NullBool
+	var archiveRetention sql.NullInt64
+	var geminiModel sql.NullString
+	var maxSourceChars, maxPromptChars sql.NullInt64
+	var summaryMinWords, summaryMaxWords sql.NullInt64
+	var promptTokenPrice, outputTokenPrice sql.NullFloat64
+	var geminiTimeoutSeconds, ollamaTimeoutSeconds sql.NullInt64
+	var searchGrounding, semanticDedup, rankByImportance, verifyDiscoveredSources sql.NullBool
+	var openaiAPIKey, ollamaHost, ollamaModel sql.NullString
+	var geminiTemperature, geminiTopP, semanticDedupThreshold sql.NullFloat64
+	var geminiMaxOutputTokens, chunkThresholdChars sql.NullInt64
+	var geminiSafetyThreshold, fallbackModel, storyLanguage, summaryLength sql.NullString
+	var minSources, maxSources sql.NullInt64
 
 	err := db.conn.QueryRow(`
 		SELECT id, refresh_interval_minutes, stories_per_topic, global_sourcing_prompt,
 		       global_summarizing_prompt, primary_color, secondary_color, dark_mode, gemini_api_key,
-		       dashboard_title, dashboard_subtitle, story_title_font_size, story_text_font_size
+		       dashboard_title, dashboard_subtitle, story_title_font_size, story_text_font_size,
+		       quiet_hours_start, quiet_hours_end, provider, openai_base_url, openai_model,
+		       max_concurrent_refreshes, refresh_stagger_seconds, reddit_include_link_posts,
+		       reddit_min_words, story_retention_count, story_retention_days, scrape_parallelism,
+		       webhook_url, reddit_min_score, reddit_max_age_hours, daily_request_budget,
+		       daily_token_budget, boilerplate_patterns, headless_fallback, archive_stories,
+		       archive_retention_days, gemini_model, max_source_chars, max_prompt_chars,
+		       summary_min_words, summary_max_words, prompt_token_price, output_token_price,
+		       gemini_timeout_seconds, search_grounding, openai_api_key, ollama_host, ollama_model,
+		       ollama_timeout_seconds, gemini_temperature, gemini_top_p, gemini_max_output_tokens,
+		       gemini_safety_threshold, fallback_model, chunk_threshold_chars, semantic_dedup,
+		       semantic_dedup_threshold, story_language, rank_by_importance,
+		       verify_discovered_sources, summary_length, min_sources, max_sources
 		FROM settings WHERE id = 1
 	`).Scan(&s.ID, &s.RefreshIntervalMinutes, &s.StoriesPerTopic, &sourcingPrompt,
 		&summarizingPrompt, &s.PrimaryColor, &s.SecondaryColor, &s.DarkMode, &apiKey,
-		&dashTitle, &dashSubtitle, &storyTitleFontSize, &storyTextFontSize)
+		&dashTitle, &dashSubtitle, &storyTitleFontSize, &storyTextFontSize,
+		&quietStart, &quietEnd, &provider, &openaiBaseURL, &openaiModel,
+		&maxConcurrent, &staggerSeconds, &redditLinkPosts, &redditMinWords,
+		&retentionCount, &retentionDays, &scrapeParallelism, &webhookURL,
+		&redditMinScore, &redditMaxAge, &requestBudget, &tokenBudget,
+		&boilerplatePatterns, &headlessFallback, &archiveStories, &archiveRetention, &geminiModel,
+		&maxSourceChars, &maxPromptChars, &summaryMinWords, &summaryMaxWords, &promptTokenPrice,
+		&outputTokenPrice, &geminiTimeoutSeconds, &searchGrounding, &openaiAPIKey, &ollamaHost,
+		&ollamaModel, &ollamaTimeoutSeconds, &geminiTemperature, &geminiTopP, &geminiMaxOutputTokens,
+		&geminiSafetyThreshold, &fallbackModel, &chunkThresholdChars, &semanticDedup,
+		&semanticDedupThreshold, &storyLanguage, &rankByImportance, &verifyDiscoveredSources,
+		&summaryLength, &minSources, &maxSources)
 
 	if err == sql.ErrNoRows {
 		// Insert default settings
@@ -465,6 +1488,170 @@ func (db *DB) GetSettings() (*models.Settings, error) {
 	} else {
 		s.StoryTextFontSize = 0.9
 	}
+	if quietStart.Valid {
+		s.QuietHoursStart = quietStart.String
+	}
+	if quietEnd.Valid {
+		s.QuietHoursEnd = quietEnd.String
+	}
+	if provider.Valid && provider.String != "" {
+		s.Provider = provider.String
+	} else {
+		s.Provider = "gemini"
+	}
+	if openaiBaseURL.Valid {
+		s.OpenAIBaseURL = openaiBaseURL.String
+	}
+	if openaiModel.Valid {
+		s.OpenAIModel = openaiModel.String
+	}
+	if maxConcurrent.Valid && maxConcurrent.Int64 > 0 {
+		s.MaxConcurrentRefreshes = int(maxConcurrent.Int64)
+	} else {
+		s.MaxConcurrentRefreshes = 1
+	}
+	if staggerSeconds.Valid {
+		s.RefreshStaggerSeconds = int(staggerSeconds.Int64)
+	} else {
+		s.RefreshStaggerSeconds = 30
+	}
+	s.RedditIncludeLinkPosts = redditLinkPosts.Valid && redditLinkPosts.Bool
+	if redditMinWords.Valid {
+		s.RedditMinWords = int(redditMinWords.Int64)
+	} else {
+		s.RedditMinWords = 100
+	}
+	if redditMinScore.Valid {
+		s.RedditMinScore = int(redditMinScore.Int64)
+	}
+	if redditMaxAge.Valid {
+		s.RedditMaxAgeHours = int(redditMaxAge.Int64)
+	}
+	if retentionCount.Valid {
+		s.StoryRetentionCount = int(retentionCount.Int64)
+	}
+	if retentionDays.Valid {
+		s.StoryRetentionDays = int(retentionDays.Int64)
+	}
+	if scrapeParallelism.Valid && scrapeParallelism.Int64 > 0 {
+		s.ScrapeParallelism = int(scrapeParallelism.Int64)
+	} else {
+		s.ScrapeParallelism = 2
+	}
+	if webhookURL.Valid {
+		s.WebhookURL = webhookURL.String
+	}
+	if requestBudget.Valid {
+		s.DailyRequestBudget = int(requestBudget.Int64)
+	}
+	if tokenBudget.Valid {
+		s.DailyTokenBudget = int(tokenBudget.Int64)
+	}
+	if boilerplatePatterns.Valid {
+		s.BoilerplatePatterns = boilerplatePatterns.String
+	}
+	s.HeadlessFallback = headlessFallback.Valid && headlessFallback.Bool
+	s.ArchiveStories = archiveStories.Valid && archiveStories.Bool
+	if archiveRetention.Valid {
+		s.ArchiveRetentionDays = int(archiveRetention.Int64)
+	} else {
+		s.ArchiveRetentionDays = 90
+	}
+	if geminiModel.Valid && geminiModel.String != "" {
+		s.GeminiModel = geminiModel.String
+	} else {
+		s.GeminiModel = "gemini-2.0-flash"
+	}
+	if maxSourceChars.Valid && maxSourceChars.Int64 > 0 {
+		s.MaxSourceChars = int(maxSourceChars.Int64)
+	} else {
+		s.MaxSourceChars = 10000
+	}
+	if maxPromptChars.Valid {
+		s.MaxPromptChars = int(maxPromptChars.Int64)
+	} else {
+		s.MaxPromptChars = 60000
+	}
+	if summaryMinWords.Valid && summaryMinWords.Int64 > 0 {
+		s.SummaryMinWords = int(summaryMinWords.Int64)
+	} else {
+		s.SummaryMinWords = 75
+	}
+	if summaryMaxWords.Valid && summaryMaxWords.Int64 > 0 {
+		s.SummaryMaxWords = int(summaryMaxWords.Int64)
+	} else {
+		s.SummaryMaxWords = 150
+	}
+	if promptTokenPrice.Valid {
+		s.PromptTokenPrice = promptTokenPrice.Float64
+	} else {
+		s.PromptTokenPrice = 0.1
+	}
+	if outputTokenPrice.Valid {
+		s.OutputTokenPrice = outputTokenPrice.Float64
+	} else {
+		s.OutputTokenPrice = 0.4
+	}
+	if geminiTimeoutSeconds.Valid && geminiTimeoutSeconds.Int64 > 0 {
+		s.GeminiTimeoutSeconds = int(geminiTimeoutSeconds.Int64)
+	} else {
+		s.GeminiTimeoutSeconds = 90
+	}
+	s.SearchGrounding = !searchGrounding.Valid || searchGrounding.Bool
+	s.OpenAIAPIKey = openaiAPIKey.String
+	if ollamaHost.Valid && ollamaHost.String != "" {
+		s.OllamaHost = ollamaHost.String
+	} else {
+		s.OllamaHost = "http://localhost:11434"
+	}
+	s.OllamaModel = ollamaModel.String
+	if ollamaTimeoutSeconds.Valid && ollamaTimeoutSeconds.Int64 > 0 {
+		s.OllamaTimeoutSeconds = int(ollamaTimeoutSeconds.Int64)
+	} else {
+		s.OllamaTimeoutSeconds = 300
+	}
+	if geminiTemperature.Valid {
+		s.GeminiTemperature = geminiTemperature.Float64
+	} else {
+		s.GeminiTemperature = 1.0
+	}
+	if geminiTopP.Valid {
+		s.GeminiTopP = geminiTopP.Float64
+	} else {
+		s.GeminiTopP = 0.95
+	}
+	s.GeminiMaxOutputTokens = int(geminiMaxOutputTokens.Int64)
+	s.GeminiSafetyThreshold = geminiSafetyThreshold.String
+	s.FallbackModel = fallbackModel.String
+	if chunkThresholdChars.Valid {
+		s.ChunkThresholdChars = int(chunkThresholdChars.Int64)
+	}
+	s.SemanticDedup = semanticDedup.Valid && semanticDedup.Bool
+	if semanticDedupThreshold.Valid {
+		s.SemanticDedupThreshold = semanticDedupThreshold.Float64
+	} else {
+		s.SemanticDedupThreshold = 0.88
+	}
+	if storyLanguage.Valid {
+		s.StoryLanguage = storyLanguage.String
+	}
+	s.RankByImportance = rankByImportance.Valid && rankByImportance.Bool
+	s.VerifyDiscoveredSources = !verifyDiscoveredSources.Valid || verifyDiscoveredSources.Bool
+	if summaryLength.Valid && summaryLength.String != "" {
+		s.SummaryLength = summaryLength.String
+	} else {
+		s.SummaryLength = "standard"
+	}
+	if minSources.Valid && minSources.Int64 > 0 {
+		s.MinSources = int(minSources.Int64)
+	} else {
+		s.MinSources = 4
+	}
+	if maxSources.Valid && maxSources.Int64 > 0 {
+		s.MaxSources = int(maxSources.Int64)
+	} else {
+		s.MaxSources = 8
+	}
 
 	return &s, nil
 }
@@ -484,34 +1671,87 @@ func (db *DB) UpdateSettings(s *models.Settings) error {
 			dashboard_title = ?,
 			dashboard_subtitle = ?,
 			story_title_font_size = ?,
-			story_text_font_size = ?
+			story_text_font_size = ?,
+			quiet_hours_start = ?,
+			quiet_hours_end = ?,
+			provider = ?,
+			openai_base_url = ?,
+			openai_model = ?,
+			max_concurrent_refreshes = ?,
+			refresh_stagger_seconds = ?,
+			reddit_include_link_posts = ?,
+			reddit_min_words = ?,
+			story_retention_count = ?,
+			story_retention_days = ?,
+			scrape_parallelism = ?,
+			webhook_url = ?,
+			reddit_min_score = ?,
+			reddit_max_age_hours = ?,
+			daily_request_budget = ?,
+			daily_token_budget = ?,
+			boilerplate_patterns = ?,
+			headless_fallback = ?,
+			archive_stories = ?,
+			archive_retention_days = ?,
+			gemini_model = ?,
+			max_source_chars = ?,
+			max_prompt_chars = ?,
+			summary_min_words = ?,
+			summary_max_words = ?,
+			prompt_token_price = ?,
+			output_token_price = ?,
+			gemini_timeout_seconds = ?,
+			search_grounding = ?,
+			openai_api_key = ?,
+			ollama_host = ?,
+			ollama_model = ?,
+			ollama_timeout_seconds = ?,
+			gemini_temperature = ?,
+			gemini_top_p = ?,
+			gemini_max_output_tokens = ?,
+			gemini_safety_threshold = ?,
+			fallback_model = ?,
+			chunk_threshold_chars = ?,
+			semantic_dedup = ?,
+			semantic_dedup_threshold = ?,
+			story_language = ?,
+			rank_by_importance = ?,
+			verify_discovered_sources = ?,
+			summary_length = ?,
+			min_sources = ?,
+			max_sources = ?
 		WHERE id = 1
 	`, s.RefreshIntervalMinutes, s.StoriesPerTopic, s.GlobalSourcingPrompt,
 		s.GlobalSummarizingPrompt, s.PrimaryColor, s.SecondaryColor, s.DarkMode, s.GeminiAPIKey,
-		s.DashboardTitle, s.DashboardSubtitle, s.StoryTitleFontSize, s.StoryTextFontSize)
+		s.DashboardTitle, s.DashboardSubtitle, s.StoryTitleFontSize, s.StoryTextFontSize,
+		s.QuietHoursStart, s.QuietHoursEnd, s.Provider, s.OpenAIBaseURL, s.OpenAIModel,
+		s.MaxConcurrentRefreshes, s.RefreshStaggerSeconds, s.RedditIncludeLinkPosts,
+		s.RedditMinWords, s.StoryRetentionCount, s.StoryRetentionDays,
+		s.ScrapeParallelism, s.WebhookURL, s.RedditMinScore, s.RedditMaxAgeHours,
+		s.DailyRequestBudget, s.DailyTokenBudget, s.BoilerplatePatterns,
+		s.HeadlessFallback, s.ArchiveStories, s.ArchiveRetentionDays, s.GeminiModel,
+		s.MaxSourceChars, s.MaxPromptChars, s.SummaryMinWords, s.SummaryMaxWords, s.PromptTokenPrice,
+		s.OutputTokenPrice, s.GeminiTimeoutSeconds, s.SearchGrounding, s.OpenAIAPIKey, s.OllamaHost,
+		s.OllamaModel, s.OllamaTimeoutSeconds, s.GeminiTemperature, s.GeminiTopP,
+		s.GeminiMaxOutputTokens, s.GeminiSafetyThreshold, s.FallbackModel, s.ChunkThresholdChars,
+		s.SemanticDedup, s.SemanticDedupThreshold, s.StoryLanguage, s.RankByImportance,
+		s.VerifyDiscoveredSources, s.SummaryLength, s.MinSources, s.MaxSources)
 	return err
 }
 
 // Refresh status operations
 
-// GetRefreshStatus returns refresh status for a topic
-func (db *DB) GetRefreshStatus(topicID int64) (*models.RefreshStatus, error) {
+// refreshStatusColumns lists the refresh_status columns in the order expected by scanRefreshStatus
+const refreshStatusColumns = `topic_id, last_refresh, next_refresh, status, error_message, progress_stage, progress_percent, empty_refreshes, backoff_multiplier`
+
+// scanRefreshStatus scans a row selected with refreshStatusColumns into a RefreshStatus
+func scanRefreshStatus(row rowScanner) (models.RefreshStatus, error) {
 	var rs models.RefreshStatus
 	var lastRefresh, nextRefresh sql.NullTime
-	var errorMsg sql.NullString
-
-	err := db.conn.QueryRow(`
-		SELECT topic_id, last_refresh, next_refresh, status, error_message
-		FROM refresh_status WHERE topic_id = ?
-	`, topicID).Scan(&rs.TopicID, &lastRefresh, &nextRefresh, &rs.Status, &errorMsg)
-
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
-	if err != nil {
-		return nil, err
-	}
+	var errorMsg, progressStage sql.NullString
+	var progressPercent, emptyRefreshes, backoffMultiplier sql.NullInt64
 
+	err := row.Scan(&rs.TopicID, &lastRefresh, &nextRefresh, &rs.Status, &errorMsg, &progressStage, &progressPercent, &emptyRefreshes, &backoffMultiplier)
 	if lastRefresh.Valid {
 		rs.LastRefresh = lastRefresh.Time
 	}
@@ -521,30 +1761,81 @@ func (db *DB) GetRefreshStatus(topicID int64) (*models.RefreshStatus, error) {
 	if errorMsg.Valid {
 		rs.ErrorMessage = errorMsg.String
 	}
+	if progressStage.Valid {
+		rs.ProgressStage = progressStage.String
+	}
+	if progressPercent.Valid {
+		rs.ProgressPercent = int(progressPercent.Int64)
+	}
+	if emptyRefreshes.Valid {
+		rs.EmptyRefreshes = int(emptyRefreshes.Int64)
+	}
+	rs.BackoffMultiplier = 1
+	if backoffMultiplier.Valid && backoffMultiplier.Int64 > 1 {
+		rs.BackoffMultiplier = int(backoffMultiplier.Int64)
+	}
+	return rs, err
+}
 
+// GetRefreshStatus returns refresh status for a topic
+func (db *DB) GetRefreshStatus(topicID int64) (*models.RefreshStatus, error) {
+	rs, err := scanRefreshStatus(db.conn.QueryRow(`SELECT `+refreshStatusColumns+` FROM refresh_status WHERE topic_id = ?`, topicID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 	return &rs, nil
 }
 
 // UpdateRefreshStatus updates or inserts refresh status for a topic
 func (db *DB) UpdateRefreshStatus(rs *models.RefreshStatus) error {
 	_, err := db.conn.Exec(`
-		INSERT INTO refresh_status (topic_id, last_refresh, next_refresh, status, error_message)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO refresh_status (topic_id, last_refresh, next_refresh, status, error_message, progress_stage, progress_percent, empty_refreshes, backoff_multiplier)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(topic_id) DO UPDATE SET
 			last_refresh = excluded.last_refresh,
 			next_refresh = excluded.next_refresh,
 			status = excluded.status,
-			error_message = excluded.error_message
-	`, rs.TopicID, rs.LastRefresh, rs.NextRefresh, rs.Status, rs.ErrorMessage)
+			error_message = excluded.error_message,
+			progress_stage = excluded.progress_stage,
+			progress_percent = excluded.progress_percent,
+			empty_refreshes = excluded.empty_refreshes,
+			backoff_multiplier = excluded.backoff_multiplier
+	`, rs.TopicID, rs.LastRefresh, rs.NextRefresh, rs.Status, rs.ErrorMessage, rs.ProgressStage, rs.ProgressPercent, rs.EmptyRefreshes, max(rs.BackoffMultiplier, 1))
+	return err
+}
+
+// ResetInProgressStatuses marks refreshes left in_progress, queued, or discovering by a
+// crash or unclean shutdown as failed, due again after retryAfter. The refresh queue
+// lives in memory, so nothing would ever finish them and the scheduler skips topics in
+// those states. It returns how many topics were reset.
+func (db *DB) ResetInProgressStatuses(retryAfter time.Duration) (int64, error) {
+	result, err := db.conn.Exec(`
+		UPDATE refresh_status
+		SET status = 'failed', error_message = 'interrupted by restart', progress_stage = '',
+			progress_percent = 0, next_refresh = ?
+		WHERE status IN ('in_progress', 'queued', 'discovering')
+	`, time.Now().Add(retryAfter))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// UpdateRefreshProgress updates only the progress of an in-progress refresh.
+// It's a single-row primary key update so it stays cheap when called often.
+func (db *DB) UpdateRefreshProgress(topicID int64, stage string, percent int) error {
+	_, err := db.conn.Exec(`
+		UPDATE refresh_status SET progress_stage = ?, progress_percent = ? WHERE topic_id = ?
+	`, stage, percent, topicID)
 	return err
 }
 
 // GetAllRefreshStatuses returns all refresh statuses
 func (db *DB) GetAllRefreshStatuses() ([]models.RefreshStatus, error) {
-	rows, err := db.conn.Query(`
-		SELECT topic_id, last_refresh, next_refresh, status, error_message
-		FROM refresh_status
-	`)
+	rows, err := db.conn.Query(`SELECT ` + refreshStatusColumns + ` FROM refresh_status`)
 	if err != nil {
 		return nil, err
 	}
@@ -552,31 +1843,206 @@ func (db *DB) GetAllRefreshStatuses() ([]models.RefreshStatus, error) {
 
 	var statuses []models.RefreshStatus
 	for rows.Next() {
-		var rs models.RefreshStatus
-		var lastRefresh, nextRefresh sql.NullTime
-		var errorMsg sql.NullString
+		rs, err := scanRefreshStatus(rows)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, rs)
+	}
+	return statuses, rows.Err()
+}
 
-		if err := rows.Scan(&rs.TopicID, &lastRefresh, &nextRefresh, &rs.Status, &errorMsg); err != nil {
+// GetAllRefreshStatusesWithNames returns all refresh statuses together with each topic's
+// name and number of active sources, in topic display order
+func (db *DB) GetAllRefreshStatusesWithNames() ([]models.TopicRefreshStatus, error) {
+	rows, err := db.conn.Query(`
+		SELECT ` + refreshStatusColumns + `, t.name,
+		       (SELECT COUNT(*) FROM sources s WHERE s.topic_id = t.id AND s.is_active = 1)
+		FROM refresh_status JOIN topics t ON t.id = refresh_status.topic_id
+		ORDER BY t.position ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statuses []models.TopicRefreshStatus
+	for rows.Next() {
+		var ts models.TopicRefreshStatus
+		rs, err := scanRefreshStatus(scanFunc(func(dest ...interface{}) error {
+			return rows.Scan(append(dest, &ts.TopicName, &ts.ActiveSources)...)
+		}))
+		if err != nil {
 			return nil, err
 		}
+		ts.RefreshStatus = rs
+		statuses = append(statuses, ts)
+	}
+	return statuses, rows.Err()
+}
+
+// Refresh history operations
 
-		if lastRefresh.Valid {
-			rs.LastRefresh = lastRefresh.Time
+// maxHistoryPerTopic is how many refresh history entries are kept per topic
+const maxHistoryPerTopic = 100
+
+// AddRefreshHistory records a finished refresh and prunes the topic's history
+// to the most recent maxHistoryPerTopic entries
+func (db *DB) AddRefreshHistory(h *models.RefreshHistory) error {
+	result, err := db.conn.Exec(`
+		INSERT INTO refresh_history (topic_id, started_at, finished_at, status, stories_created,
+		                             sources_scraped, sources_failed, error, prompt_tokens, output_tokens, cost, model)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, h.TopicID, h.StartedAt, h.FinishedAt, h.Status, h.StoriesCreated, h.SourcesScraped, h.SourcesFailed, h.Error,
+		h.PromptTokens, h.OutputTokens, h.Cost, h.Model)
+	if err != nil {
+		return err
+	}
+	h.ID, _ = result.LastInsertId()
+
+	_, err = db.conn.Exec(`
+		DELETE FROM refresh_history WHERE topic_id = ? AND id NOT IN (
+			SELECT id FROM refresh_history WHERE topic_id = ? ORDER BY id DESC LIMIT ?
+		)
+	`, h.TopicID, h.TopicID, maxHistoryPerTopic)
+	return err
+}
+
+// GetRefreshHistory returns a topic's most recent refreshes, newest first
+func (db *DB) GetRefreshHistory(topicID int64, limit int) ([]models.RefreshHistory, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, topic_id, started_at, finished_at, status, stories_created,
+		       sources_scraped, sources_failed, error, prompt_tokens, output_tokens, cost, model
+		FROM refresh_history WHERE topic_id = ?
+		ORDER BY id DESC LIMIT ?
+	`, topicID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.RefreshHistory
+	for rows.Next() {
+		var h models.RefreshHistory
+		var errMsg, model sql.NullString
+		var promptTokens, outputTokens sql.NullInt64
+		var cost sql.NullFloat64
+		if err := rows.Scan(&h.ID, &h.TopicID, &h.StartedAt, &h.FinishedAt, &h.Status, &h.StoriesCreated,
+			&h.SourcesScraped, &h.SourcesFailed, &errMsg, &promptTokens, &outputTokens, &cost, &model); err != nil {
+			return nil, err
 		}
-		if nextRefresh.Valid {
-			rs.NextRefresh = nextRefresh.Time
+		if errMsg.Valid {
+			h.Error = errMsg.String
 		}
-		if errorMsg.Valid {
-			rs.ErrorMessage = errorMsg.String
+		h.PromptTokens = int(promptTokens.Int64)
+		h.OutputTokens = int(outputTokens.Int64)
+		h.Cost = cost.Float64
+		h.Model = model.String
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+// API usage operations
+
+// RecordAPIUsage adds one AI API call and the tokens it used to the given day's totals
+func (db *DB) RecordAPIUsage(day string, tokens int) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO api_usage (day, requests, tokens) VALUES (?, 1, ?)
+		ON CONFLICT(day) DO UPDATE SET
+			requests = requests + 1,
+			tokens = tokens + excluded.tokens
+	`, day, tokens)
+	return err
+}
+
+// GetAPIUsage returns the usage for a day, with zero counts if nothing was recorded
+func (db *DB) GetAPIUsage(day string) (models.APIUsage, error) {
+	usage := models.APIUsage{Day: day}
+	err := db.conn.QueryRow(`SELECT requests, tokens FROM api_usage WHERE day = ?`, day).
+		Scan(&usage.Requests, &usage.Tokens)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return usage, err
+}
+
+// GetAPIUsageHistory returns the usage of the most recent days with recorded calls, newest first
+func (db *DB) GetAPIUsageHistory(days int) ([]models.APIUsage, error) {
+	rows, err := db.conn.Query(`SELECT day, requests, tokens FROM api_usage ORDER BY day DESC LIMIT ?`, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.APIUsage
+	for rows.Next() {
+		var u models.APIUsage
+		if err := rows.Scan(&u.Day, &u.Requests, &u.Tokens); err != nil {
+			return nil, err
 		}
+		history = append(history, u)
+	}
+	return history, rows.Err()
+}
 
-		statuses = append(statuses, rs)
+// RecordTopicUsage adds one AI API call made for a topic, its tokens, and its estimated cost
+// to the topic's totals for the given day
+func (db *DB) RecordTopicUsage(day string, topicID int64, promptTokens, outputTokens int, cost float64) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO topic_usage (day, topic_id, requests, prompt_tokens, output_tokens, cost)
+		VALUES (?, ?, 1, ?, ?, ?)
+		ON CONFLICT(day, topic_id) DO UPDATE SET
+			requests = requests + 1,
+			prompt_tokens = prompt_tokens + excluded.prompt_tokens,
+			output_tokens = output_tokens + excluded.output_tokens,
+			cost = cost + excluded.cost
+	`, day, topicID, promptTokens, outputTokens, cost)
+	return err
+}
+
+// GetTopicUsage returns per-topic usage for each day since the given day (inclusive),
+// newest day first and then by topic order
+func (db *DB) GetTopicUsage(since string) ([]models.TopicUsage, error) {
+	rows, err := db.conn.Query(`
+		SELECT u.day, u.topic_id, t.name, u.requests, u.prompt_tokens, u.output_tokens, u.cost
+		FROM topic_usage u JOIN topics t ON t.id = u.topic_id
+		WHERE u.day >= ?
+		ORDER BY u.day DESC, t.position ASC
+	`, since)
+	if err != nil {
+		return nil, err
 	}
-	return statuses, rows.Err()
+	defer rows.Close()
+
+	var usage []models.TopicUsage
+	for rows.Next() {
+		var u models.TopicUsage
+		if err := rows.Scan(&u.Day, &u.TopicID, &u.TopicName, &u.Requests, &u.PromptTokens, &u.OutputTokens, &u.Cost); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
 }
 
 // GetTopicsWithStories returns all topics with their recent stories
-func (db *DB) GetTopicsWithStories(storiesPerTopic int) ([]models.TopicWithStories, error) {
+func (db *DB) GetTopicsWithStories(storiesPerTopic int, order StoryOrder) ([]models.TopicWithStories, error) {
+	return db.topicsWithStories(func(topicID int64) ([]models.Story, error) {
+		return db.GetStoriesForTopic(topicID, storiesPerTopic, order)
+	})
+}
+
+// GetTopicsWithStoriesBetween returns all topics with their recent stories created
+// between since and until, inclusive
+func (db *DB) GetTopicsWithStoriesBetween(since, until time.Time, storiesPerTopic int, order StoryOrder) ([]models.TopicWithStories, error) {
+	return db.topicsWithStories(func(topicID int64) ([]models.Story, error) {
+		return db.GetStoriesForTopicBetween(topicID, since, until, storiesPerTopic, order)
+	})
+}
+
+// topicsWithStories returns all topics, each with the stories fetch returns for it
+func (db *DB) topicsWithStories(fetch func(topicID int64) ([]models.Story, error)) ([]models.TopicWithStories, error) {
 	topics, err := db.GetTopics()
 	if err != nil {
 		return nil, err
@@ -584,7 +2050,7 @@ func (db *DB) GetTopicsWithStories(storiesPerTopic int) ([]models.TopicWithStori
 
 	var result []models.TopicWithStories
 	for _, topic := range topics {
-		stories, err := db.GetStoriesForTopic(topic.ID, storiesPerTopic)
+		stories, err := fetch(topic.ID)
 		if err != nil {
 			return nil, err
 		}
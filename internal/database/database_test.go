@@ -0,0 +1,70 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/thinkscotty/maggpi_go/internal/models"
+)
+
+// newTestDB opens a fresh database in a temporary directory
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSeedDefaultTopics(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.SeedDefaultTopics(); err != nil {
+		t.Fatalf("SeedDefaultTopics: %v", err)
+	}
+	topics, err := db.GetTopics()
+	if err != nil {
+		t.Fatalf("GetTopics: %v", err)
+	}
+	if len(topics) != len(defaultTopics) {
+		t.Fatalf("seeded %d topics, want %d", len(topics), len(defaultTopics))
+	}
+	for _, topic := range topics {
+		// Seeded topics have no sources, so they can only refresh if they may find their own
+		if !topic.AutoDiscover || !topic.AutoRefresh || !topic.Enabled {
+			t.Errorf("topic %q: auto_discover=%v auto_refresh=%v enabled=%v, want all true",
+				topic.Name, topic.AutoDiscover, topic.AutoRefresh, topic.Enabled)
+		}
+	}
+
+	// Seeding again leaves an existing install alone
+	if err := db.SeedDefaultTopics(); err != nil {
+		t.Fatalf("SeedDefaultTopics again: %v", err)
+	}
+	if again, _ := db.GetTopics(); len(again) != len(topics) {
+		t.Errorf("seeding twice left %d topics, want %d", len(again), len(topics))
+	}
+}
+
+func TestCreateTopicAutoDiscover(t *testing.T) {
+	db := newTestDB(t)
+
+	discover := models.NewTopic("Discover", "")
+	manual := models.NewTopic("Manual", "")
+	manual.AutoDiscover = false
+
+	for _, tt := range []struct {
+		topic models.Topic
+		want  bool
+	}{{discover, true}, {manual, false}} {
+		created, err := db.CreateTopic(&tt.topic)
+		if err != nil {
+			t.Fatalf("CreateTopic(%q): %v", tt.topic.Name, err)
+		}
+		if created.AutoDiscover != tt.want {
+			t.Errorf("topic %q auto_discover = %v, want %v", created.Name, created.AutoDiscover, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"google.golang.org/genai"
+)
+
+// Model is the Gemini embedding model used for comparing stories
+const Model = "gemini-embedding-001"
+
+// dimensions is the size vectors are requested at. The model supports up to 3072, but
+// 768 tells stories apart just as well and keeps each stored vector to 3 KB.
+const dimensions = 768
+
+// Client computes text embeddings with the Gemini API
+type Client struct {
+	client *genai.Client
+}
+
+// New creates an embeddings client
+func New(apiKey string) (*Client, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("Gemini API key is required")
+	}
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+	return &Client{client: client}, nil
+}
+
+// Embed returns one vector per text, in the same order, from a single API call
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	contents := make([]*genai.Content, len(texts))
+	for i, text := range texts {
+		contents[i] = &genai.Content{Parts: []*genai.Part{{Text: text}}}
+	}
+	size := int32(dimensions)
+	result, err := c.client.Models.EmbedContent(ctx, Model, contents, &genai.EmbedContentConfig{
+		TaskType:             "SEMANTIC_SIMILARITY",
+		OutputDimensionality: &size,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed text: %w", err)
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(result.Embeddings))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, embedding := range result.Embeddings {
+		vectors[i] = embedding.Values
+	}
+	return vectors, nil
+}
+
+// StoryText is the text embedded for a story
+func StoryText(title, summary string) string {
+	return title + "\n" + summary
+}
+
+// Similarity returns the cosine similarity of two vectors: 1 for the same meaning, around
+// 0 for unrelated text. Vectors of different sizes, from a different model, give 0.
+func Similarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Encode packs a vector into a blob for storage
+func Encode(v []float32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+// Decode unpacks a blob written by Encode, nil if it isn't one
+func Decode(b []byte) []float32 {
+	if len(b)%4 != 0 {
+		return nil
+	}
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
@@ -0,0 +1,59 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/thinkscotty/maggpi_go/internal/models"
+)
+
+// subscriberBuffer is how many updates a subscriber may fall behind before updates are dropped
+const subscriberBuffer = 16
+
+// Broker is an in-process pub/sub hub that fans refresh status updates out to subscribers
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[chan models.RefreshStatus]struct{}
+}
+
+// NewBroker creates a new Broker
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[chan models.RefreshStatus]struct{}),
+	}
+}
+
+// Subscribe registers a new subscriber. The returned function must be called
+// to unsubscribe once the caller stops reading, after which the channel is closed.
+func (b *Broker) Subscribe() (<-chan models.RefreshStatus, func()) {
+	ch := make(chan models.RefreshStatus, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Publish sends a status update to all subscribers without blocking.
+// Subscribers whose buffer is full miss the update rather than stalling the publisher.
+func (b *Broker) Publish(status models.RefreshStatus) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- status:
+		default:
+			// Slow consumer - drop this update
+		}
+	}
+}
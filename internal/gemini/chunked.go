@@ -0,0 +1,193 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+const (
+	// DefaultChunkThreshold is the default amount of scraped text above which content is
+	// summarized in batches, kept under DefaultMaxContentLength so batching starts before
+	// any text would be cut
+	DefaultChunkThreshold = 50000
+	// maxBatchSources is the most sources summarized together in one batch
+	maxBatchSources = 3
+)
+
+// ContentLength returns the total scraped text in content
+func ContentLength(content []ScrapedContent) int {
+	total := 0
+	for _, c := range content {
+		total += len(c.Content)
+	}
+	return total
+}
+
+// Batches splits content into groups of up to maxBatchSources sources holding at most
+// maxChars of text between them. A source longer than maxChars gets a batch to itself.
+func Batches(content []ScrapedContent, maxChars int) [][]ScrapedContent {
+	var batches [][]ScrapedContent
+	var batch []ScrapedContent
+	size := 0
+	for _, c := range content {
+		if len(batch) > 0 && (len(batch) == maxBatchSources || size+len(c.Content) > maxChars) {
+			batches = append(batches, batch)
+			batch, size = nil, 0
+		}
+		batch = append(batch, c)
+		size += len(c.Content)
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// SummarizeChunked summarizes content too large for one prompt. Each batch is summarized
+// into candidate stories on its own, then consolidate picks the best maxStories of them.
+// Batches that fail are left out as long as at least one succeeds.
+func SummarizeChunked(ctx context.Context, batches [][]ScrapedContent, maxStories int,
+	summarize func(ctx context.Context, batch []ScrapedContent) ([]SummarizedStory, error),
+	consolidate func(ctx context.Context, candidates []SummarizedStory) ([]SummarizedStory, error)) ([]SummarizedStory, error) {
+	var candidates []SummarizedStory
+	var firstErr error
+	for i, batch := range batches {
+		stories, err := summarize(ctx, batch)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("batch %d of %d: %w", i+1, len(batches), err)
+			}
+			continue
+		}
+		candidates = append(candidates, stories...)
+	}
+	if len(candidates) == 0 {
+		if firstErr != nil {
+			return nil, firstErr
+		}
+		return nil, nil
+	}
+	if len(batches) == 1 {
+		return candidates, nil
+	}
+
+	stories, err := consolidate(ctx, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consolidate %d candidate stories: %w", len(candidates), err)
+	}
+	return stories, nil
+}
+
+// ConsolidatePrompt builds the prompt that picks the final stories from the candidates
+// found in each batch, shared by all AI providers. Candidates are numbered so the model
+// can refer to them by id, which keeps each story attributed to the source it came from.
+func ConsolidatePrompt(topicName string, candidates []SummarizedStory, globalInstructions string, maxStories, minWords, maxWords int) string {
+	var candidateBuilder strings.Builder
+	for i, story := range candidates {
+		candidateBuilder.WriteString(fmt.Sprintf("\n--- Candidate %d ---\nTitle: %s\nSource: %s (%s)\nSummary: %s\n",
+			i+1, story.Title, story.SourceTitle, story.SourceURL, story.Summary))
+	}
+
+	return fmt.Sprintf(`You are a news editor. The candidate stories below were written from different batches of sources about one topic. Pick the final stories to publish.
+
+Topic: %s
+
+%s
+
+Candidate Stories:
+%s
+
+Select the %d most interesting and relevant stories.
+
+IMPORTANT RULES:
+- Several candidates may cover the same event. Publish each event only once, choosing the candidate with the most informative source
+- Keep each story's id: the number of the candidate it is based on
+- You may rewrite the title, and merge facts from duplicate candidates into the summary, but only use facts stated in the candidates
+- List the numbers of any other candidates you took facts from in merged_ids
+- Keep each summary to %d-%d words
+- Skip candidates that are off-topic for "%s"
+
+IMPORTANT: Return ONLY a valid JSON array with no additional text, markdown, or explanation. The response must be parseable JSON.
+
+Format your response as a JSON array like this:
+[
+  {"id": 3, "title": "Headline Here", "summary": "Summary text here...", "merged_ids": [7]}
+]`, topicName, globalInstructions, candidateBuilder.String(), maxStories, minWords, maxWords, topicName)
+}
+
+// consolidatedStory is one story picked by the consolidation prompt
+type consolidatedStory struct {
+	ID        int    `json:"id"`
+	Title     string `json:"title"`
+	Summary   string `json:"summary"`
+	MergedIDs []int  `json:"merged_ids,omitempty"`
+}
+
+// consolidatedSchema is the response schema for structured consolidation output
+var consolidatedSchema = &genai.Schema{
+	Type: genai.TypeArray,
+	Items: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"id":         {Type: genai.TypeInteger},
+			"title":      {Type: genai.TypeString},
+			"summary":    {Type: genai.TypeString},
+			"merged_ids": {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeInteger}},
+		},
+		PropertyOrdering: []string{"id", "title", "summary", "merged_ids"},
+		Required:         []string{"id", "title", "summary"},
+	},
+}
+
+// ParseConsolidated parses a consolidation response into stories. Each story's source
+// comes from the candidate it names, never from the model, so attribution can't drift;
+// unknown and repeated ids are dropped. The citations of candidates merged into a story
+// are added to its own.
+func ParseConsolidated(responseText string, candidates []SummarizedStory, maxStories int) ([]SummarizedStory, error) {
+	responseText = cleanJSONResponse(responseText)
+
+	var picked []consolidatedStory
+	if err := unmarshalLenient(responseText, &picked); err != nil {
+		return nil, fmt.Errorf("failed to parse consolidated stories JSON: %w (response: %s)", err, responseText)
+	}
+
+	var stories []SummarizedStory
+	used := make(map[int]bool)
+	for _, p := range picked {
+		if p.ID < 1 || p.ID > len(candidates) || used[p.ID] {
+			continue
+		}
+		used[p.ID] = true
+
+		story := candidates[p.ID-1]
+		if title := strings.TrimSpace(p.Title); title != "" {
+			story.Title = title
+		}
+		if summary := strings.TrimSpace(p.Summary); summary != "" {
+			story.Summary = summary
+		}
+		story.Citations = slices.Clone(story.Citations)
+		for _, id := range p.MergedIDs {
+			if id >= 1 && id <= len(candidates) && id != p.ID {
+				merged := candidates[id-1]
+				story.Citations = append(story.Citations, merged.SourceURL)
+				story.Citations = append(story.Citations, merged.Citations...)
+			}
+		}
+		stories = append(stories, story)
+		if maxStories > 0 && len(stories) == maxStories {
+			break
+		}
+	}
+	if len(stories) == 0 {
+		return nil, fmt.Errorf("consolidation picked no known candidates (response: %s)", responseText)
+	}
+	return stories, nil
+}
@@ -0,0 +1,47 @@
+package gemini
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// MaxCitations is the most citations kept per story
+const MaxCitations = 10
+
+// Citations returns the sources a story cites: its source URL first, then each citation
+// the AI gave that is an http(s) URL found in the scraped content, so invented URLs are
+// dropped. Repeats are left out and at most MaxCitations are kept.
+func Citations(story SummarizedStory, scrapedContent []ScrapedContent) []string {
+	var citations []string
+	add := func(citation string) {
+		if len(citations) < MaxCitations && !slices.Contains(citations, citation) {
+			citations = append(citations, citation)
+		}
+	}
+
+	if sourceURL := strings.TrimSpace(story.SourceURL); sourceURL != "" {
+		add(sourceURL)
+	}
+	for _, citation := range story.Citations {
+		citation = strings.TrimSpace(citation)
+		parsed, err := url.Parse(citation)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			continue
+		}
+		if scraped(citation, scrapedContent) {
+			add(citation)
+		}
+	}
+	return citations
+}
+
+// scraped reports whether a URL is one of the scraped sources or appears in their text
+func scraped(link string, scrapedContent []ScrapedContent) bool {
+	for _, content := range scrapedContent {
+		if content.URL == link || strings.Contains(content.Content, link) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,19 +2,54 @@ package gemini
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"google.golang.org/genai"
 )
 
 // Client wraps the Gemini API client
 type Client struct {
-	client *genai.Client
-	model  string
+	client     *genai.Client
+	model      string
+	fallback   string // model tried once when model fails, "" for none
+	onUsage    UsageFunc
+	onProgress ProgressFunc      // if set, responses are streamed
+	grounding  bool              // look up discovered sources with Google Search
+	params     *GenerationParams // nil for the API defaults
+	maxContent int               // total scraped text sent in one summarize prompt
+	chunkChars int               // scraped text above which content is summarized in batches, 0 for never
+	minWords   int               // target summary length range
+	maxWords   int
+	minSources int // number of sources discovery asks for
+	maxSources int
 }
 
+// Usage is the number of tokens one API call used
+type Usage struct {
+	PromptTokens int    // tokens sent, including the scraped content
+	OutputTokens int    // tokens generated
+	Model        string // model that answered, empty if the call failed
+	Fallback     bool   // the call went to the fallback model after the primary failed
+}
+
+// Total returns the prompt and output tokens combined
+func (u Usage) Total() int {
+	return u.PromptTokens + u.OutputTokens
+}
+
+// UsageFunc is called after every API call with the tokens it used (zero if the
+// call failed), so callers can track usage against a quota and estimate its cost
+type UsageFunc func(usage Usage)
+
+// ProgressFunc is called as a streamed response arrives with the text just received and
+// the total characters received so far. The total restarts from the new chunk's length
+// when a failed call is retried.
+type ProgressFunc func(received int, chunk string)
+
 // DiscoveredSource represents a source discovered by AI
 type DiscoveredSource struct {
 	URL         string `json:"url"`
@@ -24,14 +59,41 @@ type DiscoveredSource struct {
 
 // SummarizedStory represents a summarized story from AI
 type SummarizedStory struct {
-	Title       string `json:"title"`
-	Summary     string `json:"summary"`
-	SourceURL   string `json:"source_url"`
-	SourceTitle string `json:"source_title"`
+	Title       string   `json:"title"`
+	Summary     string   `json:"summary"`
+	SourceURL   string   `json:"source_url"`
+	SourceTitle string   `json:"source_title"`
+	Tags        []string `json:"tags,omitempty"`      // short labels such as "politics" or "rumor"; see NormalizeTags
+	Importance  int      `json:"importance"`          // 1-10, see ClampImportance
+	Citations   []string `json:"citations,omitempty"` // every source URL the summary draws on; see Citations
+}
+
+// MinImportance and MaxImportance bound the importance score asked for on each story
+const (
+	MinImportance = 1
+	MaxImportance = 10
+)
+
+// ClampImportance brings an importance score from the AI into range; a missing score,
+// read as 0, becomes MinImportance
+func ClampImportance(n int) int {
+	return min(max(n, MinImportance), MaxImportance)
 }
 
-// New creates a new Gemini client
-func New(apiKey string) (*Client, error) {
+// DefaultModel is the Gemini model used when none is configured
+const DefaultModel = "gemini-2.0-flash"
+
+// KnownModels lists Gemini models known to work with MaggPi's prompts, for the settings UI
+var KnownModels = []string{
+	"gemini-2.0-flash",
+	"gemini-2.0-flash-lite",
+	"gemini-2.5-flash",
+	"gemini-2.5-flash-lite",
+	"gemini-2.5-pro",
+}
+
+// New creates a new Gemini client for the given model, or DefaultModel if model is empty
+func New(apiKey, model string) (*Client, error) {
 	if apiKey == "" {
 		return nil, fmt.Errorf("Gemini API key is required")
 	}
@@ -45,9 +107,19 @@ func New(apiKey string) (*Client, error) {
 		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
 	}
 
+	if model == "" {
+		model = DefaultModel
+	}
+
 	return &Client{
-		client: client,
-		model:  "gemini-2.0-flash",
+		client:     client,
+		model:      model,
+		maxContent: DefaultMaxContentLength,
+		chunkChars: DefaultChunkThreshold,
+		minWords:   DefaultSummaryMinWords,
+		maxWords:   DefaultSummaryMaxWords,
+		minSources: DefaultMinSources,
+		maxSources: DefaultMaxSources,
 	}, nil
 }
 
@@ -56,16 +128,380 @@ func (c *Client) Close() error {
 	return nil
 }
 
-// DiscoverSources uses AI to find relevant sources for a topic
+// SetUsageFunc sets the function called after each API call
+func (c *Client) SetUsageFunc(fn UsageFunc) {
+	c.onUsage = fn
+}
+
+// SetProgressFunc sets the function called as responses arrive. Setting one makes the
+// client stream its responses instead of waiting for each one whole.
+func (c *Client) SetProgressFunc(fn ProgressFunc) {
+	c.onProgress = fn
+}
+
+// SetFallbackModel sets a model to try once when a call to the primary model fails for
+// any reason but a rejected request or key. Empty or the primary model disables it.
+func (c *Client) SetFallbackModel(model string) {
+	if model == c.model {
+		model = ""
+	}
+	c.fallback = model
+}
+
+// SetSearchGrounding sets whether source discovery uses the Google Search tool. Not
+// every API tier includes it.
+func (c *Client) SetSearchGrounding(enabled bool) {
+	c.grounding = enabled
+}
+
+// SetMaxContentLength caps the scraped text sent in one summarize prompt, 0 for no cap
+func (c *Client) SetMaxContentLength(n int) {
+	c.maxContent = n
+}
+
+// SetChunkThreshold sets how much scraped text switches summarizing to batches followed
+// by a consolidation pass, 0 to always use a single prompt
+func (c *Client) SetChunkThreshold(n int) {
+	c.chunkChars = n
+}
+
+// SetSummaryLength sets the length range, in words, asked for in each story summary
+func (c *Client) SetSummaryLength(minWords, maxWords int) {
+	c.minWords, c.maxWords = minWords, maxWords
+}
+
+// SetSourceCount sets how many sources source discovery asks for
+func (c *Client) SetSourceCount(minSources, maxSources int) {
+	c.minSources, c.maxSources = minSources, maxSources
+}
+
+// generate sends a single prompt to the model, retrying transient errors. If the primary
+// model still fails, it is tried once more on the fallback model.
+func (c *Client) generate(ctx context.Context, prompt string, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	var result *genai.GenerateContentResponse
+	err := withRetry(ctx, func() error {
+		var err error
+		result, err = c.generateWith(ctx, c.model, prompt, config)
+		return err
+	})
+	if err == nil || c.fallback == "" || !canFallBack(ctx, err) {
+		return result, err
+	}
+
+	result, fallbackErr := c.generateWith(ctx, c.fallback, prompt, config)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("%w; fallback model %s also failed: %v", err, c.fallback, fallbackErr)
+	}
+	return result, nil
+}
+
+// generateWith makes one GenerateContent call to model and reports its usage
+func (c *Client) generateWith(ctx context.Context, model, prompt string, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	var result *genai.GenerateContentResponse
+	var err error
+	contents := []*genai.Content{{Parts: []*genai.Part{{Text: prompt}}}}
+	config = c.withParams(config)
+	if c.onProgress != nil {
+		result, err = c.generateStream(ctx, model, contents, config)
+	} else {
+		result, err = c.client.Models.GenerateContent(ctx, model, contents, config)
+	}
+	if c.onUsage != nil {
+		usage := Usage{Fallback: model != c.model}
+		if err == nil {
+			usage.Model = model
+			if result.UsageMetadata != nil {
+				usage.PromptTokens = int(result.UsageMetadata.PromptTokenCount)
+				usage.OutputTokens = int(result.UsageMetadata.CandidatesTokenCount)
+			}
+		}
+		c.onUsage(usage)
+	}
+	return result, err
+}
+
+// generateStream is the streaming form of a GenerateContent call. It reports each chunk to
+// the progress function as it arrives and returns the chunks merged into one response.
+func (c *Client) generateStream(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	var text strings.Builder
+	last := &genai.Candidate{}
+	merged := &genai.GenerateContentResponse{}
+	for chunk, err := range c.client.Models.GenerateContentStream(ctx, model, contents, config) {
+		if err != nil {
+			return nil, err
+		}
+		if chunk.UsageMetadata != nil {
+			// Each chunk carries the running totals, so the last one counts
+			merged.UsageMetadata = chunk.UsageMetadata
+		}
+		if chunk.PromptFeedback != nil {
+			merged.PromptFeedback = chunk.PromptFeedback
+		}
+		if len(chunk.Candidates) > 0 {
+			// Grounding, the finish reason, and safety ratings arrive with the final chunks
+			candidate := chunk.Candidates[0]
+			if candidate.GroundingMetadata != nil {
+				last.GroundingMetadata = candidate.GroundingMetadata
+			}
+			if candidate.FinishReason != "" {
+				last.FinishReason = candidate.FinishReason
+			}
+			if candidate.SafetyRatings != nil {
+				last.SafetyRatings = candidate.SafetyRatings
+			}
+		}
+		if t := extractText(chunk); t != "" {
+			text.WriteString(t)
+			c.onProgress(text.Len(), t)
+		}
+	}
+
+	last.Content = &genai.Content{Parts: []*genai.Part{{Text: text.String()}}}
+	merged.Candidates = []*genai.Candidate{last}
+	return merged, nil
+}
+
+// generateJSON asks the model for a JSON response matching schema. If the structured
+// request itself is rejected, it retries as a plain prompt; structured reports whether the
+// response came from the structured request, whose replies aren't worth a repair prompt.
+func (c *Client) generateJSON(ctx context.Context, prompt string, schema *genai.Schema) (text string, structured bool, err error) {
+	result, err := c.generate(ctx, prompt, &genai.GenerateContentConfig{
+		ResponseMIMEType: "application/json",
+		ResponseSchema:   schema,
+	})
+	structured = err == nil
+	if err != nil && ctx.Err() == nil && !isTransient(err) {
+		result, err = c.generate(ctx, prompt, nil)
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to generate content: %w", err)
+	}
+
+	text, err = responseText(result)
+	if err != nil {
+		return "", false, err
+	}
+	return text, structured, nil
+}
+
+// Response schemas for structured output, matching DiscoveredSource and SummarizedStory
+var (
+	sourcesSchema = &genai.Schema{
+		Type: genai.TypeArray,
+		Items: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"url":         {Type: genai.TypeString},
+				"name":        {Type: genai.TypeString},
+				"description": {Type: genai.TypeString},
+			},
+			PropertyOrdering: []string{"url", "name", "description"},
+			Required:         []string{"url", "name"},
+		},
+	}
+	storiesSchema = &genai.Schema{
+		Type: genai.TypeArray,
+		Items: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"title":        {Type: genai.TypeString},
+				"summary":      {Type: genai.TypeString},
+				"source_url":   {Type: genai.TypeString},
+				"source_title": {Type: genai.TypeString},
+				"tags": {
+					Type:     genai.TypeArray,
+					Items:    &genai.Schema{Type: genai.TypeString},
+					MaxItems: genai.Ptr[int64](MaxTags),
+				},
+				"importance": {
+					Type:    genai.TypeInteger,
+					Minimum: genai.Ptr[float64](MinImportance),
+					Maximum: genai.Ptr[float64](MaxImportance),
+				},
+				"citations": {
+					Type:     genai.TypeArray,
+					Items:    &genai.Schema{Type: genai.TypeString},
+					MaxItems: genai.Ptr[int64](MaxCitations),
+				},
+			},
+			PropertyOrdering: []string{"title", "summary", "source_url", "source_title", "tags", "importance", "citations"},
+			Required:         []string{"title", "summary", "source_url", "source_title", "importance"},
+		},
+	}
+)
+
+// DiscoverSources uses AI to find relevant sources for a topic. With search grounding on,
+// the model's list is merged with the sites Google Search found; if the grounded request
+// is rejected, for example because the API tier lacks search, discovery goes ahead without it.
 func (c *Client) DiscoverSources(ctx context.Context, topicName, topicDescription, globalInstructions string) ([]DiscoveredSource, error) {
-	prompt := fmt.Sprintf(`You are a helpful assistant that discovers reliable web sources for news topics.
+	prompt := DiscoverSourcesPrompt(topicName, topicDescription, globalInstructions, c.minSources, c.maxSources)
+
+	if c.grounding {
+		result, err := c.generate(ctx, prompt, groundingConfig)
+		if err == nil {
+			text, err := responseText(result)
+			if err != nil {
+				return nil, err
+			}
+			var sources []DiscoveredSource
+			if err := c.parseOrRepair(ctx, jsonArray(text), func(text string) error {
+				var err error
+				sources, err = ParseSources(text)
+				return err
+			}); err != nil {
+				return nil, err
+			}
+			return mergeGrounded(sources, groundedSites(result)), nil
+		}
+		if ctx.Err() != nil || isTransient(err) {
+			return nil, fmt.Errorf("failed to generate content: %w", err)
+		}
+	}
+
+	responseText, structured, err := c.generateJSON(ctx, prompt, sourcesSchema)
+	if err != nil {
+		return nil, err
+	}
+	if !structured {
+		var sources []DiscoveredSource
+		err := c.parseOrRepair(ctx, responseText, func(text string) error {
+			var err error
+			sources, err = ParseSources(text)
+			return err
+		})
+		return sources, err
+	}
+	// Structured replies still occasionally come back fenced or with stray commas
+	return ParseSources(responseText)
+}
+
+// SummarizeContent summarizes scraped content into news stories. Content over the chunk
+// threshold is summarized a few sources at a time and the results consolidated.
+func (c *Client) SummarizeContent(ctx context.Context, topicName string, scrapedContent []ScrapedContent, globalInstructions string, maxStories int) ([]SummarizedStory, error) {
+	if len(scrapedContent) == 0 {
+		return nil, nil
+	}
+	if c.chunkChars > 0 && ContentLength(scrapedContent) > c.chunkChars {
+		return SummarizeChunked(ctx, Batches(scrapedContent, c.chunkChars), maxStories,
+			func(ctx context.Context, batch []ScrapedContent) ([]SummarizedStory, error) {
+				return c.summarize(ctx, topicName, batch, globalInstructions, maxStories)
+			},
+			func(ctx context.Context, candidates []SummarizedStory) ([]SummarizedStory, error) {
+				return c.consolidate(ctx, topicName, candidates, globalInstructions, maxStories)
+			})
+	}
+	return c.summarize(ctx, topicName, scrapedContent, globalInstructions, maxStories)
+}
+
+// summarize turns scraped content into news stories with a single prompt
+func (c *Client) summarize(ctx context.Context, topicName string, scrapedContent []ScrapedContent, globalInstructions string, maxStories int) ([]SummarizedStory, error) {
+	prompt := SummarizePrompt(topicName, LimitContent(scrapedContent, c.maxContent), globalInstructions, maxStories, c.minWords, c.maxWords)
+
+	responseText, structured, err := c.generateJSON(ctx, prompt, storiesSchema)
+	if err != nil {
+		return nil, err
+	}
+	if !structured {
+		var stories []SummarizedStory
+		err := c.parseOrRepair(ctx, responseText, func(text string) error {
+			var err error
+			stories, err = ParseStories(text)
+			return err
+		})
+		return stories, err
+	}
+	return ParseStories(responseText)
+}
+
+// consolidate picks the final stories from the candidates summarized batch by batch
+func (c *Client) consolidate(ctx context.Context, topicName string, candidates []SummarizedStory, globalInstructions string, maxStories int) ([]SummarizedStory, error) {
+	prompt := ConsolidatePrompt(topicName, candidates, globalInstructions, maxStories, c.minWords, c.maxWords)
+
+	responseText, _, err := c.generateJSON(ctx, prompt, consolidatedSchema)
+	if err != nil {
+		return nil, err
+	}
+	var stories []SummarizedStory
+	err = c.parseOrRepair(ctx, responseText, func(text string) error {
+		var err error
+		stories, err = ParseConsolidated(text, candidates, maxStories)
+		return err
+	})
+	return stories, err
+}
+
+// ScrapedContent represents content scraped from a source
+type ScrapedContent struct {
+	URL        string
+	SourceName string
+	Content    string
+	Feed       bool // the content came from the items of an RSS or Atom feed
+	// Images maps the page URL and feed item links to the preview image found for them
+	Images map[string]string
+	// Published maps feed item links to when the feed says they were published
+	Published map[string]time.Time
+}
+
+const (
+	// DefaultMaxContentLength is the default cap on scraped text sent in one summarize prompt
+	DefaultMaxContentLength = 60000
+	// DefaultSummaryMinWords and DefaultSummaryMaxWords are the default story summary length range
+	DefaultSummaryMinWords = 75
+	DefaultSummaryMaxWords = 150
+	// DefaultMinSources and DefaultMaxSources are the default number of sources discovery asks for
+	DefaultMinSources = 4
+	DefaultMaxSources = 8
+)
+
+// LimitContent trims scraped content so the sources add up to at most max bytes of text.
+// Each source gets an equal share, and whatever short sources don't use goes to the
+// longer ones, so a single long page can't crowd out the rest. A max of 0 disables the cap.
+func LimitContent(scrapedContent []ScrapedContent, max int) []ScrapedContent {
+	if max <= 0 || ContentLength(scrapedContent) <= max {
+		return scrapedContent
+	}
+
+	// Hand out shares shortest source first
+	order := make([]int, len(scrapedContent))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return len(scrapedContent[order[a]].Content) < len(scrapedContent[order[b]].Content)
+	})
+
+	limited := make([]ScrapedContent, len(scrapedContent))
+	remaining := max
+	for n, i := range order {
+		c := scrapedContent[i]
+		share := remaining / (len(order) - n)
+		if len(c.Content) > share {
+			c.Content = c.Content[:share] + "..."
+			remaining -= share
+		} else {
+			remaining -= len(c.Content)
+		}
+		limited[i] = c
+	}
+	return limited
+}
+
+// DiscoverSourcesPrompt builds the source discovery prompt shared by all AI providers,
+// asking for minSources to maxSources sources
+func DiscoverSourcesPrompt(topicName, topicDescription, globalInstructions string, minSources, maxSources int) string {
+	count := fmt.Sprintf("%d-%d", minSources, maxSources)
+	if minSources >= maxSources {
+		count = strconv.Itoa(maxSources)
+	}
+
+	return fmt.Sprintf(`You are a helpful assistant that discovers reliable web sources for news topics.
 
 Topic: %s
 Description: %s
 
 %s
 
-Find 4-8 reliable sources that provide ongoing news and updates related to this topic. Sources can include:
+Find %s reliable sources that provide ongoing news and updates related to this topic. Sources can include:
 - News websites and RSS feeds
 - Reddit subreddits (format as https://reddit.com/r/subredditname)
 - Technical blogs or official sources
@@ -83,38 +519,11 @@ Format your response as a JSON array like this:
 [
   {"url": "https://example.com/feed", "name": "Example News", "description": "Daily updates on topic"},
   {"url": "https://reddit.com/r/technology", "name": "r/technology", "description": "Tech news and discussion"}
-]`, topicName, topicDescription, globalInstructions)
-
-	result, err := c.client.Models.GenerateContent(ctx, c.model,
-		[]*genai.Content{{Parts: []*genai.Part{{Text: prompt}}}},
-		nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate content: %w", err)
-	}
-
-	// Extract text from response
-	responseText := extractText(result)
-	if responseText == "" {
-		return nil, fmt.Errorf("empty response from Gemini")
-	}
-
-	// Clean up the response - remove markdown code blocks if present
-	responseText = cleanJSONResponse(responseText)
-
-	var sources []DiscoveredSource
-	if err := json.Unmarshal([]byte(responseText), &sources); err != nil {
-		return nil, fmt.Errorf("failed to parse sources JSON: %w (response: %s)", err, responseText)
-	}
-
-	return sources, nil
+]`, topicName, topicDescription, globalInstructions, count)
 }
 
-// SummarizeContent summarizes scraped content into news stories
-func (c *Client) SummarizeContent(ctx context.Context, topicName string, scrapedContent []ScrapedContent, globalInstructions string, maxStories int) ([]SummarizedStory, error) {
-	if len(scrapedContent) == 0 {
-		return nil, nil
-	}
-
+// SummarizePrompt builds the summarization prompt shared by all AI providers
+func SummarizePrompt(topicName string, scrapedContent []ScrapedContent, globalInstructions string, maxStories, minWords, maxWords int) string {
 	// Build content string from scraped data
 	var contentBuilder strings.Builder
 	for i, content := range scrapedContent {
@@ -122,7 +531,7 @@ func (c *Client) SummarizeContent(ctx context.Context, topicName string, scraped
 			i+1, content.SourceName, content.URL, content.Content))
 	}
 
-	prompt := fmt.Sprintf(`You are a news summarization assistant. Your task is to analyze the following scraped content and create clear, informative news summaries.
+	return fmt.Sprintf(`You are a news summarization assistant. Your task is to analyze the following scraped content and create clear, informative news summaries.
 
 Topic: %s
 
@@ -141,46 +550,46 @@ IMPORTANT FILTERING RULES:
 
 For each story:
 1. Create a compelling headline (title)
-2. Write a summary of 75-150 words focusing on key facts and why this story matters
+2. Write a summary of %d-%d words focusing on key facts and why this story matters
 3. Include the source URL where the story was found (for Reddit posts, use the full permalink URL)
 4. Include the source name/title
+5. Add 1-%d short lowercase tags saying what kind of story it is, such as "politics", "release", "research" or "rumor". Reuse the same tag for the same kind of story
+6. Rate the story's importance from %d to %d: 10 for major news most followers of the topic need to know, 1 for minor items and chatter
+7. List in citations the URL of every source or article the summary takes facts from, the source URL above included. Only use URLs that appear in the scraped content
 
 IMPORTANT: Return ONLY a valid JSON array with no additional text, markdown, or explanation. The response must be parseable JSON.
 
 Format your response as a JSON array like this:
 [
-  {"title": "Headline Here", "summary": "Summary text here...", "source_url": "https://source.com/article", "source_title": "Source Name"}
-]`, topicName, globalInstructions, contentBuilder.String(), maxStories, topicName)
+  {"title": "Headline Here", "summary": "Summary text here...", "source_url": "https://source.com/article", "source_title": "Source Name", "tags": ["release"], "importance": 6, "citations": ["https://source.com/article", "https://other.com/report"]}
+]`, topicName, globalInstructions, contentBuilder.String(), maxStories, topicName, minWords, maxWords, MaxTags, MinImportance, MaxImportance)
+}
 
-	result, err := c.client.Models.GenerateContent(ctx, c.model,
-		[]*genai.Content{{Parts: []*genai.Part{{Text: prompt}}}},
-		nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate content: %w", err)
-	}
+// ParseSources parses a model response containing a JSON array of discovered sources
+func ParseSources(responseText string) ([]DiscoveredSource, error) {
+	// Clean up the response - remove markdown code blocks if present
+	responseText = cleanJSONResponse(responseText)
 
-	responseText := extractText(result)
-	if responseText == "" {
-		return nil, fmt.Errorf("empty response from Gemini")
+	var sources []DiscoveredSource
+	if err := unmarshalLenient(responseText, &sources); err != nil {
+		return nil, fmt.Errorf("failed to parse sources JSON: %w (response: %s)", err, responseText)
 	}
 
+	return sources, nil
+}
+
+// ParseStories parses a model response containing a JSON array of summarized stories
+func ParseStories(responseText string) ([]SummarizedStory, error) {
 	responseText = cleanJSONResponse(responseText)
 
 	var stories []SummarizedStory
-	if err := json.Unmarshal([]byte(responseText), &stories); err != nil {
+	if err := unmarshalLenient(responseText, &stories); err != nil {
 		return nil, fmt.Errorf("failed to parse stories JSON: %w (response: %s)", err, responseText)
 	}
 
 	return stories, nil
 }
 
-// ScrapedContent represents content scraped from a source
-type ScrapedContent struct {
-	URL        string
-	SourceName string
-	Content    string
-}
-
 // extractText extracts text from a Gemini response
 func extractText(result *genai.GenerateContentResponse) string {
 	if result == nil || len(result.Candidates) == 0 {
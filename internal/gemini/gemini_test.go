@@ -0,0 +1,214 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+// roundTripFunc answers API requests without touching the network
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newTestClient returns a client whose API requests are sent to rt
+func newTestClient(t *testing.T, rt http.RoundTripper) *Client {
+	t.Helper()
+	c, err := New("test-key", "")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c.client, err = genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:     "test-key",
+		Backend:    genai.BackendGeminiAPI,
+		HTTPClient: &http.Client{Transport: rt},
+	})
+	if err != nil {
+		t.Fatalf("genai.NewClient: %v", err)
+	}
+	return c
+}
+
+// reply builds an API response with the given status and JSON body
+func reply(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+// textReply is the body of a successful GenerateContent response carrying text
+func textReply(t *testing.T, text string) string {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{
+		"candidates": []map[string]any{{
+			"content":      map[string]any{"role": "model", "parts": []map[string]any{{"text": text}}},
+			"finishReason": "STOP",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("marshal reply: %v", err)
+	}
+	return string(body)
+}
+
+// errorReply is the body of a failed API call
+func errorReply(code int, status string) string {
+	body, _ := json.Marshal(map[string]any{
+		"error": map[string]any{"code": code, "message": "test error", "status": status},
+	})
+	return string(body)
+}
+
+// readBody returns a request's body as a string
+func readBody(t *testing.T, req *http.Request) string {
+	t.Helper()
+	if req.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Errorf("read request body: %v", err)
+	}
+	return string(body)
+}
+
+// storyFixtures are recorded model replies to the summarize prompt that aren't valid JSON as sent
+var storyFixtures = []string{
+	"stories_prose.txt",
+	"stories_fenced.txt",
+	"stories_trailing_comma.txt",
+	"stories_raw_newline.txt",
+}
+
+func loadFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return string(data)
+}
+
+var testContent = []ScrapedContent{{URL: "https://go.dev/blog", SourceName: "The Go Blog", Content: "Go 1.26 is out."}}
+
+// checkFixtureStories checks the stories parsed from one of the story fixtures
+func checkFixtureStories(t *testing.T, stories []SummarizedStory) {
+	t.Helper()
+	want := []string{"Go 1.26 released", "Raspberry Pi 6 announced"}
+	if len(stories) != len(want) {
+		t.Fatalf("got %d stories, want %d", len(stories), len(want))
+	}
+	for i, title := range want {
+		if stories[i].Title != title {
+			t.Errorf("story %d title = %q, want %q", i, stories[i].Title, title)
+		}
+		if stories[i].Summary == "" || stories[i].SourceURL == "" {
+			t.Errorf("story %d is missing its summary or source: %+v", i, stories[i])
+		}
+	}
+}
+
+func TestSummarizeStructuredMalformed(t *testing.T) {
+	for _, name := range storyFixtures {
+		t.Run(name, func(t *testing.T) {
+			fixture := loadFixture(t, name)
+			requests := 0
+			c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				requests++
+				if body := readBody(t, req); !strings.Contains(body, "responseSchema") {
+					t.Errorf("request %d has no response schema", requests)
+				}
+				return reply(req, http.StatusOK, textReply(t, fixture)), nil
+			}))
+
+			stories, err := c.SummarizeContent(context.Background(), "Tech", testContent, "", 5)
+			if err != nil {
+				t.Fatalf("SummarizeContent: %v", err)
+			}
+			checkFixtureStories(t, stories)
+			if requests != 1 {
+				t.Errorf("made %d requests, want 1", requests)
+			}
+		})
+	}
+}
+
+func TestSummarizeFallsBackWhenSchemaRejected(t *testing.T) {
+	for _, name := range storyFixtures {
+		t.Run(name, func(t *testing.T) {
+			fixture := loadFixture(t, name)
+			var schemaRequests, plainRequests int
+			c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				if strings.Contains(readBody(t, req), "responseSchema") {
+					schemaRequests++
+					return reply(req, http.StatusBadRequest, errorReply(400, "INVALID_ARGUMENT")), nil
+				}
+				plainRequests++
+				return reply(req, http.StatusOK, textReply(t, fixture)), nil
+			}))
+
+			stories, err := c.SummarizeContent(context.Background(), "Tech", testContent, "", 5)
+			if err != nil {
+				t.Fatalf("SummarizeContent: %v", err)
+			}
+			checkFixtureStories(t, stories)
+			if schemaRequests != 1 || plainRequests != 1 {
+				t.Errorf("made %d schema and %d plain requests, want 1 of each", schemaRequests, plainRequests)
+			}
+		})
+	}
+}
+
+func TestDiscoverSourcesFallsBackWhenSchemaRejected(t *testing.T) {
+	var plainRequests int
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.Contains(readBody(t, req), "responseSchema") {
+			return reply(req, http.StatusBadRequest, errorReply(400, "INVALID_ARGUMENT")), nil
+		}
+		plainRequests++
+		return reply(req, http.StatusOK, textReply(t, loadFixture(t, "sources_prose.txt"))), nil
+	}))
+
+	sources, err := c.DiscoverSources(context.Background(), "Tech", "Technology news", "")
+	if err != nil {
+		t.Fatalf("DiscoverSources: %v", err)
+	}
+	if plainRequests != 1 {
+		t.Errorf("made %d plain requests, want 1", plainRequests)
+	}
+	if len(sources) != 2 || sources[0].URL != "https://go.dev/blog" || sources[1].URL != "https://hnrss.org/frontpage" {
+		t.Errorf("sources = %+v, want the two from the fixture", sources)
+	}
+}
+
+func TestSummarizeSchemaRequestTransientErrorNoFallback(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var plainRequests int
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if !strings.Contains(readBody(t, req), "responseSchema") {
+			plainRequests++
+		}
+		// Cancel so the retry loop gives up instead of backing off
+		cancel()
+		return reply(req, http.StatusServiceUnavailable, errorReply(503, "UNAVAILABLE")), nil
+	}))
+
+	if _, err := c.SummarizeContent(ctx, "Tech", testContent, "", 5); err == nil {
+		t.Fatal("SummarizeContent succeeded, want an error")
+	}
+	if plainRequests != 0 {
+		t.Errorf("made %d plain requests after a transient error, want none", plainRequests)
+	}
+}
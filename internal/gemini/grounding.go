@@ -0,0 +1,107 @@
+package gemini
+
+import (
+	"net/url"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// groundingConfig enables the Google Search tool, so the model looks sources up instead
+// of recalling URLs from memory. Structured output can't be combined with tools, so the
+// response is parsed like a plain prompt's.
+var groundingConfig = &genai.GenerateContentConfig{
+	Tools: []*genai.Tool{{GoogleSearch: &genai.GoogleSearch{}}},
+}
+
+// groundedSites returns the sites Google Search found while grounding a response. The
+// Gemini API gives each result as a temporary redirect URL titled with the site's domain,
+// so the domain's home page stands in for the result.
+func groundedSites(result *genai.GenerateContentResponse) []DiscoveredSource {
+	if result == nil {
+		return nil
+	}
+
+	var sites []DiscoveredSource
+	seen := make(map[string]bool)
+	for _, candidate := range result.Candidates {
+		if candidate.GroundingMetadata == nil {
+			continue
+		}
+		for _, chunk := range candidate.GroundingMetadata.GroundingChunks {
+			if chunk.Web == nil {
+				continue
+			}
+			domain := strings.ToLower(strings.TrimPrefix(chunk.Web.Title, "www."))
+			if !strings.Contains(domain, ".") || strings.ContainsAny(domain, " /") || seen[domain] {
+				continue
+			}
+			seen[domain] = true
+			sites = append(sites, DiscoveredSource{
+				URL:         "https://" + domain,
+				Name:        domain,
+				Description: "Found by Google Search",
+			})
+		}
+	}
+	return sites
+}
+
+// mergeGrounded combines the model's sources with the sites search actually found.
+// Sources on a searched site come first, then searched sites the model didn't list,
+// then the model's remaining sources, which had no search result to back them up.
+func mergeGrounded(sources, sites []DiscoveredSource) []DiscoveredSource {
+	if len(sites) == 0 {
+		return sources
+	}
+
+	var confirmed, unconfirmed []DiscoveredSource
+	matched := make([]bool, len(sites))
+	for _, source := range sources {
+		found := false
+		for i, site := range sites {
+			if sameSite(source.URL, site.Name) {
+				matched[i] = true
+				found = true
+			}
+		}
+		if found {
+			confirmed = append(confirmed, source)
+		} else {
+			unconfirmed = append(unconfirmed, source)
+		}
+	}
+
+	merged := confirmed
+	for i, site := range sites {
+		if !matched[i] {
+			merged = append(merged, site)
+		}
+	}
+	return append(merged, unconfirmed...)
+}
+
+// sameSite reports whether rawURL is on domain or one of its subdomains, or domain is a
+// subdomain of rawURL's host
+func sameSite(rawURL, domain string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	if host == "" {
+		return false
+	}
+	return host == domain || strings.HasSuffix(host, "."+domain) || strings.HasSuffix(domain, "."+host)
+}
+
+// jsonArray returns the outermost JSON array in text. Grounded responses can't be
+// constrained to JSON, so the model sometimes adds a sentence before or after it.
+func jsonArray(text string) string {
+	start := strings.Index(text, "[")
+	end := strings.LastIndex(text, "]")
+	if start < 0 || end < start {
+		return text
+	}
+	return text[start : end+1]
+}
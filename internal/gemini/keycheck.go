@@ -0,0 +1,62 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// Reasons a key check can fail, as returned by FailureReason
+const (
+	FailureInvalidKey    = "invalid_key"
+	FailureQuotaExceeded = "quota_exceeded"
+	FailureUnknownModel  = "unknown_model"
+	FailureTimeout       = "timeout"
+	FailureNetwork       = "network"
+	FailureOther         = "error"
+)
+
+// CheckKey makes the smallest useful GenerateContent call to confirm the key works with the
+// client's model. It isn't retried, so a rate limit is reported straight away.
+func (c *Client) CheckKey(ctx context.Context) error {
+	config := &genai.GenerateContentConfig{MaxOutputTokens: 5}
+	_, err := c.generateWith(ctx, c.model, "Reply with OK.", config)
+	return err
+}
+
+// Model returns the primary model the client calls
+func (c *Client) Model() string {
+	return c.model
+}
+
+// FailureReason sorts an error from the Gemini API into one of the Failure reasons, so a
+// wrong key can be told apart from a spent quota or an unreachable API
+func FailureReason(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return FailureTimeout
+	}
+	apiErr, ok := asAPIError(err)
+	if !ok {
+		// DNS failures, refused connections and the like never got an answer from the API
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			return FailureNetwork
+		}
+		return FailureOther
+	}
+	switch {
+	case apiErr.Code == 429 || apiErr.Status == "RESOURCE_EXHAUSTED":
+		return FailureQuotaExceeded
+	case apiErr.Code == 401 || apiErr.Code == 403:
+		return FailureInvalidKey
+	case apiErr.Code == 400 && strings.Contains(strings.ToLower(apiErr.Message), "api key"):
+		// An unknown key is a 400 INVALID_ARGUMENT saying "API key not valid"
+		return FailureInvalidKey
+	case apiErr.Code == 404:
+		return FailureUnknownModel
+	}
+	return FailureOther
+}
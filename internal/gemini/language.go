@@ -0,0 +1,37 @@
+package gemini
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// ParseLanguage checks an ISO 639 language code, optionally with a region as in "pt-BR",
+// and returns it in canonical form. An empty code is valid and means no language is asked for.
+func ParseLanguage(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", nil
+	}
+	tag, err := language.Parse(code)
+	if err != nil {
+		return "", fmt.Errorf("unknown language code %q, expected an ISO 639 code such as \"de\" or \"pt-BR\"", code)
+	}
+	return tag.String(), nil
+}
+
+// LanguageInstruction returns the prompt text asking for titles and summaries in the
+// language with the given code, or "" if code is empty or unknown
+func LanguageInstruction(code string) string {
+	tag, err := language.Parse(strings.TrimSpace(code))
+	if err != nil {
+		return ""
+	}
+	name := display.English.Tags().Name(tag)
+	if name == "" {
+		name = tag.String()
+	}
+	return fmt.Sprintf("Write every story title and summary in %s (%s), translating from the sources where they use another language. Keep names of people, organizations and products as they are.", name, tag)
+}
@@ -0,0 +1,54 @@
+package gemini
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Summary length presets. Standard uses the configured word range; the others replace it.
+const (
+	SummaryLengthHeadline = "headline"
+	SummaryLengthShort    = "short"
+	SummaryLengthStandard = "standard"
+	SummaryLengthLong     = "long"
+)
+
+// SummaryLengths lists the summary length presets from shortest to longest
+var SummaryLengths = []string{SummaryLengthHeadline, SummaryLengthShort, SummaryLengthStandard, SummaryLengthLong}
+
+// ParseSummaryLength checks a summary length preset and returns it lowercased. An empty
+// preset is valid and means none is chosen.
+func ParseSummaryLength(preset string) (string, error) {
+	preset = strings.ToLower(strings.TrimSpace(preset))
+	if preset == "" {
+		return "", nil
+	}
+	for _, known := range SummaryLengths {
+		if preset == known {
+			return preset, nil
+		}
+	}
+	return "", fmt.Errorf("unknown summary length %q, expected one of %s", preset, strings.Join(SummaryLengths, ", "))
+}
+
+// SummaryWords returns the summary word range for a preset. Standard, empty and unknown
+// presets give the configured minWords-maxWords range.
+func SummaryWords(preset string, minWords, maxWords int) (int, int) {
+	switch preset {
+	case SummaryLengthHeadline:
+		return 10, 25
+	case SummaryLengthShort:
+		return 30, 50
+	case SummaryLengthLong:
+		return 200, 300
+	}
+	return minWords, maxWords
+}
+
+// SummaryLengthInstruction returns extra prompt text for a preset, or "" if it needs none
+func SummaryLengthInstruction(preset string) string {
+	if preset == SummaryLengthHeadline {
+		return "Keep each summary to a single sentence that adds the most important fact the headline leaves out."
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package gemini
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// GenerationParams tune how the model writes its responses
+type GenerationParams struct {
+	Temperature     float64 // 0-2; lower sticks closer to the source text
+	TopP            float64 // 0-1
+	MaxOutputTokens int     // 0 for the model's default
+	SafetyThreshold string  // one of SafetyThresholds, "" for the API's default
+}
+
+// SafetyThresholds lists the accepted safety thresholds, most permissive first
+var SafetyThresholds = []string{
+	string(genai.HarmBlockThresholdBlockNone),
+	string(genai.HarmBlockThresholdBlockOnlyHigh),
+	string(genai.HarmBlockThresholdBlockMediumAndAbove),
+	string(genai.HarmBlockThresholdBlockLowAndAbove),
+}
+
+// safetyCategories are the harm categories a safety threshold applies to
+var safetyCategories = []genai.HarmCategory{
+	genai.HarmCategoryHarassment,
+	genai.HarmCategoryHateSpeech,
+	genai.HarmCategorySexuallyExplicit,
+	genai.HarmCategoryDangerousContent,
+}
+
+// SetGenerationParams sets the sampling, length, and safety parameters sent with every request
+func (c *Client) SetGenerationParams(p GenerationParams) {
+	c.params = &p
+}
+
+// withParams returns a copy of config with the generation parameters applied, leaving
+// shared configs like groundingConfig untouched
+func (c *Client) withParams(config *genai.GenerateContentConfig) *genai.GenerateContentConfig {
+	if c.params == nil {
+		return config
+	}
+	var out genai.GenerateContentConfig
+	if config != nil {
+		out = *config
+	}
+
+	temperature, topP := float32(c.params.Temperature), float32(c.params.TopP)
+	out.Temperature = &temperature
+	out.TopP = &topP
+	out.MaxOutputTokens = int32(c.params.MaxOutputTokens)
+	if c.params.SafetyThreshold != "" {
+		out.SafetySettings = nil
+		for _, category := range safetyCategories {
+			out.SafetySettings = append(out.SafetySettings, &genai.SafetySetting{
+				Category:  category,
+				Threshold: genai.HarmBlockThreshold(c.params.SafetyThreshold),
+			})
+		}
+	}
+	return &out
+}
+
+// responseText returns the text of a response, or if there is none, an error saying why:
+// a blocked prompt, a candidate stopped by the safety filters, or an empty reply
+func responseText(result *genai.GenerateContentResponse) (string, error) {
+	if text := extractText(result); text != "" {
+		return text, nil
+	}
+	if result == nil {
+		return "", fmt.Errorf("empty response from Gemini")
+	}
+
+	if fb := result.PromptFeedback; fb != nil && fb.BlockReason != "" {
+		return "", fmt.Errorf("Gemini blocked the prompt: %s", fb.BlockReason)
+	}
+	for _, candidate := range result.Candidates {
+		switch candidate.FinishReason {
+		case "", genai.FinishReasonStop:
+			continue
+		}
+		var blocked []string
+		for _, rating := range candidate.SafetyRatings {
+			if rating.Blocked {
+				blocked = append(blocked, string(rating.Category))
+			}
+		}
+		if len(blocked) > 0 {
+			return "", fmt.Errorf("Gemini stopped the response: %s (%s)", candidate.FinishReason, strings.Join(blocked, ", "))
+		}
+		return "", fmt.Errorf("Gemini stopped the response: %s", candidate.FinishReason)
+	}
+	return "", fmt.Errorf("empty response from Gemini")
+}
@@ -0,0 +1,143 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// unmarshalLenient unmarshals a model's JSON reply into v. If the reply isn't valid JSON
+// it is put through RepairJSON and tried again; if that fails too, the original error is
+// returned.
+func unmarshalLenient(text string, v any) error {
+	err := json.Unmarshal([]byte(text), v)
+	if err == nil {
+		return nil
+	}
+	if repaired := RepairJSON(text); repaired != text && json.Unmarshal([]byte(repaired), v) == nil {
+		return nil
+	}
+	return err
+}
+
+// RepairJSON fixes the mistakes models make most often when writing a JSON array by hand:
+// text around the array, trailing commas, single-quoted strings, raw newlines and other
+// control characters inside strings, and unescaped double quotes inside strings. The
+// result is only a better guess, so it still needs to be checked by unmarshalling it.
+func RepairJSON(text string) string {
+	text = jsonArray(cleanJSONResponse(text))
+
+	var out strings.Builder
+	out.Grow(len(text))
+	var quote byte // the quote character of the string being read, 0 outside strings
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case quote == 0:
+			switch ch {
+			case '"', '\'':
+				quote = ch
+				out.WriteByte('"')
+			case ',':
+				// Drop a comma that only closes the array or object after it
+				if next := nextNonSpace(text, i+1); next != ']' && next != '}' {
+					out.WriteByte(ch)
+				}
+			default:
+				out.WriteByte(ch)
+			}
+
+		case ch == '\\' && i+1 < len(text):
+			i++
+			if text[i] == '\'' {
+				// \' is not a JSON escape; a plain apostrophe is fine in a JSON string
+				out.WriteByte('\'')
+			} else {
+				out.WriteByte(ch)
+				out.WriteByte(text[i])
+			}
+
+		case ch == quote && closesString(text, i+1):
+			quote = 0
+			out.WriteByte('"')
+
+		case ch == '"':
+			// A double quote that doesn't end the string, or one inside a single-quoted string
+			out.WriteString(`\"`)
+
+		case ch == '\n':
+			out.WriteString(`\n`)
+		case ch == '\r':
+			out.WriteString(`\r`)
+		case ch == '\t':
+			out.WriteString(`\t`)
+		case ch < 0x20:
+			fmt.Fprintf(&out, `\u%04x`, ch)
+
+		default:
+			out.WriteByte(ch)
+		}
+	}
+	return out.String()
+}
+
+// closesString reports whether a quote just before text[i] ends the string it's in, judged
+// by what follows it: a string ends before a comma, colon, closing bracket, or the end
+func closesString(text string, i int) bool {
+	switch nextNonSpace(text, i) {
+	case ',', ':', ']', '}', 0:
+		return true
+	}
+	return false
+}
+
+// nextNonSpace returns the first byte of text from i on that isn't whitespace, or 0 if there is none
+func nextNonSpace(text string, i int) byte {
+	for ; i < len(text); i++ {
+		switch text[i] {
+		case ' ', '\t', '\n', '\r':
+			continue
+		}
+		return text[i]
+	}
+	return 0
+}
+
+// BriefError returns a parse error's message without the reply it quotes, for prompts
+// that include the reply already
+func BriefError(err error) string {
+	msg, _, _ := strings.Cut(err.Error(), " (response:")
+	return msg
+}
+
+// RepairPrompt asks a model to correct a reply that couldn't be parsed as the requested JSON
+func RepairPrompt(reply string, parseErr error) string {
+	return fmt.Sprintf(`The reply below was supposed to be a JSON array but could not be parsed (%s).
+
+Reply with only the corrected JSON array, keeping its content the same, and nothing else.
+
+Reply:
+%s`, BriefError(parseErr), reply)
+}
+
+// parseOrRepair hands a reply to parse and, if it can't be parsed even after RepairJSON,
+// asks the model once to correct it and parses the correction instead
+func (c *Client) parseOrRepair(ctx context.Context, reply string, parse func(text string) error) error {
+	parseErr := parse(reply)
+	if parseErr == nil || ctx.Err() != nil {
+		return parseErr
+	}
+
+	result, err := c.generate(ctx, RepairPrompt(reply, parseErr), &genai.GenerateContentConfig{ResponseMIMEType: "application/json"})
+	if err != nil {
+		return fmt.Errorf("%w (repair request also failed: %v)", parseErr, err)
+	}
+	text, err := responseText(result)
+	if err != nil {
+		return fmt.Errorf("%w (repair request also failed: %v)", parseErr, err)
+	}
+	return parse(text)
+}
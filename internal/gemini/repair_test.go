@@ -0,0 +1,161 @@
+package gemini
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalLenient(t *testing.T) {
+	tests := []struct {
+		name      string
+		reply     string
+		summaries []string // the summaries parsed, in order
+		wantErr   bool
+	}{
+		{
+			name:      "valid",
+			reply:     `[{"title": "A", "summary": "First."}, {"title": "B", "summary": "Second."}]`,
+			summaries: []string{"First.", "Second."},
+		},
+		{
+			name:      "trailing comma in array",
+			reply:     `[{"title": "A", "summary": "First."}, {"title": "B", "summary": "Second."},]`,
+			summaries: []string{"First.", "Second."},
+		},
+		{
+			name:      "trailing commas in objects and lists",
+			reply:     "[\n  {\"title\": \"A\", \"summary\": \"First.\", \"tags\": [\"x\", \"y\",],},\n]",
+			summaries: []string{"First."},
+		},
+		{
+			name:      "json code fence",
+			reply:     "```json\n[{\"title\": \"A\", \"summary\": \"First.\"}]\n```",
+			summaries: []string{"First."},
+		},
+		{
+			name:      "bare code fence",
+			reply:     "```\n[{\"title\": \"A\", \"summary\": \"First.\"}]\n```",
+			summaries: []string{"First."},
+		},
+		{
+			name:      "prose around the array",
+			reply:     "Here are today's stories:\n\n[{\"title\": \"A\", \"summary\": \"First.\"}]\n\nI hope this helps!",
+			summaries: []string{"First."},
+		},
+		{
+			name:      "raw newlines in a string",
+			reply:     "[{\"title\": \"A\", \"summary\": \"First line.\n\nSecond paragraph.\"}]",
+			summaries: []string{"First line.\n\nSecond paragraph."},
+		},
+		{
+			name:      "raw tab and carriage return in a string",
+			reply:     "[{\"title\": \"A\", \"summary\": \"Col one\tcol two\r\nnext\"}]",
+			summaries: []string{"Col one\tcol two\r\nnext"},
+		},
+		{
+			name:      "other control character in a string",
+			reply:     "[{\"title\": \"A\", \"summary\": \"bell\x07here\"}]",
+			summaries: []string{"bell\x07here"},
+		},
+		{
+			name:      "single quoted strings",
+			reply:     `[{'title': 'A', 'summary': 'The "quoted" word.'}]`,
+			summaries: []string{`The "quoted" word.`},
+		},
+		{
+			name:      "escaped apostrophe",
+			reply:     `[{"title": "A", "summary": "It\'s out."}]`,
+			summaries: []string{"It's out."},
+		},
+		{
+			name:      "unescaped double quotes in a string",
+			reply:     `[{"title": "A", "summary": "The minister called it "a good day" for the city."}]`,
+			summaries: []string{`The minister called it "a good day" for the city.`},
+		},
+		{
+			name:    "truncated mid-string",
+			reply:   `[{"title": "A", "summary": "First."}, {"title": "B", "summary": "The ne`,
+			wantErr: true,
+		},
+		{
+			name:    "truncated before the closing bracket",
+			reply:   `[{"title": "A", "summary": "First."}, {"title": "B", "summary": "Second."}`,
+			wantErr: true,
+		},
+		{
+			name:    "no array at all",
+			reply:   "I could not find any stories about this topic.",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stories []SummarizedStory
+			err := unmarshalLenient(tt.reply, &stories)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("unmarshalLenient succeeded with %+v, want an error", stories)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unmarshalLenient: %v (repaired: %s)", err, RepairJSON(tt.reply))
+			}
+			if len(stories) != len(tt.summaries) {
+				t.Fatalf("got %d stories, want %d", len(stories), len(tt.summaries))
+			}
+			for i, want := range tt.summaries {
+				if stories[i].Summary != want {
+					t.Errorf("story %d summary = %q, want %q", i, stories[i].Summary, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseOrRepairReprompts(t *testing.T) {
+	truncated := `[{"title": "A", "summary": "First."}, {"title": "B", "summary": "Sec`
+	fixed := `[{"title": "A", "summary": "First."}, {"title": "B", "summary": "Second."}]`
+
+	var prompts []string
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		prompts = append(prompts, readBody(t, req))
+		return reply(req, http.StatusOK, textReply(t, fixed)), nil
+	}))
+
+	var stories []SummarizedStory
+	err := c.parseOrRepair(context.Background(), truncated, func(text string) error {
+		var err error
+		stories, err = ParseStories(text)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("parseOrRepair: %v", err)
+	}
+	if len(stories) != 2 || stories[1].Summary != "Second." {
+		t.Errorf("stories = %+v, want the corrected reply", stories)
+	}
+	if len(prompts) != 1 {
+		t.Fatalf("made %d requests, want one repair prompt", len(prompts))
+	}
+	if !strings.Contains(prompts[0], "could not be parsed") {
+		t.Errorf("request is not a repair prompt: %s", prompts[0])
+	}
+}
+
+func TestParseOrRepairValidNoRequest(t *testing.T) {
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Error("a reply that parses should not be sent back to the model")
+		return reply(req, http.StatusOK, textReply(t, "[]")), nil
+	}))
+
+	err := c.parseOrRepair(context.Background(), `[{"title": "A", "summary": "First.",}]`, func(text string) error {
+		_, err := ParseStories(text)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("parseOrRepair: %v", err)
+	}
+}
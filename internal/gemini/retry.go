@@ -0,0 +1,108 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+const (
+	// maxAttempts is how many times an API call is tried before giving up on a transient error
+	maxAttempts = 3
+	// retryBaseDelay is the wait before the first retry; it doubles on each further attempt
+	retryBaseDelay = 2 * time.Second
+	// maxRetryDelay caps the wait between attempts, including delays asked for by the API
+	maxRetryDelay = time.Minute
+)
+
+// withRetry calls fn until it succeeds, fails with an error that isn't transient, or
+// maxAttempts calls have been made. It waits with jittered exponential backoff between
+// attempts, or as long as the API asked for, and gives up early rather than wait past
+// the context's deadline.
+func withRetry(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isTransient(err) {
+			return err
+		}
+		if attempt == maxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		delay := retryDelay(err, attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return fmt.Errorf("giving up after %d attempts, no time left to retry: %w", attempt, err)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+	}
+}
+
+// isTransient reports whether err is an API error worth retrying: rate limiting or a
+// temporary server problem. Anything else, such as a bad key or request, fails fast.
+func isTransient(err error) bool {
+	apiErr, ok := asAPIError(err)
+	if !ok {
+		return false
+	}
+	switch apiErr.Code {
+	case 429, 500, 502, 503, 504:
+		return true
+	}
+	return false
+}
+
+// canFallBack reports whether a call that failed with err is worth trying on the fallback
+// model. A bad request or key would fail there too, so only outages, rate limits, and
+// unavailable models fall back. Responses blocked by the safety filter aren't errors here
+// and never reach the fallback either.
+func canFallBack(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if apiErr, ok := asAPIError(err); ok {
+		switch apiErr.Code {
+		case 400, 401, 403:
+			return false
+		}
+	}
+	return true
+}
+
+// asAPIError extracts the genai API error from err, if there is one
+func asAPIError(err error) (genai.APIError, bool) {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	var apiErrPtr *genai.APIError
+	if errors.As(err, &apiErrPtr) && apiErrPtr != nil {
+		return *apiErrPtr, true
+	}
+	return genai.APIError{}, false
+}
+
+// retryDelay returns how long to wait before the next attempt: the delay in the error's
+// RetryInfo if the API sent one, otherwise exponential backoff with ±20% jitter
+func retryDelay(err error, attempt int) time.Duration {
+	if apiErr, ok := asAPIError(err); ok {
+		for _, detail := range apiErr.Details {
+			if s, ok := detail["retryDelay"].(string); ok {
+				if d, err := time.ParseDuration(s); err == nil && d > 0 {
+					return min(d, maxRetryDelay)
+				}
+			}
+		}
+	}
+
+	delay := retryBaseDelay << (attempt - 1)
+	jitter := time.Duration((rand.Float64()*0.4 - 0.2) * float64(delay))
+	return min(delay+jitter, maxRetryDelay)
+}
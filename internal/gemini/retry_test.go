@@ -0,0 +1,135 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// rateLimitReply is the body of a 429 response asking the caller to wait retryDelay
+func rateLimitReply(retryDelay string) string {
+	body, _ := json.Marshal(map[string]any{
+		"error": map[string]any{
+			"code":    429,
+			"message": "Resource has been exhausted",
+			"status":  "RESOURCE_EXHAUSTED",
+			"details": []map[string]any{{
+				"@type":      "type.googleapis.com/google.rpc.RetryInfo",
+				"retryDelay": retryDelay,
+			}},
+		},
+	})
+	return string(body)
+}
+
+func TestGenerateRetriesRateLimit(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		if requests == 1 {
+			return reply(req, http.StatusTooManyRequests, rateLimitReply("0.01s")), nil
+		}
+		return reply(req, http.StatusOK, textReply(t, "hello")), nil
+	}))
+
+	result, err := c.generate(context.Background(), "hi", nil)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if text := extractText(result); text != "hello" {
+		t.Errorf("text = %q, want %q", text, "hello")
+	}
+	if requests != 2 {
+		t.Errorf("made %d requests, want 2", requests)
+	}
+}
+
+func TestGenerateNoRetryOnBadRequest(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return reply(req, http.StatusBadRequest, errorReply(400, "INVALID_ARGUMENT")), nil
+	}))
+
+	if _, err := c.generate(context.Background(), "hi", nil); err == nil {
+		t.Fatal("generate succeeded, want an error")
+	}
+	if requests != 1 {
+		t.Errorf("made %d requests, want 1", requests)
+	}
+}
+
+func TestGenerateRetryCutShortByDeadline(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return reply(req, http.StatusTooManyRequests, rateLimitReply("30s")), nil
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := c.generate(ctx, "hi", nil)
+	if err == nil || !strings.Contains(err.Error(), "no time left to retry") {
+		t.Errorf("generate error = %v, want it to give up for lack of time", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("generate took %v, want it to give up without waiting", elapsed)
+	}
+	if requests != 1 {
+		t.Errorf("made %d requests, want 1", requests)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	withDelay := func(d string) error {
+		return genai.APIError{Code: 429, Details: []map[string]any{{"retryDelay": d}}}
+	}
+	tests := []struct {
+		name     string
+		err      error
+		attempt  int
+		min, max time.Duration
+	}{
+		{"API delay", withDelay("7s"), 1, 7 * time.Second, 7 * time.Second},
+		{"API delay capped", withDelay("300s"), 1, maxRetryDelay, maxRetryDelay},
+		{"bad API delay falls back to backoff", withDelay("soon"), 1, 1600 * time.Millisecond, 2400 * time.Millisecond},
+		{"first backoff", genai.APIError{Code: 503}, 1, 1600 * time.Millisecond, 2400 * time.Millisecond},
+		{"second backoff", genai.APIError{Code: 503}, 2, 3200 * time.Millisecond, 4800 * time.Millisecond},
+		{"backoff capped", genai.APIError{Code: 503}, 10, maxRetryDelay, maxRetryDelay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if got := retryDelay(tt.err, tt.attempt); got < tt.min || got > tt.max {
+					t.Fatalf("retryDelay = %v, want within [%v, %v]", got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{genai.APIError{Code: 429}, true},
+		{genai.APIError{Code: 500}, true},
+		{genai.APIError{Code: 503}, true},
+		{&genai.APIError{Code: 504}, true},
+		{genai.APIError{Code: 400}, false},
+		{genai.APIError{Code: 403}, false},
+		{context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
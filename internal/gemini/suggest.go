@@ -0,0 +1,94 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// SuggestedTopic is a topic the AI suggests following, or one already followed when
+// passed in as an example
+type SuggestedTopic struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// topicsSchema is the response schema for structured topic suggestion output
+var topicsSchema = &genai.Schema{
+	Type: genai.TypeArray,
+	Items: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"name":        {Type: genai.TypeString},
+			"description": {Type: genai.TypeString},
+		},
+		PropertyOrdering: []string{"name", "description"},
+		Required:         []string{"name", "description"},
+	},
+}
+
+// SuggestTopics asks the model for count new topics related to the existing ones
+func (c *Client) SuggestTopics(ctx context.Context, existing []SuggestedTopic, count int) ([]SuggestedTopic, error) {
+	prompt := SuggestTopicsPrompt(existing, count)
+
+	responseText, structured, err := c.generateJSON(ctx, prompt, topicsSchema)
+	if err != nil {
+		return nil, err
+	}
+	if !structured {
+		var topics []SuggestedTopic
+		err := c.parseOrRepair(ctx, responseText, func(text string) error {
+			var err error
+			topics, err = ParseTopics(text)
+			return err
+		})
+		return topics, err
+	}
+	return ParseTopics(responseText)
+}
+
+// SuggestTopicsPrompt builds the topic suggestion prompt shared by all AI providers
+func SuggestTopicsPrompt(existing []SuggestedTopic, count int) string {
+	var existingBuilder strings.Builder
+	for _, topic := range existing {
+		existingBuilder.WriteString(fmt.Sprintf("- %s", topic.Name))
+		if topic.Description != "" {
+			existingBuilder.WriteString(": " + topic.Description)
+		}
+		existingBuilder.WriteString("\n")
+	}
+	if len(existing) == 0 {
+		existingBuilder.WriteString("(none yet)\n")
+	}
+
+	return fmt.Sprintf(`You are a helpful assistant that suggests news topics worth following.
+
+The user already follows these topics:
+%s
+Suggest %d new topics the user would likely also want news about. Base them on the interests the existing topics show, but don't repeat or merely rename any of them. If the user follows no topics yet, suggest popular, broadly interesting ones.
+
+For each topic, provide:
+1. A short name, a few words at most
+2. A one-sentence description of what news the topic covers
+
+IMPORTANT: Return ONLY a valid JSON array with no additional text, markdown, or explanation. The response must be parseable JSON.
+
+Format your response as a JSON array like this:
+[
+  {"name": "Electric Vehicles", "description": "New EV models, battery technology and charging infrastructure"}
+]`, existingBuilder.String(), count)
+}
+
+// ParseTopics parses a model response containing a JSON array of suggested topics
+func ParseTopics(responseText string) ([]SuggestedTopic, error) {
+	responseText = cleanJSONResponse(responseText)
+
+	var topics []SuggestedTopic
+	if err := unmarshalLenient(responseText, &topics); err != nil {
+		return nil, fmt.Errorf("failed to parse topics JSON: %w (response: %s)", err, responseText)
+	}
+
+	return topics, nil
+}
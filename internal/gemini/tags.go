@@ -0,0 +1,40 @@
+package gemini
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTags is the most tags kept per story
+	MaxTags = 3
+	// MaxTagLength is the most characters kept of each tag
+	MaxTagLength = 30
+)
+
+// NormalizeTag lowercases a tag, trims it and cuts it to MaxTagLength characters
+func NormalizeTag(tag string) string {
+	tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+	if utf8.RuneCountInString(tag) > MaxTagLength {
+		tag = strings.TrimSpace(string([]rune(tag)[:MaxTagLength]))
+	}
+	return tag
+}
+
+// NormalizeTags normalizes the tags the AI gave a story, dropping empty and repeated ones
+// and keeping at most MaxTags
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || slices.Contains(normalized, tag) {
+			continue
+		}
+		normalized = append(normalized, tag)
+		if len(normalized) == MaxTags {
+			break
+		}
+	}
+	return normalized
+}
@@ -1,18 +1,32 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"html/template"
-	"log"
+	"io"
+	"math"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/thinkscotty/maggpi_go/internal/database"
+	"github.com/thinkscotty/maggpi_go/internal/gemini"
+	"github.com/thinkscotty/maggpi_go/internal/llm"
+	"github.com/thinkscotty/maggpi_go/internal/logging"
 	"github.com/thinkscotty/maggpi_go/internal/models"
+	"github.com/thinkscotty/maggpi_go/internal/opml"
+	"github.com/thinkscotty/maggpi_go/internal/reddit"
 	"github.com/thinkscotty/maggpi_go/internal/scheduler"
 	"github.com/thinkscotty/maggpi_go/internal/scraper"
+	"golang.org/x/time/rate"
 )
 
 // Handlers contains all HTTP handlers
@@ -21,6 +35,9 @@ type Handlers struct {
 	scheduler   *scheduler.Scheduler
 	templates   map[string]*template.Template
 	templateDir string
+
+	// suggestLimiter spaces out topic suggestion requests, which each cost an AI call
+	suggestLimiter *rate.Limiter
 }
 
 // New creates a new Handlers instance
@@ -30,6 +47,8 @@ func New(db *database.DB, sched *scheduler.Scheduler, templatesDir string) (*Han
 		scheduler:   sched,
 		templates:   make(map[string]*template.Template),
 		templateDir: templatesDir,
+
+		suggestLimiter: rate.NewLimiter(rate.Every(topicSuggestInterval), topicSuggestBurst),
 	}
 
 	// Template functions
@@ -38,6 +57,16 @@ func New(db *database.DB, sched *scheduler.Scheduler, templatesDir string) (*Han
 			b, _ := json.Marshal(v)
 			return template.JS(b)
 		},
+		"nextRuns": func(expr string, n int) []time.Time {
+			times, _ := scheduler.NextRunTimes(expr, time.Now(), n)
+			return times
+		},
+		"host": func(link string) string {
+			if parsed, err := url.Parse(link); err == nil && parsed.Host != "" {
+				return strings.TrimPrefix(parsed.Hostname(), "www.")
+			}
+			return link
+		},
 	}
 
 	// Load each page template with base.html
@@ -58,19 +87,19 @@ func New(db *database.DB, sched *scheduler.Scheduler, templatesDir string) (*Han
 }
 
 // render renders a template with data
-func (h *Handlers) render(w http.ResponseWriter, tmpl string, data interface{}) {
+func (h *Handlers) render(w http.ResponseWriter, r *http.Request, tmpl string, data interface{}) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
 	t, ok := h.templates[tmpl]
 	if !ok {
-		log.Printf("Template not found: %s", tmpl)
+		logging.FromContext(r.Context()).Error("Template not found", "template", tmpl)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 
 	// Execute the "base" template which will include the page's "content" block
 	if err := t.ExecuteTemplate(w, "base", data); err != nil {
-		log.Printf("Template error: %v", err)
+		logging.FromContext(r.Context()).Error("Template error", "template", tmpl, "error", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
 }
@@ -96,13 +125,19 @@ func jsonError(w http.ResponseWriter, status int, message string) {
 func (h *Handlers) Dashboard(w http.ResponseWriter, r *http.Request) {
 	settings, err := h.db.GetSettings()
 	if err != nil {
-		log.Printf("Error getting settings: %v", err)
+		logging.FromContext(r.Context()).Error("Error getting settings", "error", err)
 		settings = &models.Settings{}
 	}
 
-	topics, err := h.db.GetTopicsWithStories(settings.StoriesPerTopic)
+	// An unknown ?order_by= just gets the default order on the page
+	order, err := storyOrder(r, settings)
 	if err != nil {
-		log.Printf("Error getting topics: %v", err)
+		order = database.StoryOrder{By: database.OrderByCreated, ByImportance: settings.RankByImportance}
+	}
+
+	topics, err := h.db.GetTopicsWithStories(settings.StoriesPerTopic, order)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error getting topics", "error", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
@@ -111,9 +146,10 @@ func (h *Handlers) Dashboard(w http.ResponseWriter, r *http.Request) {
 		"Title":    "Dashboard",
 		"Topics":   topics,
 		"Settings": settings,
+		"OrderBy":  order.By,
 	}
 
-	h.render(w, "dashboard.html", data)
+	h.render(w, r, "dashboard.html", data)
 }
 
 // ManageTopics renders the topic management page
@@ -121,7 +157,7 @@ func (h *Handlers) ManageTopics(w http.ResponseWriter, r *http.Request) {
 	settings, _ := h.db.GetSettings()
 	topics, err := h.db.GetTopicsWithSources()
 	if err != nil {
-		log.Printf("Error getting topics: %v", err)
+		logging.FromContext(r.Context()).Error("Error getting topics", "error", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
@@ -132,30 +168,36 @@ func (h *Handlers) ManageTopics(w http.ResponseWriter, r *http.Request) {
 		"Settings": settings,
 	}
 
-	h.render(w, "topics.html", data)
+	h.render(w, r, "topics.html", data)
 }
 
 // Settings renders the settings page
 func (h *Handlers) Settings(w http.ResponseWriter, r *http.Request) {
 	settings, err := h.db.GetSettings()
 	if err != nil {
-		log.Printf("Error getting settings: %v", err)
+		logging.FromContext(r.Context()).Error("Error getting settings", "error", err)
 		settings = &models.Settings{}
 	}
 
+	// The page only needs to show that keys are set; saving a masked key keeps the stored one
+	settings.GeminiAPIKey = maskKey(settings.GeminiAPIKey)
+	settings.OpenAIAPIKey = maskKey(settings.OpenAIAPIKey)
+
 	data := map[string]interface{}{
-		"Title":    "Settings",
-		"Settings": settings,
+		"Title":            "Settings",
+		"Settings":         settings,
+		"Providers":        llm.Providers(),
+		"SafetyThresholds": gemini.SafetyThresholds,
 	}
 
-	h.render(w, "settings.html", data)
+	h.render(w, r, "settings.html", data)
 }
 
 // API handlers for topics
 
-// GetTopics returns all topics as JSON
+// GetTopics returns all topics as JSON, each with its story and source counts and last refresh time
 func (h *Handlers) GetTopics(w http.ResponseWriter, r *http.Request) {
-	topics, err := h.db.GetTopics()
+	topics, err := h.db.GetTopicsWithMeta()
 	if err != nil {
 		jsonError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -165,32 +207,74 @@ func (h *Handlers) GetTopics(w http.ResponseWriter, r *http.Request) {
 
 // CreateTopic creates a new topic
 func (h *Handlers) CreateTopic(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
-	}
+	// Topics are refreshed and find their own sources automatically unless the request says otherwise
+	req := models.NewTopic("", "")
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		jsonError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
+	req.Name = database.NormalizeTopicName(req.Name)
 	if req.Name == "" {
 		jsonError(w, http.StatusBadRequest, "Topic name is required")
 		return
 	}
 
-	topic, err := h.db.CreateTopic(req.Name, req.Description)
+	req.CronSchedule = strings.TrimSpace(req.CronSchedule)
+	if req.CronSchedule != "" {
+		if err := scheduler.ValidateCronSchedule(req.CronSchedule); err != nil {
+			jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	req.SourcingPrompt = strings.TrimSpace(req.SourcingPrompt)
+	req.SummarizingPrompt = strings.TrimSpace(req.SummarizingPrompt)
+	language, err := gemini.ParseLanguage(req.StoryLanguage)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.StoryLanguage = language
+	length, err := gemini.ParseSummaryLength(req.SummaryLength)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.SummaryLength = length
+
+	if err := validateTopicCounts(&req); err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	topic, err := h.db.CreateTopic(&req)
+	if errors.Is(err, database.ErrTopicExists) {
+		jsonError(w, http.StatusConflict, fmt.Sprintf("A topic named %q already exists", req.Name))
+		return
+	}
 	if err != nil {
 		jsonError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	// Trigger source discovery in background (with panic recovery)
-	go h.scheduler.SafeDiscoverSources(topic.ID)
+	// Discover sources and run the first refresh in the background
+	h.scheduler.SetupTopicInBackground(topic.ID)
 
 	jsonResponse(w, http.StatusCreated, models.APIResponse{Success: true, Data: topic})
 }
 
+// validateTopicCounts checks a topic's optional per-topic counts, the same for creating
+// and updating a topic
+func validateTopicCounts(t *models.Topic) error {
+	if t.StoryRetentionCount != nil && (*t.StoryRetentionCount < 0 || *t.StoryRetentionCount > 10000) {
+		return errors.New("Stories to keep must be between 0 and 10000")
+	}
+	if t.DiscoverSourceCount != nil && (*t.DiscoverSourceCount < 1 || *t.DiscoverSourceCount > scheduler.MaxDiscoverSources) {
+		return fmt.Errorf("Sources to discover must be between 1 and %d", scheduler.MaxDiscoverSources)
+	}
+	return nil
+}
+
 // UpdateTopic updates an existing topic
 func (h *Handlers) UpdateTopic(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
@@ -206,25 +290,68 @@ func (h *Handlers) UpdateTopic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
-	}
+	// Start from the existing topic so omitted fields are left unchanged
+	req := *existingTopic
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		jsonError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	req.ID = id
+
+	req.Name = database.NormalizeTopicName(req.Name)
+	if req.Name == "" {
+		jsonError(w, http.StatusBadRequest, "Topic name is required")
+		return
+	}
+
+	req.CronSchedule = strings.TrimSpace(req.CronSchedule)
+	if req.CronSchedule != "" {
+		if err := scheduler.ValidateCronSchedule(req.CronSchedule); err != nil {
+			jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	req.SourcingPrompt = strings.TrimSpace(req.SourcingPrompt)
+	req.SummarizingPrompt = strings.TrimSpace(req.SummarizingPrompt)
+	language, err := gemini.ParseLanguage(req.StoryLanguage)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.StoryLanguage = language
+	length, err := gemini.ParseSummaryLength(req.SummaryLength)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.SummaryLength = length
+
+	if err := validateTopicCounts(&req); err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	descriptionChanged := existingTopic.Description != req.Description
+	scheduleChanged := existingTopic.CronSchedule != req.CronSchedule
 
-	if err := h.db.UpdateTopic(id, req.Name, req.Description); err != nil {
+	if err := h.db.UpdateTopic(&req); errors.Is(err, database.ErrTopicExists) {
+		jsonError(w, http.StatusConflict, fmt.Sprintf("A topic named %q already exists", req.Name))
+		return
+	} else if err != nil {
 		jsonError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	// If description changed, re-discover sources (with panic recovery)
-	if descriptionChanged {
-		go h.scheduler.SafeDiscoverSources(id)
+	// If description changed, re-discover sources in the background unless the user picks them
+	if descriptionChanged && req.AutoDiscover {
+		h.scheduler.DiscoverSourcesInBackground(id)
+	}
+
+	if scheduleChanged {
+		if err := h.scheduler.RescheduleTopic(id); err != nil {
+			logging.FromContext(r.Context()).Error("Error rescheduling topic", "topic_id", id, "error", err)
+		}
 	}
 
 	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true})
@@ -264,6 +391,41 @@ func (h *Handlers) ReorderTopics(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true})
 }
 
+// SetTopicEnabled enables or disables a topic's refreshes
+func (h *Handlers) SetTopicEnabled(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid topic ID")
+		return
+	}
+
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		jsonError(w, http.StatusBadRequest, `Request body must be {"enabled": true} or {"enabled": false}`)
+		return
+	}
+
+	topic, err := h.db.GetTopic(id)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if topic == nil {
+		jsonError(w, http.StatusNotFound, "Topic not found")
+		return
+	}
+
+	if err := h.scheduler.SetTopicEnabled(id, *req.Enabled); err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	topic.Enabled = *req.Enabled
+
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: topic})
+}
+
 // RefreshTopic manually triggers a topic refresh
 func (h *Handlers) RefreshTopic(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
@@ -272,173 +434,1416 @@ func (h *Handlers) RefreshTopic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Run refresh in background with panic recovery
-	go h.scheduler.SafeRefreshTopic(id)
+	topic, err := h.db.GetTopic(id)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if topic == nil {
+		jsonError(w, http.StatusNotFound, "Topic not found")
+		return
+	}
 
-	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: "Refresh started"})
-}
+	if !topic.Enabled {
+		jsonError(w, http.StatusConflict, "Topic is disabled; enable it to refresh")
+		return
+	}
 
-// API handlers for sources
+	// Queue the refresh; repeated clicks return the job already queued or running
+	job, added := h.scheduler.EnqueueRefresh(id)
 
-// AddSource adds a manual source to a topic
-func (h *Handlers) AddSource(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"job_id":             job.ID,
+			"status":             job.Status,
+			"already_refreshing": !added,
+		},
+	})
+}
+
+// GetTopicHistory returns a topic's recent refresh history, newest first
+func (h *Handlers) GetTopicHistory(w http.ResponseWriter, r *http.Request) {
 	topicID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
 		jsonError(w, http.StatusBadRequest, "Invalid topic ID")
 		return
 	}
 
-	var req struct {
-		URL  string `json:"url"`
-		Name string `json:"name"`
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		jsonError(w, http.StatusBadRequest, "Invalid request body")
+
+	history, err := h.db.GetRefreshHistory(topicID, limit)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if history == nil {
+		history = []models.RefreshHistory{}
+	}
 
-	if err := scraper.ValidateURL(req.URL); err != nil {
-		jsonError(w, http.StatusBadRequest, err.Error())
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: history})
+}
+
+// GetTopicArchive returns a page of a topic's archived stories
+func (h *Handlers) GetTopicArchive(w http.ResponseWriter, r *http.Request) {
+	topicID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid topic ID")
 		return
 	}
 
-	source, err := h.db.AddSource(topicID, req.URL, req.Name, true)
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 200 {
+			limit = parsed
+		}
+	}
+	offset := 0
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	stories, err := h.db.GetArchivedStories(topicID, limit, offset)
 	if err != nil {
 		jsonError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if stories == nil {
+		stories = []models.ArchivedStory{}
+	}
 
-	jsonResponse(w, http.StatusCreated, models.APIResponse{Success: true, Data: source})
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: stories})
 }
 
-// DeleteSource removes a source
-func (h *Handlers) DeleteSource(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.ParseInt(chi.URLParam(r, "sourceId"), 10, 64)
+// GetTopicTags lists the tags on a topic's stories with how often each is used, for
+// building tag filters
+func (h *Handlers) GetTopicTags(w http.ResponseWriter, r *http.Request) {
+	topicID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		jsonError(w, http.StatusBadRequest, "Invalid source ID")
+		jsonError(w, http.StatusBadRequest, "Invalid topic ID")
+		return
+	}
+	if topic, err := h.db.GetTopic(topicID); err != nil || topic == nil {
+		jsonError(w, http.StatusNotFound, "Topic not found")
 		return
 	}
 
-	if err := h.db.DeleteSource(id); err != nil {
+	tags, err := h.db.GetTopicTagCounts(topicID)
+	if err != nil {
 		jsonError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true})
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: tags})
 }
 
-// API handlers for settings
+// previewTimeout bounds a dry-run refresh, the one request that scrapes and summarizes synchronously
+const previewTimeout = 3 * time.Minute
 
-// GetSettings returns current settings
-func (h *Handlers) GetSettings(w http.ResponseWriter, r *http.Request) {
-	settings, err := h.db.GetSettings()
+// PreviewRefresh runs a topic's refresh pipeline without storing anything and returns
+// the stories it would produce, per-source scrape sizes, and timings. With ?stream=true
+// the response is a Server-Sent Events stream instead: "summary" events carry the AI's
+// response text as it arrives, and a final "result" event carries the usual JSON body.
+func (h *Handlers) PreviewRefresh(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		jsonError(w, http.StatusBadRequest, "Invalid topic ID")
 		return
 	}
-
-	// Don't expose the full API key
-	if settings.GeminiAPIKey != "" {
-		settings.GeminiAPIKey = "********" + settings.GeminiAPIKey[len(settings.GeminiAPIKey)-4:]
+	if topic, err := h.db.GetTopic(id); err != nil || topic == nil {
+		jsonError(w, http.StatusNotFound, "Topic not found")
+		return
 	}
 
-	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: settings})
-}
+	// The run takes longer than the server's write timeout allows
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(previewTimeout + 10*time.Second))
+	ctx, cancel := context.WithTimeout(r.Context(), previewTimeout)
+	defer cancel()
 
-// UpdateSettings updates application settings
-func (h *Handlers) UpdateSettings(w http.ResponseWriter, r *http.Request) {
-	var req models.Settings
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		jsonError(w, http.StatusBadRequest, "Invalid request body")
+	if r.URL.Query().Get("stream") == "true" {
+		h.streamPreview(ctx, w, id)
 		return
 	}
 
-	// Get current settings to preserve API key if not changed
-	current, _ := h.db.GetSettings()
-	if current != nil && (req.GeminiAPIKey == "" || req.GeminiAPIKey[:8] == "********") {
-		req.GeminiAPIKey = current.GeminiAPIKey
+	preview, err := h.scheduler.PreviewRefresh(ctx, id, nil)
+	if err != nil {
+		// Return partial results (e.g. which sources failed) along with the error
+		jsonResponse(w, http.StatusUnprocessableEntity, models.APIResponse{Success: false, Data: preview, Error: err.Error()})
+		return
 	}
 
-	req.ID = 1
-	if err := h.db.UpdateSettings(&req); err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: preview})
+}
+
+// PreviewDiscovery returns the sources AI discovery would suggest for a topic, each
+// checked for reachability, without adding any. Chosen ones can then be added as
+// manual sources.
+func (h *Handlers) PreviewDiscovery(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid topic ID")
+		return
+	}
+	if topic, err := h.db.GetTopic(id); err != nil || topic == nil {
+		jsonError(w, http.StatusNotFound, "Topic not found")
 		return
 	}
 
-	// Update scheduler interval
-	h.scheduler.UpdateInterval(req.RefreshIntervalMinutes)
+	// The AI call and URL checks can take longer than the server's write timeout allows
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(previewTimeout + 10*time.Second))
+	ctx, cancel := context.WithTimeout(r.Context(), previewTimeout)
+	defer cancel()
 
-	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true})
+	candidates, err := h.scheduler.PreviewDiscovery(ctx, id)
+	if err != nil {
+		jsonError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: candidates})
 }
 
-// External API for client devices
+// Topic suggestions are limited to a short burst and then one per topicSuggestInterval,
+// so repeated clicks can't spend the AI quota
+const (
+	topicSuggestInterval = time.Minute
+	topicSuggestBurst    = 3
+)
 
-// APIGetAllStories returns all topics with stories for external clients
-func (h *Handlers) APIGetAllStories(w http.ResponseWriter, r *http.Request) {
-	settings, _ := h.db.GetSettings()
-	storiesPerTopic := 5
-	if settings != nil {
-		storiesPerTopic = settings.StoriesPerTopic
+// SuggestTopics asks the AI for new topics based on the existing ones, for the topics
+// page to offer as one-click adds. Requests over the limit get 429 with a Retry-After header.
+func (h *Handlers) SuggestTopics(w http.ResponseWriter, r *http.Request) {
+	reservation := h.suggestLimiter.Reserve()
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+		jsonError(w, http.StatusTooManyRequests, "Topics were suggested recently, please wait a minute before asking again")
+		return
 	}
 
-	topics, err := h.db.GetTopicsWithStories(storiesPerTopic)
+	// The AI call can take longer than the server's write timeout allows
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(previewTimeout + 10*time.Second))
+	ctx, cancel := context.WithTimeout(r.Context(), previewTimeout)
+	defer cancel()
+
+	suggestions, err := h.scheduler.SuggestTopics(ctx)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		jsonError(w, http.StatusUnprocessableEntity, err.Error())
 		return
 	}
 
-	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: topics})
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: suggestions})
 }
 
-// APIGetTopicStories returns stories for a specific topic
-func (h *Handlers) APIGetTopicStories(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
-	if err != nil {
-		jsonError(w, http.StatusBadRequest, "Invalid topic ID")
+// sourcePreviewTimeout bounds scraping a URL for a source preview
+const sourcePreviewTimeout = 45 * time.Second
+
+// PreviewSource scrapes a URL as a refresh would and returns the extracted title and the
+// start of the content, so a source can be checked before it's added. A failed scrape
+// still returns the preview, with the error, as a 422.
+func (h *Handlers) PreviewSource(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	settings, _ := h.db.GetSettings()
-	limit := 5
-	if settings != nil {
-		limit = settings.StoriesPerTopic
+	req.URL = scraper.NormalizeURL(req.URL)
+	if err := scraper.ValidateURL(req.URL); err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
 	}
-
-	// Check for limit query param
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
-			limit = parsed
+	if reddit.IsRedditURL(req.URL) {
+		if err := reddit.ValidateListing(reddit.ListingOptions(req.URL)); err != nil {
+			jsonError(w, http.StatusBadRequest, err.Error())
+			return
 		}
 	}
 
-	topic, err := h.db.GetTopic(id)
-	if err != nil || topic == nil {
-		jsonError(w, http.StatusNotFound, "Topic not found")
-		return
-	}
+	ctx, cancel := context.WithTimeout(r.Context(), sourcePreviewTimeout)
+	defer cancel()
 
-	stories, err := h.db.GetStoriesForTopic(id, limit)
+	preview, err := h.scheduler.PreviewSource(ctx, req.URL)
 	if err != nil {
 		jsonError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if preview.Error != "" {
+		jsonResponse(w, http.StatusUnprocessableEntity, models.APIResponse{Success: false, Data: preview, Error: preview.Error})
+		return
+	}
 
-	jsonResponse(w, http.StatusOK, models.APIResponse{
-		Success: true,
-		Data: models.TopicWithStories{
-			Topic:   *topic,
-			Stories: stories,
-		},
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: preview})
+}
+
+// streamPreview runs a dry-run refresh, streaming the summary as Server-Sent Events
+func (h *Handlers) streamPreview(ctx context.Context, w http.ResponseWriter, topicID int64) {
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	writeEvent := func(event string, v any) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error encoding preview event", "event", event, "error", err)
+			return
+		}
+		// Write errors mean the client went away; the run stops when ctx is cancelled
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		rc.Flush()
+	}
+
+	// Scraping sends nothing, so start the stream now rather than leave the client waiting on headers
+	fmt.Fprint(w, ": preview started\n\n")
+	rc.Flush()
+
+	preview, err := h.scheduler.PreviewRefresh(ctx, topicID, func(received int, chunk string) {
+		writeEvent("summary", models.PreviewChunk{Received: received, Text: chunk})
 	})
+	if err != nil {
+		writeEvent("result", models.APIResponse{Success: false, Data: preview, Error: err.Error()})
+		return
+	}
+	writeEvent("result", models.APIResponse{Success: true, Data: preview})
 }
 
-// APIGetRefreshStatus returns refresh status for all topics
-func (h *Handlers) APIGetRefreshStatus(w http.ResponseWriter, r *http.Request) {
-	statuses, err := h.db.GetAllRefreshStatuses()
+// TranslateStory rewrites a story's title and summary in another language
+func (h *Handlers) TranslateStory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		jsonError(w, http.StatusBadRequest, "Invalid story ID")
 		return
 	}
 
-	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: statuses})
+	var req struct {
+		Language string `json:"language"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	language, err := gemini.ParseLanguage(req.Language)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if language == "" {
+		jsonError(w, http.StatusBadRequest, "Language is required")
+		return
+	}
+
+	if story, err := h.db.GetStory(id); err != nil || story == nil {
+		jsonError(w, http.StatusNotFound, "Story not found")
+		return
+	}
+
+	// The AI call can take longer than the server's write timeout allows
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(previewTimeout + 10*time.Second))
+	ctx, cancel := context.WithTimeout(r.Context(), previewTimeout)
+	defer cancel()
+
+	story, err := h.scheduler.TranslateStory(ctx, id, language)
+	if err != nil {
+		jsonError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: story})
+}
+
+// RunMaintenance checkpoints and vacuums the database
+func (h *Handlers) RunMaintenance(w http.ResponseWriter, r *http.Request) {
+	// Maintenance waits for running refreshes, which can outlast the server's write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	if err := h.scheduler.RunMaintenance(); err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true})
+}
+
+// GetJobs lists running, queued, and recently finished refresh jobs
+func (h *Handlers) GetJobs(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: h.scheduler.Jobs()})
+}
+
+// GetSchedulerStats returns the scheduler's refresh counters since startup
+func (h *Handlers) GetSchedulerStats(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: h.scheduler.Stats()})
+}
+
+// GetUsage returns today's AI API usage, the remaining daily budget, and daily totals
+// overall and per topic, for the last ?days= days (default 30, at most 365)
+func (h *Handlers) GetUsage(w http.ResponseWriter, r *http.Request) {
+	days := scheduler.DefaultUsageDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 && parsed <= 365 {
+			days = parsed
+		}
+	}
+
+	usage, err := h.scheduler.Usage(days)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: usage})
+}
+
+// GetGeminiModels returns the Gemini model names known to work, for the settings dropdown
+func (h *Handlers) GetGeminiModels(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: gemini.KnownModels})
+}
+
+// RefreshAllTopics queues a refresh job for every topic
+func (h *Handlers) RefreshAllTopics(w http.ResponseWriter, r *http.Request) {
+	count, err := h.scheduler.RefreshAll()
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data:    map[string]int{"queued": count},
+	})
+}
+
+// maxOPMLSize caps the size of an uploaded OPML document
+const maxOPMLSize = 5 << 20
+
+// ImportOPML creates a topic for each folder in an uploaded OPML document and adds the
+// folder's feeds to it as manual sources. The document may be sent as the request body or
+// as the "file" field of a multipart form. Feeds for a folder whose name is already taken
+// go to the existing topic.
+func (h *Handlers) ImportOPML(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxOPMLSize)
+
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Missing OPML file")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	doc, err := opml.Parse(body)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result := models.OPMLImportResult{Topics: []models.ImportedTopic{}, Skipped: []models.SkippedFeed{}}
+	for _, feed := range doc.Loose {
+		result.Skipped = append(result.Skipped, models.SkippedFeed{Title: feed.Title, URL: feed.URL, Reason: "not in a folder"})
+	}
+
+	created := 0
+	for _, folder := range doc.Folders {
+		imported, skipped, err := h.importFolder(folder)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		result.Skipped = append(result.Skipped, skipped...)
+		if imported == nil {
+			continue
+		}
+		result.Topics = append(result.Topics, *imported)
+		if imported.Created {
+			created++
+			// The feeds are the topic's sources, so it goes straight to its first refresh
+			h.scheduler.EnqueueRefresh(imported.ID)
+		}
+	}
+
+	logging.FromContext(r.Context()).Info("Imported OPML", "topics_created", created, "topics", len(result.Topics), "skipped", len(result.Skipped))
+
+	status := http.StatusOK
+	if created > 0 {
+		status = http.StatusCreated
+	}
+	jsonResponse(w, status, models.APIResponse{Success: true, Data: result})
+}
+
+// importFolder adds an OPML folder's feeds to the topic of the same name, creating it if
+// needed. It returns nil for the topic if none of the feeds could be added.
+func (h *Handlers) importFolder(folder opml.Folder) (*models.ImportedTopic, []models.SkippedFeed, error) {
+	name := database.NormalizeTopicName(folder.Title)
+	var skipped []models.SkippedFeed
+	skip := func(feed opml.Feed, reason string) {
+		skipped = append(skipped, models.SkippedFeed{Folder: folder.Title, Title: feed.Title, URL: feed.URL, Reason: reason})
+	}
+
+	var feeds []opml.Feed
+	for _, feed := range folder.Feeds {
+		feed.URL = scraper.NormalizeURL(feed.URL)
+		if name == "" {
+			skip(feed, "folder has no name")
+			continue
+		}
+		err := scraper.ValidateURL(feed.URL)
+		if err == nil && reddit.IsRedditURL(feed.URL) {
+			err = reddit.ValidateListing(reddit.ListingOptions(feed.URL))
+		}
+		if err != nil {
+			skip(feed, err.Error())
+			continue
+		}
+		feeds = append(feeds, feed)
+	}
+	if len(feeds) == 0 {
+		return nil, skipped, nil
+	}
+
+	imported := &models.ImportedTopic{Name: name, Created: true}
+	newTopic := models.NewTopic(name, "")
+	topic, err := h.db.CreateTopic(&newTopic)
+	if errors.Is(err, database.ErrTopicExists) {
+		imported.Created = false
+		topic, err = h.findTopic(name)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create topic %q: %w", name, err)
+	}
+	imported.ID = topic.ID
+	imported.Name = topic.Name
+
+	existing, err := h.db.GetSourcesForTopic(topic.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, feed := range feeds {
+		if slices.ContainsFunc(existing, func(s models.Source) bool { return scraper.SameSource(s.URL, feed.URL) }) {
+			skip(feed, "already a source of this topic")
+			continue
+		}
+		source, err := h.db.AddSource(topic.ID, feed.URL, feed.Title, true)
+		if errors.Is(err, database.ErrSourceExists) {
+			skip(feed, "already a source of this topic")
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to add source %s: %w", feed.URL, err)
+		}
+		existing = append(existing, *source)
+		imported.SourcesAdded++
+	}
+	return imported, skipped, nil
+}
+
+// findTopic returns the topic with the given name, ignoring case
+func (h *Handlers) findTopic(name string) (*models.Topic, error) {
+	topics, err := h.db.GetTopics()
+	if err != nil {
+		return nil, err
+	}
+	for i := range topics {
+		if strings.EqualFold(topics[i].Name, name) {
+			return &topics[i], nil
+		}
+	}
+	return nil, fmt.Errorf("topic %q not found", name)
+}
+
+// API handlers for sources
+
+// GetTopicSources returns a topic's sources with their scrape statistics
+func (h *Handlers) GetTopicSources(w http.ResponseWriter, r *http.Request) {
+	topicID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid topic ID")
+		return
+	}
+
+	topic, err := h.db.GetTopic(topicID)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if topic == nil {
+		jsonError(w, http.StatusNotFound, "Topic not found")
+		return
+	}
+
+	sources, err := h.db.GetSourcesForTopic(topicID)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if sources == nil {
+		sources = []models.Source{}
+	}
+
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: sources})
+}
+
+// sourceProbeTimeout bounds the reachability check made when a source is added by hand
+const sourceProbeTimeout = 5 * time.Second
+
+// AddSource adds a manual source to a topic, first checking that the URL answers. A URL
+// that doesn't is added anyway, with a warning in the response.
+func (h *Handlers) AddSource(w http.ResponseWriter, r *http.Request) {
+	topicID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid topic ID")
+		return
+	}
+
+	var req struct {
+		URL  string `json:"url"`
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.URL = scraper.NormalizeURL(req.URL)
+	if err := scraper.ValidateURL(req.URL); err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if reddit.IsRedditURL(req.URL) {
+		if err := reddit.ValidateListing(reddit.ListingOptions(req.URL)); err != nil {
+			jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	existing, err := h.db.GetSourcesForTopic(topicID)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, source := range existing {
+		if scraper.SameSource(source.URL, req.URL) {
+			jsonError(w, http.StatusConflict, "This topic already has that source")
+			return
+		}
+	}
+
+	// A URL that doesn't answer is still added, since the site may only be down for now,
+	// but the response warns about it. ?validate=false skips the check for offline setups.
+	var warning string
+	if r.URL.Query().Get("validate") != "false" {
+		ctx, cancel := context.WithTimeout(r.Context(), sourceProbeTimeout)
+		err := h.scheduler.CheckSourceURL(ctx, req.URL)
+		cancel()
+		if err != nil {
+			warning = fmt.Sprintf("Source added, but it may not work: %v", err)
+		}
+	}
+
+	source, err := h.db.AddSource(topicID, req.URL, req.Name, true)
+	if errors.Is(err, database.ErrSourceExists) {
+		jsonError(w, http.StatusConflict, "This topic already has that source")
+		return
+	}
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusCreated, models.APIResponse{Success: true, Data: source, Warning: warning})
+}
+
+const (
+	// maxBulkSourcesSize caps the size of a bulk source request body
+	maxBulkSourcesSize = 1 << 20
+	// maxBulkSources caps how many URLs one bulk source request may add
+	maxBulkSources = 500
+)
+
+// AddSourcesBulk adds many manual sources to a topic at once. The body is either JSON,
+// {"urls": [...]}, or plain text with one URL per line; blank lines and lines starting
+// with # are ignored. Each URL is reported as added, invalid or duplicate, in order.
+func (h *Handlers) AddSourcesBulk(w http.ResponseWriter, r *http.Request) {
+	topicID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid topic ID")
+		return
+	}
+	if topic, err := h.db.GetTopic(topicID); err != nil || topic == nil {
+		jsonError(w, http.StatusNotFound, "Topic not found")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkSourcesSize)
+	var urls []string
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var req struct {
+			URLs []string `json:"urls"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		urls = req.URLs
+	} else {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		for _, line := range strings.Split(string(body), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				urls = append(urls, line)
+			}
+		}
+	}
+	if len(urls) == 0 {
+		jsonError(w, http.StatusBadRequest, "No URLs given")
+		return
+	}
+	if len(urls) > maxBulkSources {
+		jsonError(w, http.StatusBadRequest, fmt.Sprintf("At most %d URLs can be added at once", maxBulkSources))
+		return
+	}
+
+	existing, err := h.db.GetSourcesForTopic(topicID)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	results := make([]models.BulkSourceResult, 0, len(urls))
+	added := 0
+	for _, raw := range urls {
+		result := models.BulkSourceResult{URL: raw}
+		sourceURL := scraper.NormalizeURL(raw)
+		err := scraper.ValidateURL(sourceURL)
+		if err == nil && reddit.IsRedditURL(sourceURL) {
+			err = reddit.ValidateListing(reddit.ListingOptions(sourceURL))
+		}
+		switch {
+		case err != nil:
+			result.Status = "invalid"
+			result.Error = err.Error()
+		case slices.ContainsFunc(existing, func(s models.Source) bool { return scraper.SameSource(s.URL, sourceURL) }):
+			result.Status = "duplicate"
+		default:
+			source, err := h.db.AddSource(topicID, sourceURL, sourceName(sourceURL), true)
+			if errors.Is(err, database.ErrSourceExists) {
+				result.Status = "duplicate"
+				break
+			}
+			if err != nil {
+				jsonError(w, http.StatusInternalServerError, fmt.Sprintf("failed to add source %s: %v", sourceURL, err))
+				return
+			}
+			existing = append(existing, *source)
+			result.Status = "added"
+			result.SourceID = source.ID
+			added++
+		}
+		results = append(results, result)
+	}
+
+	logging.FromContext(r.Context()).Info("Added sources in bulk", "topic_id", topicID, "urls", len(urls), "added", added)
+
+	status := http.StatusOK
+	if added > 0 {
+		status = http.StatusCreated
+	}
+	jsonResponse(w, status, models.APIResponse{Success: true, Data: results})
+}
+
+// sourceName names a source added without one after its URL: "r/name" for a subreddit,
+// otherwise the host without "www."
+func sourceName(sourceURL string) string {
+	u, err := url.Parse(sourceURL)
+	if err != nil || u.Host == "" {
+		return sourceURL
+	}
+	if reddit.IsRedditURL(sourceURL) {
+		if parts := strings.Split(strings.Trim(u.Path, "/"), "/"); len(parts) >= 2 && parts[0] == "r" {
+			return "r/" + parts[1]
+		}
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// DeleteSource removes a source
+func (h *Handlers) DeleteSource(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "sourceId"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid source ID")
+		return
+	}
+
+	if err := h.db.DeleteSource(id); err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true})
+}
+
+// ReactivateSource resets a source's failure tracking so the next refresh retries it
+func (h *Handlers) ReactivateSource(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "sourceId"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid source ID")
+		return
+	}
+
+	source, err := h.db.GetSource(id)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if source == nil {
+		jsonError(w, http.StatusNotFound, "Source not found")
+		return
+	}
+
+	if err := h.db.UpdateSourceStatus(id, true, 0, ""); err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true})
+}
+
+// ReactivateAllSources resets failure tracking for every disabled or failing source of a topic
+func (h *Handlers) ReactivateAllSources(w http.ResponseWriter, r *http.Request) {
+	topicID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid topic ID")
+		return
+	}
+
+	sources, err := h.db.GetSourcesForTopic(topicID)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	reactivated := 0
+	for _, source := range sources {
+		if source.IsActive && source.FailureCount == 0 {
+			continue
+		}
+		if err := h.db.UpdateSourceStatus(source.ID, true, 0, ""); err != nil {
+			jsonError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		reactivated++
+	}
+
+	jsonResponse(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]int{"reactivated": reactivated},
+	})
+}
+
+// API handlers for settings
+
+// GetSettings returns current settings
+func (h *Handlers) GetSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.db.GetSettings()
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Don't expose the full API keys
+	settings.GeminiAPIKey = maskKey(settings.GeminiAPIKey)
+	settings.OpenAIAPIKey = maskKey(settings.OpenAIAPIKey)
+
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: settings})
+}
+
+// keyMask stands in for the hidden part of an API key. It is always the same width, so a
+// masked key doesn't give away the length of the real one.
+const keyMask = "********"
+
+// minRevealedKeyLength is the shortest key whose last 4 characters are shown when masked;
+// shorter keys are hidden entirely so most of the key is never revealed
+const minRevealedKeyLength = 12
+
+// maskKey hides all but the last 4 characters of an API key behind keyMask
+func maskKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	if len(key) < minRevealedKeyLength {
+		return keyMask
+	}
+	return keyMask + key[len(key)-4:]
+}
+
+// keepMaskedKey returns the stored key if the submitted one is empty or still masked
+func keepMaskedKey(submitted, stored string) string {
+	if submitted == "" || strings.HasPrefix(submitted, keyMask) {
+		return stored
+	}
+	return submitted
+}
+
+// keyTestTimeout bounds the request made to test an API key
+const keyTestTimeout = 15 * time.Second
+
+// TestGeminiKey checks a Gemini API key with a minimal request to the given model, or the
+// saved model if none is given. A masked or empty key tests the saved key. A failed
+// test returns 422 with the error and a reason telling a bad key from a spent quota.
+func (h *Handlers) TestGeminiKey(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		APIKey string `json:"api_key"`
+		Model  string `json:"model"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	settings, err := h.db.GetSettings()
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	apiKey := keepMaskedKey(strings.TrimSpace(req.APIKey), settings.GeminiAPIKey)
+	if apiKey == "" {
+		jsonError(w, http.StatusBadRequest, "No Gemini API key to test")
+		return
+	}
+	model := strings.TrimSpace(req.Model)
+	if model == "" {
+		model = settings.GeminiModel
+	}
+
+	client, err := gemini.New(apiKey, model)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), keyTestTimeout)
+	defer cancel()
+
+	result := models.KeyTest{Model: client.Model()}
+	if err := client.CheckKey(ctx); err != nil {
+		result.Reason = gemini.FailureReason(err)
+		jsonResponse(w, http.StatusUnprocessableEntity, models.APIResponse{Success: false, Data: result, Error: err.Error()})
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: result})
+}
+
+// UpdateSettings updates application settings
+func (h *Handlers) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	// Start from the current settings so fields missing from the request keep their values
+	current, _ := h.db.GetSettings()
+	var req models.Settings
+	if current != nil {
+		req = *current
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Preserve API keys if not changed
+	if current != nil {
+		req.GeminiAPIKey = keepMaskedKey(req.GeminiAPIKey, current.GeminiAPIKey)
+		req.OpenAIAPIKey = keepMaskedKey(req.OpenAIAPIKey, current.OpenAIAPIKey)
+	}
+
+	if req.Provider == "" {
+		req.Provider = llm.DefaultProvider
+	}
+	if err := llm.Validate(&req); err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	req.GeminiModel = strings.TrimSpace(req.GeminiModel)
+	if req.GeminiModel == "" {
+		jsonError(w, http.StatusBadRequest, "Gemini model can't be empty")
+		return
+	}
+
+	// Quiet hours must be both set as "HH:MM" or both left empty
+	if (req.QuietHoursStart == "") != (req.QuietHoursEnd == "") {
+		jsonError(w, http.StatusBadRequest, "Quiet hours need both a start and an end time")
+		return
+	}
+	for _, t := range []string{req.QuietHoursStart, req.QuietHoursEnd} {
+		if t == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", t); err != nil {
+			jsonError(w, http.StatusBadRequest, "Quiet hours must use HH:MM format")
+			return
+		}
+	}
+
+	if req.StoriesPerTopic < 1 || req.StoriesPerTopic > 20 {
+		jsonError(w, http.StatusBadRequest, "Stories per topic must be between 1 and 20")
+		return
+	}
+	if req.MaxConcurrentRefreshes < 1 || req.MaxConcurrentRefreshes > 8 {
+		jsonError(w, http.StatusBadRequest, "Parallel refreshes must be between 1 and 8")
+		return
+	}
+	if req.RefreshStaggerSeconds < 0 || req.RefreshStaggerSeconds > 600 {
+		jsonError(w, http.StatusBadRequest, "Delay between refreshes must be between 0 and 600 seconds")
+		return
+	}
+
+	// Clamp scrape parallelism to a sane range rather than rejecting the save
+	if req.ScrapeParallelism < 1 {
+		req.ScrapeParallelism = 1
+	} else if req.ScrapeParallelism > 16 {
+		req.ScrapeParallelism = 16
+	}
+	if req.RedditMinWords < 0 || req.RedditMinWords > 1000 {
+		jsonError(w, http.StatusBadRequest, "Reddit minimum words must be between 0 and 1000")
+		return
+	}
+	if req.RedditMinScore < 0 || req.RedditMinScore > 100000 {
+		jsonError(w, http.StatusBadRequest, "Reddit minimum score must be between 0 and 100000")
+		return
+	}
+	if req.RedditMaxAgeHours < 0 || req.RedditMaxAgeHours > 8760 {
+		jsonError(w, http.StatusBadRequest, "Reddit maximum post age must be between 0 and 8760 hours")
+		return
+	}
+
+	if req.StoryRetentionCount < 0 || req.StoryRetentionCount > 1000 {
+		jsonError(w, http.StatusBadRequest, "Stories to keep must be between 0 and 1000")
+		return
+	}
+	if req.StoryRetentionCount > 0 && req.StoryRetentionCount < req.StoriesPerTopic {
+		jsonError(w, http.StatusBadRequest, "Stories to keep can't be fewer than stories per topic")
+		return
+	}
+	if req.StoryRetentionDays < 0 || req.StoryRetentionDays > 3650 {
+		jsonError(w, http.StatusBadRequest, "Story retention days must be between 0 and 3650")
+		return
+	}
+	if req.SummaryMinWords < 5 || req.SummaryMaxWords > 1000 || req.SummaryMinWords >= req.SummaryMaxWords {
+		jsonError(w, http.StatusBadRequest, "Summary length must be between 5 and 1000 words, with the minimum below the maximum")
+		return
+	}
+	if req.MinSources < 1 || req.MaxSources > scheduler.MaxDiscoverSources || req.MinSources > req.MaxSources {
+		jsonError(w, http.StatusBadRequest, fmt.Sprintf("Sources to discover must be between 1 and %d, with the minimum no more than the maximum", scheduler.MaxDiscoverSources))
+		return
+	}
+	if req.MaxSourceChars < 1000 || req.MaxSourceChars > 100000 {
+		jsonError(w, http.StatusBadRequest, "Content kept per source must be between 1000 and 100000 characters")
+		return
+	}
+	if req.MaxPromptChars != 0 && (req.MaxPromptChars < req.MaxSourceChars || req.MaxPromptChars > 1000000) {
+		jsonError(w, http.StatusBadRequest, "Content per AI request must be 0 or between the per-source limit and 1000000 characters")
+		return
+	}
+	if req.ChunkThresholdChars != 0 && (req.ChunkThresholdChars < req.MaxSourceChars || req.ChunkThresholdChars > 1000000) {
+		jsonError(w, http.StatusBadRequest, "Batch summarizing threshold must be 0 or between the per-source limit and 1000000 characters")
+		return
+	}
+	language, err := gemini.ParseLanguage(req.StoryLanguage)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.StoryLanguage = language
+	length, err := gemini.ParseSummaryLength(req.SummaryLength)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if length == "" {
+		length = gemini.SummaryLengthStandard
+	}
+	req.SummaryLength = length
+	if req.SemanticDedupThreshold < 0.5 || req.SemanticDedupThreshold > 0.99 {
+		jsonError(w, http.StatusBadRequest, "Duplicate similarity threshold must be between 0.5 and 0.99")
+		return
+	}
+	if req.SemanticDedup && req.GeminiAPIKey == "" {
+		jsonError(w, http.StatusBadRequest, "Duplicate detection by meaning needs a Gemini API key, whichever AI provider is selected")
+		return
+	}
+	if req.ArchiveRetentionDays < 0 || req.ArchiveRetentionDays > 3650 {
+		jsonError(w, http.StatusBadRequest, "Archive retention days must be between 0 and 3650")
+		return
+	}
+
+	if req.GeminiTimeoutSeconds < 10 || req.GeminiTimeoutSeconds > 600 {
+		jsonError(w, http.StatusBadRequest, "AI request timeout must be between 10 and 600 seconds")
+		return
+	}
+	if req.OllamaTimeoutSeconds < 10 || req.OllamaTimeoutSeconds > 3600 {
+		jsonError(w, http.StatusBadRequest, "Ollama request timeout must be between 10 and 3600 seconds")
+		return
+	}
+
+	if req.GeminiTemperature < 0 || req.GeminiTemperature > 2 {
+		jsonError(w, http.StatusBadRequest, "Temperature must be between 0 and 2")
+		return
+	}
+	if req.GeminiTopP <= 0 || req.GeminiTopP > 1 {
+		jsonError(w, http.StatusBadRequest, "Top P must be above 0 and at most 1")
+		return
+	}
+	if req.GeminiMaxOutputTokens != 0 && (req.GeminiMaxOutputTokens < 256 || req.GeminiMaxOutputTokens > 65536) {
+		jsonError(w, http.StatusBadRequest, "Max output tokens must be 0 or between 256 and 65536")
+		return
+	}
+	if req.GeminiSafetyThreshold != "" && !slices.Contains(gemini.SafetyThresholds, req.GeminiSafetyThreshold) {
+		jsonError(w, http.StatusBadRequest, "Safety threshold must be empty or one of "+strings.Join(gemini.SafetyThresholds, ", "))
+		return
+	}
+
+	if req.PromptTokenPrice < 0 || req.OutputTokenPrice < 0 {
+		jsonError(w, http.StatusBadRequest, "Token prices can't be negative")
+		return
+	}
+
+	if req.DailyRequestBudget < 0 || req.DailyTokenBudget < 0 {
+		jsonError(w, http.StatusBadRequest, "Daily budgets can't be negative")
+		return
+	}
+
+	if _, err := scraper.ParseBoilerplatePatterns(req.BoilerplatePatterns); err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	req.WebhookURL = strings.TrimSpace(req.WebhookURL)
+	if req.WebhookURL != "" {
+		u, err := url.Parse(req.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			jsonError(w, http.StatusBadRequest, "Webhook URL must be an http or https URL")
+			return
+		}
+	}
+
+	req.ID = 1
+	if err := h.db.UpdateSettings(&req); err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Update scheduler interval and concurrency
+	h.scheduler.UpdateInterval(req.RefreshIntervalMinutes)
+	h.scheduler.UpdateConcurrency(req.MaxConcurrentRefreshes, req.RefreshStaggerSeconds)
+
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true})
+}
+
+// External API for client devices
+
+// APIGetAllStories returns all topics with stories for external clients, optionally
+// only stories created between ?since= and ?until=
+func (h *Handlers) APIGetAllStories(w http.ResponseWriter, r *http.Request) {
+	since, until, filtered, err := parseDateRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	settings, _ := h.db.GetSettings()
+	storiesPerTopic := 5
+	if settings != nil {
+		storiesPerTopic = settings.StoriesPerTopic
+	}
+	order, err := storyOrder(r, settings)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var topics []models.TopicWithStories
+	if filtered {
+		topics, err = h.db.GetTopicsWithStoriesBetween(since, until, storiesPerTopic, order)
+	} else {
+		topics, err = h.db.GetTopicsWithStories(storiesPerTopic, order)
+	}
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: topics})
+}
+
+// APIGetTopicStories returns stories for a specific topic, optionally only stories
+// created between ?since= and ?until=
+func (h *Handlers) APIGetTopicStories(w http.ResponseWriter, r *http.Request) {
+	topic, stories, ok := h.topicStories(w, r)
+	if !ok {
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: models.TopicWithStories{
+			Topic:   *topic,
+			Stories: stories,
+		},
+	})
+}
+
+// APIGetTopicFeed returns a topic's stories as a JSON Feed 1.1 document, taking the same
+// query parameters as APIGetTopicStories
+func (h *Handlers) APIGetTopicFeed(w http.ResponseWriter, r *http.Request) {
+	topic, stories, ok := h.topicStories(w, r)
+	if !ok {
+		return
+	}
+
+	base := requestBaseURL(r)
+	feed := models.JSONFeed{
+		Version:     models.JSONFeedVersion,
+		Title:       topic.Name,
+		HomePageURL: base + "/",
+		FeedURL:     base + r.URL.Path,
+		Description: topic.Description,
+		Items:       make([]models.JSONFeedItem, 0, len(stories)),
+	}
+	for _, story := range stories {
+		published := story.PublishedAt
+		if published.IsZero() {
+			published = story.CreatedAt
+		}
+		item := models.JSONFeedItem{
+			ID:            strconv.FormatInt(story.ID, 10),
+			URL:           story.SourceURL,
+			Title:         story.Title,
+			ContentText:   story.Summary,
+			Image:         story.ImageURL,
+			DatePublished: published,
+			Tags:          story.Tags,
+		}
+		if story.SourceTitle != "" {
+			item.Authors = []models.JSONFeedAuthor{{Name: story.SourceTitle}}
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+	json.NewEncoder(w).Encode(feed)
+}
+
+// storyOrder returns the order for a story list: the ?order_by= field, validated, with
+// importance first if the settings rank by it
+func storyOrder(r *http.Request, settings *models.Settings) (database.StoryOrder, error) {
+	by, err := database.ParseOrderBy(r.URL.Query().Get("order_by"))
+	if err != nil {
+		return database.StoryOrder{}, err
+	}
+	return database.StoryOrder{By: by, ByImportance: settings != nil && settings.RankByImportance}, nil
+}
+
+// topicStories loads the topic and stories for the external API's per-topic endpoints,
+// honouring the limit, since, until, tag, and order_by parameters. It writes an error response and
+// returns false if they can't be loaded.
+func (h *Handlers) topicStories(w http.ResponseWriter, r *http.Request) (*models.Topic, []models.Story, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid topic ID")
+		return nil, nil, false
+	}
+	since, until, filtered, err := parseDateRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return nil, nil, false
+	}
+
+	settings, _ := h.db.GetSettings()
+	limit := 5
+	if settings != nil {
+		limit = settings.StoriesPerTopic
+	}
+	order, err := storyOrder(r, settings)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return nil, nil, false
+	}
+
+	// Check for limit query param
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	topic, err := h.db.GetTopic(id)
+	if err != nil || topic == nil {
+		jsonError(w, http.StatusNotFound, "Topic not found")
+		return nil, nil, false
+	}
+
+	var stories []models.Story
+	if tag := gemini.NormalizeTag(r.URL.Query().Get("tag")); tag != "" {
+		stories, err = h.db.GetStoriesForTopicWithTag(id, tag, since, until, limit, order)
+	} else if filtered {
+		stories, err = h.db.GetStoriesForTopicBetween(id, since, until, limit, order)
+	} else {
+		stories, err = h.db.GetStoriesForTopic(id, limit, order)
+	}
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return nil, nil, false
+	}
+	return topic, stories, true
+}
+
+// requestBaseURL returns the scheme and host the client used to reach the server,
+// honouring X-Forwarded-Proto from a reverse proxy
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+// parseDateRange reads the ?since= and ?until= story filters, each either RFC3339 or a
+// date (YYYY-MM-DD, in server local time). A date-only until covers the whole day. A
+// missing bound is left open; filtered reports whether either was given.
+func parseDateRange(r *http.Request) (since, until time.Time, filtered bool, err error) {
+	since = time.Unix(0, 0)
+	until = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+
+	if s := r.URL.Query().Get("since"); s != "" {
+		t, _, err := parseDate(s)
+		if err != nil {
+			return since, until, false, fmt.Errorf("Invalid since date %q, expected RFC3339 (2006-01-02T15:04:05Z) or YYYY-MM-DD", s)
+		}
+		since, filtered = t, true
+	}
+	if s := r.URL.Query().Get("until"); s != "" {
+		t, dateOnly, err := parseDate(s)
+		if err != nil {
+			return since, until, false, fmt.Errorf("Invalid until date %q, expected RFC3339 (2006-01-02T15:04:05Z) or YYYY-MM-DD", s)
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		until, filtered = t, true
+	}
+
+	if until.Before(since) {
+		return since, until, false, fmt.Errorf("until must not be before since")
+	}
+	return since, until, filtered, nil
+}
+
+// parseDate parses an RFC3339 timestamp or a local YYYY-MM-DD date, reporting which it was
+func parseDate(s string) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+	t, err = time.ParseInLocation(time.DateOnly, s, time.Local)
+	return t, true, err
+}
+
+// APIGetRefreshStatus returns the refresh status of all topics
+func (h *Handlers) APIGetRefreshStatus(w http.ResponseWriter, r *http.Request) {
+	statuses, err := h.db.GetAllRefreshStatusesWithNames()
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if statuses == nil {
+		statuses = []models.TopicRefreshStatus{}
+	}
+
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: statuses})
+}
+
+// GetSchedulerHealth reports whether the scheduler loop is alive and which model AI
+// calls are going to
+func (h *Handlers) GetSchedulerHealth(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: h.scheduler.Health()})
+}
+
+// APIStatusStream streams refresh status updates as Server-Sent Events
+func (h *Handlers) APIStatusStream(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	// The stream is long-lived, so lift the server's write timeout for this response
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logging.FromContext(r.Context()).Warn("Could not clear write deadline for status stream", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	// Subscribe before sending the snapshot so no transition is missed in between
+	updates, unsubscribe := h.scheduler.Subscribe()
+	defer unsubscribe()
+
+	writeEvent := func(status models.RefreshStatus) error {
+		data, err := json.Marshal(status)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: status\ndata: %s\n\n", data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	// Send the current state of every topic first
+	statuses, err := h.db.GetAllRefreshStatuses()
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error getting refresh statuses for stream", "error", err)
+	}
+	for _, status := range statuses {
+		if err := writeEvent(status); err != nil {
+			return
+		}
+	}
+
+	// Periodic comments keep proxies from closing an idle connection
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case status, ok := <-updates:
+			if !ok {
+				return
+			}
+			if err := writeEvent(status); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
 }
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaskKey(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{"empty", "", ""},
+		{"3 characters", "abc", keyMask},
+		{"8 characters", "abcdefgh", keyMask},
+		{"just under the reveal length", "abcdefghijk", keyMask},
+		{"reveal length", "abcdefghijkl", keyMask + "ijkl"},
+		{"real key", "AIzaSyD-1234567890abcdefghijklmnopqrs", keyMask + "pqrs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maskKey(tt.key); got != tt.want {
+				t.Errorf("maskKey(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskedKeyRoundTrip(t *testing.T) {
+	// A settings form sends back the masked key it was given; the stored key must survive
+	for _, stored := range []string{"", "abc", "abcdefgh", "abcdefghijkl", "AIzaSyD-1234567890abcdefghijklmnopqrs"} {
+		masked := maskKey(stored)
+		if stored != "" && strings.Contains(masked, stored) {
+			t.Errorf("maskKey(%q) = %q reveals the whole key", stored, masked)
+		}
+		if got := keepMaskedKey(masked, stored); got != stored {
+			t.Errorf("keepMaskedKey(maskKey(%q), %q) = %q, want the stored key", stored, stored, got)
+		}
+	}
+}
+
+func TestKeepMaskedKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		submitted string
+		stored    string
+		want      string
+	}{
+		{"empty keeps stored", "", "stored-key", "stored-key"},
+		{"bare mask keeps stored", keyMask, "abc", "abc"},
+		{"mask with suffix keeps stored", keyMask + "ijkl", "abcdefghijkl", "abcdefghijkl"},
+		{"new key replaces stored", "new-key", "stored-key", "new-key"},
+		{"new short key replaces stored", "xyz", "", "xyz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keepMaskedKey(tt.submitted, tt.stored); got != tt.want {
+				t.Errorf("keepMaskedKey(%q, %q) = %q, want %q", tt.submitted, tt.stored, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package llm
+
+import (
+	"fmt"
+
+	"github.com/thinkscotty/maggpi_go/internal/gemini"
+	"github.com/thinkscotty/maggpi_go/internal/models"
+)
+
+// ProviderGemini is Google's Gemini API
+const ProviderGemini = "gemini"
+
+// Compile-time check that the client satisfies the interface
+var _ Summarizer = (*gemini.Client)(nil)
+
+func init() {
+	Register(Provider{
+		Name:  ProviderGemini,
+		Label: "Google Gemini",
+		Configured: func(settings *models.Settings) error {
+			if settings.GeminiAPIKey == "" {
+				return fmt.Errorf("Gemini API key not configured")
+			}
+			return nil
+		},
+		New: func(settings *models.Settings) (Summarizer, error) {
+			client, err := gemini.New(settings.GeminiAPIKey, settings.GeminiModel)
+			if err != nil {
+				return nil, err
+			}
+			client.SetFallbackModel(settings.FallbackModel)
+			client.SetSearchGrounding(settings.SearchGrounding)
+			client.SetGenerationParams(gemini.GenerationParams{
+				Temperature:     settings.GeminiTemperature,
+				TopP:            settings.GeminiTopP,
+				MaxOutputTokens: settings.GeminiMaxOutputTokens,
+				SafetyThreshold: settings.GeminiSafetyThreshold,
+			})
+			return client, nil
+		},
+	})
+}
@@ -0,0 +1,147 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/thinkscotty/maggpi_go/internal/gemini"
+	"github.com/thinkscotty/maggpi_go/internal/models"
+)
+
+// Summarizer is implemented by every AI backend that can discover sources and summarize content
+type Summarizer interface {
+	// DiscoverSources finds relevant web sources for a topic
+	DiscoverSources(ctx context.Context, topicName, topicDescription, globalInstructions string) ([]gemini.DiscoveredSource, error)
+
+	// SummarizeContent turns scraped content into news stories
+	SummarizeContent(ctx context.Context, topicName string, scrapedContent []gemini.ScrapedContent, globalInstructions string, maxStories int) ([]gemini.SummarizedStory, error)
+
+	// SuggestTopics suggests count new topics related to the existing ones
+	SuggestTopics(ctx context.Context, existing []gemini.SuggestedTopic, count int) ([]gemini.SuggestedTopic, error)
+
+	// SetUsageFunc sets a function called after every API call with the tokens it used
+	SetUsageFunc(fn gemini.UsageFunc)
+
+	// SetProgressFunc sets a function called as response text arrives, streaming
+	// responses where the backend supports it
+	SetProgressFunc(fn gemini.ProgressFunc)
+
+	// SetMaxContentLength caps the total scraped text sent in one summarize prompt, 0 for no cap
+	SetMaxContentLength(n int)
+
+	// SetChunkThreshold sets how much scraped text switches summarizing to batches of a
+	// few sources followed by a consolidation pass, 0 to always use a single prompt
+	SetChunkThreshold(n int)
+
+	// SetSummaryLength sets the length range, in words, asked for in each story summary
+	SetSummaryLength(minWords, maxWords int)
+
+	// SetSourceCount sets how many sources source discovery asks for
+	SetSourceCount(minSources, maxSources int)
+
+	// Close releases any resources held by the client
+	Close() error
+}
+
+// Provider describes an AI backend. Each backend registers itself from its own file in
+// this package, so adding one doesn't touch the scheduler or handlers.
+type Provider struct {
+	Name  string // value of the provider setting
+	Label string // shown in the settings page
+
+	// Validate checks the provider's settings when they're saved, nil if there's nothing to check
+	Validate func(settings *models.Settings) error
+	// Configured returns an error if settings lack something needed to make API calls
+	Configured func(settings *models.Settings) error
+	// Timeout returns how long one summarize or discover call may take, nil for the
+	// shared gemini_timeout_seconds setting
+	Timeout func(settings *models.Settings) time.Duration
+	// New creates a client from the settings
+	New func(settings *models.Settings) (Summarizer, error)
+}
+
+// DefaultProvider is used when the provider setting is empty
+const DefaultProvider = ProviderGemini
+
+// providers holds the registered backends in registration order
+var providers []Provider
+
+// Register adds a backend. It is meant to be called from init functions.
+func Register(p Provider) {
+	if _, ok := Lookup(p.Name); ok {
+		panic("llm: provider registered twice: " + p.Name)
+	}
+	providers = append(providers, p)
+}
+
+// Providers returns the registered backends
+func Providers() []Provider {
+	return providers
+}
+
+// Lookup returns the backend registered under name, or the default backend if name is empty
+func Lookup(name string) (Provider, bool) {
+	if name == "" {
+		name = DefaultProvider
+	}
+	for _, p := range providers {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Provider{}, false
+}
+
+// lookupSetting returns the backend selected in settings, or an error naming the valid ones
+func lookupSetting(settings *models.Settings) (Provider, error) {
+	p, ok := Lookup(settings.Provider)
+	if !ok {
+		var names []string
+		for _, p := range providers {
+			names = append(names, fmt.Sprintf("%q", p.Name))
+		}
+		return Provider{}, fmt.Errorf("unknown AI provider %q, expected one of %s", settings.Provider, strings.Join(names, ", "))
+	}
+	return p, nil
+}
+
+// Validate checks the AI settings when they're saved
+func Validate(settings *models.Settings) error {
+	p, err := lookupSetting(settings)
+	if err != nil {
+		return err
+	}
+	if p.Validate == nil {
+		return nil
+	}
+	return p.Validate(settings)
+}
+
+// CheckConfigured returns an error if the selected backend is missing required settings
+func CheckConfigured(settings *models.Settings) error {
+	p, err := lookupSetting(settings)
+	if err != nil {
+		return err
+	}
+	return p.Configured(settings)
+}
+
+// Timeout returns how long one summarize or discover call may take with the backend
+// selected in settings, retries included
+func Timeout(settings *models.Settings) time.Duration {
+	if p, ok := Lookup(settings.Provider); ok && p.Timeout != nil {
+		return p.Timeout(settings)
+	}
+	return time.Duration(settings.GeminiTimeoutSeconds) * time.Second
+}
+
+// New creates a client for the backend selected in settings
+func New(settings *models.Settings) (Summarizer, error) {
+	p, err := lookupSetting(settings)
+	if err != nil {
+		return nil, err
+	}
+	return p.New(settings)
+}
@@ -0,0 +1,45 @@
+package llm
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/thinkscotty/maggpi_go/internal/llm/ollama"
+	"github.com/thinkscotty/maggpi_go/internal/models"
+)
+
+// ProviderOllama is an Ollama server, usually on the local network
+const ProviderOllama = "ollama"
+
+// Compile-time check that the client satisfies the interface
+var _ Summarizer = (*ollama.Client)(nil)
+
+func init() {
+	Register(Provider{
+		Name:  ProviderOllama,
+		Label: "Ollama (local LLM)",
+		Validate: func(settings *models.Settings) error {
+			if settings.OllamaModel == "" {
+				return fmt.Errorf("Ollama provider needs a model")
+			}
+			parsed, err := url.Parse(settings.OllamaHost)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return fmt.Errorf("Invalid Ollama host: must be an http or https URL")
+			}
+			return nil
+		},
+		Configured: func(settings *models.Settings) error {
+			if settings.OllamaModel == "" {
+				return fmt.Errorf("Ollama model not configured")
+			}
+			return nil
+		},
+		Timeout: func(settings *models.Settings) time.Duration {
+			return time.Duration(settings.OllamaTimeoutSeconds) * time.Second
+		},
+		New: func(settings *models.Settings) (Summarizer, error) {
+			return ollama.New(settings.OllamaHost, settings.OllamaModel)
+		},
+	})
+}
@@ -0,0 +1,242 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/thinkscotty/maggpi_go/internal/gemini"
+	"github.com/thinkscotty/maggpi_go/internal/llm/options"
+)
+
+// DefaultHost is where Ollama listens out of the box
+const DefaultHost = "http://localhost:11434"
+
+// Client talks to an Ollama server's chat API
+type Client struct {
+	httpClient *http.Client
+	host       string
+	model      string
+
+	options.Options
+}
+
+// New creates a new Ollama client. host is the server root, e.g. "http://192.168.1.20:11434".
+func New(host, model string) (*Client, error) {
+	if host == "" {
+		host = DefaultHost
+	}
+	if model == "" {
+		return nil, fmt.Errorf("Ollama model is required")
+	}
+
+	return &Client{
+		// No client timeout: local models can be very slow, and the caller's context bounds each call
+		httpClient: &http.Client{},
+		host:       strings.TrimRight(host, "/"),
+		model:      model,
+		Options:    options.Default(),
+	}, nil
+}
+
+// Close is a no-op as the HTTP client doesn't require explicit cleanup
+func (c *Client) Close() error {
+	return nil
+}
+
+// DiscoverSources uses AI to find relevant sources for a topic
+func (c *Client) DiscoverSources(ctx context.Context, topicName, topicDescription, globalInstructions string) ([]gemini.DiscoveredSource, error) {
+	prompt := gemini.DiscoverSourcesPrompt(topicName, topicDescription, globalInstructions, c.MinSources, c.MaxSources)
+
+	var sources []gemini.DiscoveredSource
+	err := c.completeJSON(ctx, prompt, func(text string) error {
+		var err error
+		sources, err = gemini.ParseSources(text)
+		return err
+	})
+	return sources, err
+}
+
+// SuggestTopics suggests count new topics related to the existing ones
+func (c *Client) SuggestTopics(ctx context.Context, existing []gemini.SuggestedTopic, count int) ([]gemini.SuggestedTopic, error) {
+	prompt := gemini.SuggestTopicsPrompt(existing, count)
+
+	var topics []gemini.SuggestedTopic
+	err := c.completeJSON(ctx, prompt, func(text string) error {
+		var err error
+		topics, err = gemini.ParseTopics(text)
+		return err
+	})
+	return topics, err
+}
+
+// SummarizeContent summarizes scraped content into news stories. Content over the chunk
+// threshold is summarized a few sources at a time and the results consolidated.
+func (c *Client) SummarizeContent(ctx context.Context, topicName string, scrapedContent []gemini.ScrapedContent, globalInstructions string, maxStories int) ([]gemini.SummarizedStory, error) {
+	if len(scrapedContent) == 0 {
+		return nil, nil
+	}
+	if c.ChunkChars > 0 && gemini.ContentLength(scrapedContent) > c.ChunkChars {
+		return gemini.SummarizeChunked(ctx, gemini.Batches(scrapedContent, c.ChunkChars), maxStories,
+			func(ctx context.Context, batch []gemini.ScrapedContent) ([]gemini.SummarizedStory, error) {
+				return c.summarize(ctx, topicName, batch, globalInstructions, maxStories)
+			},
+			func(ctx context.Context, candidates []gemini.SummarizedStory) ([]gemini.SummarizedStory, error) {
+				return c.consolidate(ctx, topicName, candidates, globalInstructions, maxStories)
+			})
+	}
+	return c.summarize(ctx, topicName, scrapedContent, globalInstructions, maxStories)
+}
+
+// summarize turns scraped content into news stories with a single prompt
+func (c *Client) summarize(ctx context.Context, topicName string, scrapedContent []gemini.ScrapedContent, globalInstructions string, maxStories int) ([]gemini.SummarizedStory, error) {
+	prompt := gemini.SummarizePrompt(topicName, gemini.LimitContent(scrapedContent, c.MaxContent), globalInstructions, maxStories, c.MinWords, c.MaxWords)
+
+	var stories []gemini.SummarizedStory
+	err := c.completeJSON(ctx, prompt, func(text string) error {
+		var err error
+		stories, err = gemini.ParseStories(text)
+		return err
+	})
+	return stories, err
+}
+
+// consolidate picks the final stories from the candidates summarized batch by batch
+func (c *Client) consolidate(ctx context.Context, topicName string, candidates []gemini.SummarizedStory, globalInstructions string, maxStories int) ([]gemini.SummarizedStory, error) {
+	prompt := gemini.ConsolidatePrompt(topicName, candidates, globalInstructions, maxStories, c.MinWords, c.MaxWords)
+
+	var stories []gemini.SummarizedStory
+	err := c.completeJSON(ctx, prompt, func(text string) error {
+		var err error
+		stories, err = gemini.ParseConsolidated(text, candidates, maxStories)
+		return err
+	})
+	return stories, err
+}
+
+// completeJSON sends prompt and hands the reply to parse. Small local models often get
+// the JSON slightly wrong, so if parse fails the model gets one chance to repair its
+// reply before the error is returned.
+func (c *Client) completeJSON(ctx context.Context, prompt string, parse func(text string) error) error {
+	messages := []chatMessage{{Role: "user", Content: prompt}}
+	text, err := c.complete(ctx, messages)
+	if err != nil {
+		return err
+	}
+	parseErr := parse(unwrapArray(text))
+	if parseErr == nil {
+		return nil
+	}
+
+	messages = append(messages,
+		chatMessage{Role: "assistant", Content: text},
+		chatMessage{Role: "user", Content: fmt.Sprintf("That reply was not valid JSON in the requested format (%v). "+
+			"Reply again with only the corrected JSON array and nothing else.", gemini.BriefError(parseErr))},
+	)
+	text, err = c.complete(ctx, messages)
+	if err != nil {
+		return fmt.Errorf("%w (repair request also failed: %v)", parseErr, err)
+	}
+	return parse(unwrapArray(text))
+}
+
+// complete sends a chat request, reports its usage, and returns the reply text
+func (c *Client) complete(ctx context.Context, messages []chatMessage) (string, error) {
+	text, usage, err := c.send(ctx, messages)
+	if c.OnUsage != nil {
+		c.OnUsage(usage)
+	}
+	if c.OnProgress != nil && err == nil {
+		c.OnProgress(len(text), text)
+	}
+	return text, err
+}
+
+// send performs the chat request and returns the reply text and the tokens used
+func (c *Client) send(ctx context.Context, messages []chatMessage) (string, gemini.Usage, error) {
+	reqBody, err := json.Marshal(chatRequest{
+		Model:    c.model,
+		Messages: messages,
+		Stream:   false,
+		Format:   "json",
+	})
+	if err != nil {
+		return "", gemini.Usage{}, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.host+"/api/chat", bytes.NewReader(reqBody))
+	if err != nil {
+		return "", gemini.Usage{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", gemini.Usage{}, fmt.Errorf("failed to call Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", gemini.Usage{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		msg := string(body)
+		if len(msg) > 500 {
+			msg = msg[:500]
+		}
+		return "", gemini.Usage{}, fmt.Errorf("Ollama returned status %d: %s", resp.StatusCode, msg)
+	}
+
+	var chat chatResponse
+	if err := json.Unmarshal(body, &chat); err != nil {
+		return "", gemini.Usage{}, fmt.Errorf("failed to parse Ollama response: %w", err)
+	}
+
+	usage := gemini.Usage{PromptTokens: chat.PromptEvalCount, OutputTokens: chat.EvalCount}
+	if chat.Message.Content == "" {
+		return "", usage, fmt.Errorf("empty response from model")
+	}
+	usage.Model = c.model
+	return chat.Message.Content, usage, nil
+}
+
+// unwrapArray returns the array inside a reply like {"stories": [...]}. JSON mode
+// makes Ollama answer with an object even when the prompt asks for an array.
+func unwrapArray(text string) string {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(strings.TrimSpace(text)), &object); err != nil {
+		return text
+	}
+	for _, value := range object {
+		if v := bytes.TrimSpace(value); len(v) > 0 && v[0] == '[' {
+			return string(v)
+		}
+	}
+	return text
+}
+
+// Ollama chat API structures
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Format   string        `json:"format,omitempty"`
+}
+
+type chatResponse struct {
+	Message         chatMessage `json:"message"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+	EvalCount       int         `json:"eval_count"`
+}
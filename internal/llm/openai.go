@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/thinkscotty/maggpi_go/internal/llm/openai"
+	"github.com/thinkscotty/maggpi_go/internal/models"
+)
+
+// ProviderOpenAI is any server implementing the OpenAI chat completions API
+const ProviderOpenAI = "openai"
+
+// Compile-time check that the client satisfies the interface
+var _ Summarizer = (*openai.Client)(nil)
+
+func init() {
+	Register(Provider{
+		Name:  ProviderOpenAI,
+		Label: "OpenAI-compatible (local LLM)",
+		Validate: func(settings *models.Settings) error {
+			if settings.OpenAIBaseURL == "" || settings.OpenAIModel == "" {
+				return fmt.Errorf("OpenAI-compatible provider needs a base URL and model")
+			}
+			parsed, err := url.Parse(settings.OpenAIBaseURL)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return fmt.Errorf("Invalid OpenAI base URL: must be an http or https URL")
+			}
+			return nil
+		},
+		Configured: func(settings *models.Settings) error {
+			if settings.OpenAIBaseURL == "" || settings.OpenAIModel == "" {
+				return fmt.Errorf("OpenAI-compatible base URL and model not configured")
+			}
+			return nil
+		},
+		New: func(settings *models.Settings) (Summarizer, error) {
+			return openai.New(settings.OpenAIBaseURL, settings.OpenAIAPIKey, settings.OpenAIModel)
+		},
+	})
+}
@@ -0,0 +1,236 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thinkscotty/maggpi_go/internal/gemini"
+	"github.com/thinkscotty/maggpi_go/internal/llm/options"
+)
+
+// Client talks to any server implementing the OpenAI chat completions API
+// (OpenAI itself, llama.cpp, LM Studio, vLLM, ...)
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+
+	options.Options
+}
+
+// New creates a new OpenAI-compatible client.
+// baseURL is the API root, e.g. "http://localhost:8080/v1". apiKey may be empty for
+// local servers that don't check one.
+func New(baseURL, apiKey, model string) (*Client, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("OpenAI base URL is required")
+	}
+	if model == "" {
+		return nil, fmt.Errorf("OpenAI model is required")
+	}
+
+	return &Client{
+		httpClient: &http.Client{
+			// Local models can be slow; the caller's context bounds the overall time
+			Timeout: 10 * time.Minute,
+		},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		Options: options.Default(),
+	}, nil
+}
+
+// Close is a no-op as the HTTP client doesn't require explicit cleanup
+func (c *Client) Close() error {
+	return nil
+}
+
+// DiscoverSources uses AI to find relevant sources for a topic
+func (c *Client) DiscoverSources(ctx context.Context, topicName, topicDescription, globalInstructions string) ([]gemini.DiscoveredSource, error) {
+	prompt := gemini.DiscoverSourcesPrompt(topicName, topicDescription, globalInstructions, c.MinSources, c.MaxSources)
+
+	var sources []gemini.DiscoveredSource
+	err := c.completeJSON(ctx, prompt, func(text string) error {
+		var err error
+		sources, err = gemini.ParseSources(text)
+		return err
+	})
+	return sources, err
+}
+
+// SuggestTopics suggests count new topics related to the existing ones
+func (c *Client) SuggestTopics(ctx context.Context, existing []gemini.SuggestedTopic, count int) ([]gemini.SuggestedTopic, error) {
+	prompt := gemini.SuggestTopicsPrompt(existing, count)
+
+	var topics []gemini.SuggestedTopic
+	err := c.completeJSON(ctx, prompt, func(text string) error {
+		var err error
+		topics, err = gemini.ParseTopics(text)
+		return err
+	})
+	return topics, err
+}
+
+// SummarizeContent summarizes scraped content into news stories. Content over the chunk
+// threshold is summarized a few sources at a time and the results consolidated.
+func (c *Client) SummarizeContent(ctx context.Context, topicName string, scrapedContent []gemini.ScrapedContent, globalInstructions string, maxStories int) ([]gemini.SummarizedStory, error) {
+	if len(scrapedContent) == 0 {
+		return nil, nil
+	}
+	if c.ChunkChars > 0 && gemini.ContentLength(scrapedContent) > c.ChunkChars {
+		return gemini.SummarizeChunked(ctx, gemini.Batches(scrapedContent, c.ChunkChars), maxStories,
+			func(ctx context.Context, batch []gemini.ScrapedContent) ([]gemini.SummarizedStory, error) {
+				return c.summarize(ctx, topicName, batch, globalInstructions, maxStories)
+			},
+			func(ctx context.Context, candidates []gemini.SummarizedStory) ([]gemini.SummarizedStory, error) {
+				return c.consolidate(ctx, topicName, candidates, globalInstructions, maxStories)
+			})
+	}
+	return c.summarize(ctx, topicName, scrapedContent, globalInstructions, maxStories)
+}
+
+// summarize turns scraped content into news stories with a single prompt
+func (c *Client) summarize(ctx context.Context, topicName string, scrapedContent []gemini.ScrapedContent, globalInstructions string, maxStories int) ([]gemini.SummarizedStory, error) {
+	prompt := gemini.SummarizePrompt(topicName, gemini.LimitContent(scrapedContent, c.MaxContent), globalInstructions, maxStories, c.MinWords, c.MaxWords)
+
+	var stories []gemini.SummarizedStory
+	err := c.completeJSON(ctx, prompt, func(text string) error {
+		var err error
+		stories, err = gemini.ParseStories(text)
+		return err
+	})
+	return stories, err
+}
+
+// consolidate picks the final stories from the candidates summarized batch by batch
+func (c *Client) consolidate(ctx context.Context, topicName string, candidates []gemini.SummarizedStory, globalInstructions string, maxStories int) ([]gemini.SummarizedStory, error) {
+	prompt := gemini.ConsolidatePrompt(topicName, candidates, globalInstructions, maxStories, c.MinWords, c.MaxWords)
+
+	var stories []gemini.SummarizedStory
+	err := c.completeJSON(ctx, prompt, func(text string) error {
+		var err error
+		stories, err = gemini.ParseConsolidated(text, candidates, maxStories)
+		return err
+	})
+	return stories, err
+}
+
+// completeJSON sends prompt and hands the reply to parse. If the reply can't be parsed
+// even after the parser's own repairs, the model is asked once to correct it.
+func (c *Client) completeJSON(ctx context.Context, prompt string, parse func(text string) error) error {
+	text, err := c.complete(ctx, prompt)
+	if err != nil {
+		return err
+	}
+	parseErr := parse(text)
+	if parseErr == nil || ctx.Err() != nil {
+		return parseErr
+	}
+
+	text, err = c.complete(ctx, gemini.RepairPrompt(text, parseErr))
+	if err != nil {
+		return fmt.Errorf("%w (repair request also failed: %v)", parseErr, err)
+	}
+	return parse(text)
+}
+
+// complete sends a single-message chat completion request, reports its usage, and returns the reply text
+func (c *Client) complete(ctx context.Context, prompt string) (string, error) {
+	text, usage, err := c.send(ctx, prompt)
+	if c.OnUsage != nil {
+		c.OnUsage(usage)
+	}
+	if c.OnProgress != nil && err == nil {
+		c.OnProgress(len(text), text)
+	}
+	return text, err
+}
+
+// send performs the chat completion request and returns the reply text and the tokens used
+func (c *Client) send(ctx context.Context, prompt string) (string, gemini.Usage, error) {
+	reqBody, err := json.Marshal(chatRequest{
+		Model: c.model,
+		Messages: []chatMessage{
+			{Role: "user", Content: prompt},
+		},
+	})
+	if err != nil {
+		return "", gemini.Usage{}, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(reqBody))
+	if err != nil {
+		return "", gemini.Usage{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", gemini.Usage{}, fmt.Errorf("failed to call chat completions API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", gemini.Usage{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		msg := string(body)
+		if len(msg) > 500 {
+			msg = msg[:500]
+		}
+		return "", gemini.Usage{}, fmt.Errorf("chat completions API returned status %d: %s", resp.StatusCode, msg)
+	}
+
+	var completion chatResponse
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return "", gemini.Usage{}, fmt.Errorf("failed to parse chat completions response: %w", err)
+	}
+
+	if len(completion.Choices) == 0 || completion.Choices[0].Message.Content == "" {
+		return "", completion.usage(), fmt.Errorf("empty response from model")
+	}
+
+	usage := completion.usage()
+	usage.Model = c.model
+	return completion.Choices[0].Message.Content, usage, nil
+}
+
+// OpenAI chat completions API structures
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// usage converts the response's token counts to the shared Usage type
+func (r *chatResponse) usage() gemini.Usage {
+	return gemini.Usage{PromptTokens: r.Usage.PromptTokens, OutputTokens: r.Usage.CompletionTokens}
+}
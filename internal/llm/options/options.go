@@ -0,0 +1,61 @@
+// Package options holds the settings every AI client keeps the same way, for clients to
+// embed. It is separate from package llm because llm imports the clients.
+package options
+
+import "github.com/thinkscotty/maggpi_go/internal/gemini"
+
+// Options are a client's prompt and callback settings, with the setters llm.Summarizer requires
+type Options struct {
+	OnUsage    gemini.UsageFunc
+	OnProgress gemini.ProgressFunc
+	MaxContent int // total scraped text sent in one summarize prompt
+	ChunkChars int // scraped text above which content is summarized in batches, 0 for never
+	MinWords   int // target summary length range
+	MaxWords   int
+	MinSources int // number of sources discovery asks for
+	MaxSources int
+}
+
+// Default returns the options a new client starts with
+func Default() Options {
+	return Options{
+		MaxContent: gemini.DefaultMaxContentLength,
+		ChunkChars: gemini.DefaultChunkThreshold,
+		MinWords:   gemini.DefaultSummaryMinWords,
+		MaxWords:   gemini.DefaultSummaryMaxWords,
+		MinSources: gemini.DefaultMinSources,
+		MaxSources: gemini.DefaultMaxSources,
+	}
+}
+
+// SetUsageFunc sets the function called after each API call
+func (o *Options) SetUsageFunc(fn gemini.UsageFunc) {
+	o.OnUsage = fn
+}
+
+// SetProgressFunc sets the function called as response text arrives. Clients that don't
+// stream responses call it once with the whole reply.
+func (o *Options) SetProgressFunc(fn gemini.ProgressFunc) {
+	o.OnProgress = fn
+}
+
+// SetMaxContentLength caps the scraped text sent in one summarize prompt, 0 for no cap
+func (o *Options) SetMaxContentLength(n int) {
+	o.MaxContent = n
+}
+
+// SetChunkThreshold sets how much scraped text switches summarizing to batches followed
+// by a consolidation pass, 0 to always use a single prompt
+func (o *Options) SetChunkThreshold(n int) {
+	o.ChunkChars = n
+}
+
+// SetSummaryLength sets the length range, in words, asked for in each story summary
+func (o *Options) SetSummaryLength(minWords, maxWords int) {
+	o.MinWords, o.MaxWords = minWords, maxWords
+}
+
+// SetSourceCount sets how many sources source discovery asks for
+func (o *Options) SetSourceCount(minSources, maxSources int) {
+	o.MinSources, o.MaxSources = minSources, maxSources
+}
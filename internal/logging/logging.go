@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Supported log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Setup installs the default slog logger for the given format. Text output goes through
+// the standard log package, so it keeps the familiar timestamped lines; JSON output
+// writes one object per line to stderr for log shippers. Debug enables debug-level logs.
+//
+// Log lines use the same attribute names everywhere: topic_id and topic for topics,
+// source_id and source_url for sources, request_id for API requests, refresh_id for
+// topic refreshes, and error for errors.
+func Setup(format string, debug bool) error {
+	level := slog.LevelInfo
+	if debug {
+		level = slog.LevelDebug
+	}
+
+	switch format {
+	case "", FormatText:
+		slog.SetLogLoggerLevel(level)
+	case FormatJSON:
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+	default:
+		return fmt.Errorf("unknown log format %q, expected %q or %q", format, FormatText, FormatJSON)
+	}
+	return nil
+}
+
+// loggerKey is the context key for the logger set by WithLogger
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger, so code further down the call chain
+// logs with its attributes, such as a request or refresh ID
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger if it has none
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
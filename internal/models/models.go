@@ -4,56 +4,152 @@ import "time"
 
 // Topic represents a user-defined topic for news aggregation
 type Topic struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Position    int       `json:"position"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                int64  `json:"id"`
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	Position          int    `json:"position"`
+	CronSchedule      string `json:"cron_schedule"`      // optional 5-field cron expression, overrides the refresh interval
+	AutoRefresh       bool   `json:"auto_refresh"`       // false means the topic is only refreshed manually
+	AutoDiscover      bool   `json:"auto_discover"`      // false leaves the sources to the user: the AI only looks for more when asked
+	Enabled           bool   `json:"enabled"`            // false pauses every refresh, manual ones included; stories are kept
+	SourcingPrompt    string `json:"sourcing_prompt"`    // overrides the global sourcing prompt when set
+	SummarizingPrompt string `json:"summarizing_prompt"` // overrides the global summarizing prompt when set
+	// StoryRetentionCount overrides the global retention for this topic when set; 0 keeps every story
+	StoryRetentionCount *int `json:"story_retention_count"`
+	// DiscoverSourceCount, when set, is how many sources discovery finds for this topic
+	// instead of the global min-max range
+	DiscoverSourceCount *int      `json:"discover_source_count"`
+	StoryLanguage       string    `json:"story_language"` // overrides the global story language when set
+	SummaryLength       string    `json:"summary_length"` // overrides the global summary length preset when set
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// NewTopic returns a topic with the defaults the topics table gives a new row: it is
+// enabled, refreshed automatically, and finds its own sources
+func NewTopic(name, description string) Topic {
+	return Topic{Name: name, Description: description, AutoRefresh: true, AutoDiscover: true, Enabled: true}
 }
 
 // Source represents a web source for a topic
 type Source struct {
-	ID           int64     `json:"id"`
-	TopicID      int64     `json:"topic_id"`
-	URL          string    `json:"url"`
-	Name         string    `json:"name"`
-	IsManual     bool      `json:"is_manual"`      // true if manually added by user
-	IsActive     bool      `json:"is_active"`      // false if source has failed multiple times
-	FailureCount int       `json:"failure_count"`  // consecutive failure count
-	LastError    string    `json:"last_error"`     // last error message
-	CreatedAt    time.Time `json:"created_at"`
+	ID            int64      `json:"id"`
+	TopicID       int64      `json:"topic_id"`
+	URL           string     `json:"url"`
+	Name          string     `json:"name"`
+	IsManual      bool       `json:"is_manual"`       // true if manually added by user
+	IsActive      bool       `json:"is_active"`       // false if source has failed multiple times
+	FailureCount  int        `json:"failure_count"`   // consecutive failure count
+	LastError     string     `json:"last_error"`      // last error message
+	LastScrapedAt *time.Time `json:"last_scraped_at"` // last scrape attempt, nil if never scraped
+	LastSuccessAt *time.Time `json:"last_success_at"` // last successful scrape, nil if never succeeded
+	ContentHash   string     `json:"-"`               // SHA-256 of the content last summarized, to skip unchanged pages
+	CreatedAt     time.Time  `json:"created_at"`
 }
 
 // Story represents a summarized news story
 type Story struct {
-	ID          int64     `json:"id"`
-	TopicID     int64     `json:"topic_id"`
-	SourceID    *int64    `json:"source_id,omitempty"` // Nullable - may not map to a specific source
-	Title       string    `json:"title"`
-	Summary     string    `json:"summary"`
-	SourceURL   string    `json:"source_url"`
-	SourceTitle string    `json:"source_title"`
-	ImageURL    string    `json:"image_url,omitempty"`
-	PublishedAt time.Time `json:"published_at"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          int64    `json:"id"`
+	TopicID     int64    `json:"topic_id"`
+	SourceID    *int64   `json:"source_id,omitempty"` // Nullable - may not map to a specific source
+	Title       string   `json:"title"`
+	Summary     string   `json:"summary"`
+	SourceURL   string   `json:"source_url"`
+	SourceTitle string   `json:"source_title"`
+	ImageURL    string   `json:"image_url,omitempty"`
+	Tags        []string `json:"tags"`       // lowercase labels from the AI, at most 3
+	Importance  int      `json:"importance"` // 1-10 from the AI; 5 for stories stored before it was asked for
+	Citations   []string `json:"citations"`  // URLs of the sources the summary draws on, the source URL first
+	// SummaryLength is the length preset the summary was written with, empty for stories
+	// stored before presets existed
+	SummaryLength string    `json:"summary_length,omitempty"`
+	PublishedAt   time.Time `json:"published_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TagCount is how many of a topic's stories carry a tag
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// StoryEmbedding is the stored embedding of a story, for spotting new stories that
+// cover the same event in different words
+type StoryEmbedding struct {
+	StoryID   int64
+	Title     string
+	Embedding []byte // encoded by the embeddings package
+}
+
+// ArchivedStory is a story moved out of the live feed by the retention cleanup
+type ArchivedStory struct {
+	Story
+	ArchivedAt time.Time `json:"archived_at"`
 }
 
 // Settings represents global application settings
 type Settings struct {
-	ID                       int64   `json:"id"`
-	RefreshIntervalMinutes   int     `json:"refresh_interval_minutes"`
-	StoriesPerTopic          int     `json:"stories_per_topic"`
-	GlobalSourcingPrompt     string  `json:"global_sourcing_prompt"`
-	GlobalSummarizingPrompt  string  `json:"global_summarizing_prompt"`
-	PrimaryColor             string  `json:"primary_color"`
-	SecondaryColor           string  `json:"secondary_color"`
-	DarkMode                 bool    `json:"dark_mode"`
-	GeminiAPIKey             string  `json:"gemini_api_key"`
-	DashboardTitle           string  `json:"dashboard_title"`
-	DashboardSubtitle        string  `json:"dashboard_subtitle"`
-	StoryTitleFontSize       float64 `json:"story_title_font_size"`
-	StoryTextFontSize        float64 `json:"story_text_font_size"`
+	ID                      int64   `json:"id"`
+	RefreshIntervalMinutes  int     `json:"refresh_interval_minutes"`
+	StoriesPerTopic         int     `json:"stories_per_topic"`
+	GlobalSourcingPrompt    string  `json:"global_sourcing_prompt"`
+	GlobalSummarizingPrompt string  `json:"global_summarizing_prompt"`
+	PrimaryColor            string  `json:"primary_color"`
+	SecondaryColor          string  `json:"secondary_color"`
+	DarkMode                bool    `json:"dark_mode"`
+	GeminiAPIKey            string  `json:"gemini_api_key"`
+	DashboardTitle          string  `json:"dashboard_title"`
+	DashboardSubtitle       string  `json:"dashboard_subtitle"`
+	StoryTitleFontSize      float64 `json:"story_title_font_size"`
+	StoryTextFontSize       float64 `json:"story_text_font_size"`
+	QuietHoursStart         string  `json:"quiet_hours_start"` // "HH:MM" local time, empty to disable
+	QuietHoursEnd           string  `json:"quiet_hours_end"`   // "HH:MM" local time, may wrap past midnight
+	Provider                string  `json:"provider"`          // AI backend: "gemini", "openai", or "ollama"
+	OpenAIBaseURL           string  `json:"openai_base_url"`   // e.g. http://localhost:8080/v1
+	OpenAIModel             string  `json:"openai_model"`
+	OpenAIAPIKey            string  `json:"openai_api_key"`            // sent as a bearer token, if set
+	MaxConcurrentRefreshes  int     `json:"max_concurrent_refreshes"`  // scheduled topic refreshes run in parallel
+	RefreshStaggerSeconds   int     `json:"refresh_stagger_seconds"`   // pause each worker takes between refreshes
+	RedditIncludeLinkPosts  bool    `json:"reddit_include_link_posts"` // include link posts, not just self posts
+	RedditMinWords          int     `json:"reddit_min_words"`          // minimum words in a self post, 0 to disable
+	RedditMinScore          int     `json:"reddit_min_score"`          // minimum post score, 0 to disable
+	RedditMaxAgeHours       int     `json:"reddit_max_age_hours"`      // skip posts older than this, 0 to disable
+	StoryRetentionCount     int     `json:"story_retention_count"`     // stories kept per topic, 0 for 3x stories per topic
+	StoryRetentionDays      int     `json:"story_retention_days"`      // if set, keep stories by age instead of count
+	ScrapeParallelism       int     `json:"scrape_parallelism"`        // sources scraped at once per refresh (1-16)
+	WebhookURL              string  `json:"webhook_url"`               // POSTed to after each successful refresh, empty to disable
+	DailyRequestBudget      int     `json:"daily_request_budget"`      // AI API calls allowed per day, 0 for unlimited
+	DailyTokenBudget        int     `json:"daily_token_budget"`        // AI tokens allowed per day, 0 for unlimited
+	BoilerplatePatterns     string  `json:"boilerplate_patterns"`      // extra lines to strip from scraped text, one pattern per line
+	HeadlessFallback        bool    `json:"headless_fallback"`         // render near-empty pages in headless Chromium
+	ArchiveStories          bool    `json:"archive_stories"`           // move old stories to the archive instead of deleting them
+	ArchiveRetentionDays    int     `json:"archive_retention_days"`    // archived stories are deleted after this many days, 0 keeps them forever
+	GeminiModel             string  `json:"gemini_model"`              // Gemini model name, e.g. gemini-2.0-flash-lite
+	MaxSourceChars          int     `json:"max_source_chars"`          // scraped text kept per source
+	MaxPromptChars          int     `json:"max_prompt_chars"`          // scraped text sent in one summarize prompt, 0 for unlimited
+	SummaryMinWords         int     `json:"summary_min_words"`         // target summary length range, in words
+	SummaryMaxWords         int     `json:"summary_max_words"`
+	PromptTokenPrice        float64 `json:"prompt_token_price"`     // USD per million prompt tokens, for cost estimates
+	OutputTokenPrice        float64 `json:"output_token_price"`     // USD per million output tokens
+	GeminiTimeoutSeconds    int     `json:"gemini_timeout_seconds"` // bounds each AI summarize or discover call
+	SearchGrounding         bool    `json:"search_grounding"`       // ground Gemini source discovery in Google Search results
+	OllamaHost              string  `json:"ollama_host"`            // Ollama server root
+	OllamaModel             string  `json:"ollama_model"`
+	OllamaTimeoutSeconds    int     `json:"ollama_timeout_seconds"` // bounds each Ollama call; local models are slow
+	GeminiTemperature       float64 `json:"gemini_temperature"`     // 0-2; lower keeps summaries closer to the source
+	GeminiTopP              float64 `json:"gemini_top_p"`
+	GeminiMaxOutputTokens   int     `json:"gemini_max_output_tokens"`  // 0 for the model's default
+	GeminiSafetyThreshold   string  `json:"gemini_safety_threshold"`   // e.g. BLOCK_ONLY_HIGH, empty for the API default
+	FallbackModel           string  `json:"fallback_model"`            // tried once when the primary model fails, empty for none
+	ChunkThresholdChars     int     `json:"chunk_threshold_chars"`     // scraped text above which sources are summarized in batches, 0 for never
+	SemanticDedup           bool    `json:"semantic_dedup"`            // skip stories whose embedding is too close to a recent one
+	SemanticDedupThreshold  float64 `json:"semantic_dedup_threshold"`  // cosine similarity at or above which a story is a duplicate
+	StoryLanguage           string  `json:"story_language"`            // ISO 639 code stories are written in, empty for the sources' own language
+	RankByImportance        bool    `json:"rank_by_importance"`        // list stories by AI importance score before recency
+	VerifyDiscoveredSources bool    `json:"verify_discovered_sources"` // request each AI-discovered URL and drop ones that don't answer
+	SummaryLength           string  `json:"summary_length"`            // summary length preset: headline, short, standard or long
+	MinSources              int     `json:"min_sources"`               // number of sources discovery asks the AI for
+	MaxSources              int     `json:"max_sources"`               // discovered sources beyond this are dropped
 }
 
 // DefaultSettings returns the default application settings
@@ -62,14 +158,40 @@ func DefaultSettings() Settings {
 		RefreshIntervalMinutes:  120,
 		StoriesPerTopic:         5,
 		GlobalSourcingPrompt:    "Find reliable, reputable news sources that provide regular updates. Include relevant Reddit subreddits when appropriate for niche topics. Prefer sources with RSS feeds or well-structured HTML. Avoid paywalled content when possible.",
-		GlobalSummarizingPrompt: "Summarize the news story in a clear, informative tone. Focus on the key facts and why this story matters. Keep the summary between 75-150 words.",
+		GlobalSummarizingPrompt: "Summarize the news story in a clear, informative tone. Focus on the key facts and why this story matters.",
 		PrimaryColor:            "#243842",
 		SecondaryColor:          "#FA8638",
 		DarkMode:                false,
+		Provider:                "gemini",
 		DashboardTitle:          "Dashboard",
 		DashboardSubtitle:       "Your personalized news feed",
 		StoryTitleFontSize:      1.0,
 		StoryTextFontSize:       0.9,
+		MaxConcurrentRefreshes:  1,
+		RefreshStaggerSeconds:   30,
+		RedditIncludeLinkPosts:  false,
+		RedditMinWords:          100,
+		ScrapeParallelism:       2,
+		ArchiveRetentionDays:    90,
+		GeminiModel:             "gemini-2.0-flash",
+		MaxSourceChars:          10000,
+		MaxPromptChars:          60000,
+		SummaryMinWords:         75,
+		SummaryMaxWords:         150,
+		PromptTokenPrice:        0.1,
+		OutputTokenPrice:        0.4,
+		GeminiTimeoutSeconds:    90,
+		SearchGrounding:         true,
+		OllamaHost:              "http://localhost:11434",
+		OllamaTimeoutSeconds:    300,
+		GeminiTemperature:       1.0,
+		GeminiTopP:              0.95,
+		ChunkThresholdChars:     50000,
+		SemanticDedupThreshold:  0.88,
+		VerifyDiscoveredSources: true,
+		SummaryLength:           "standard",
+		MinSources:              4,
+		MaxSources:              8,
 	}
 }
 
@@ -79,6 +201,14 @@ type TopicWithStories struct {
 	Stories []Story `json:"stories"`
 }
 
+// TopicWithMeta is a topic with counts and refresh time for listing
+type TopicWithMeta struct {
+	Topic
+	StoryCount  int        `json:"story_count"`
+	SourceCount int        `json:"source_count"`
+	LastRefresh *time.Time `json:"last_refresh"` // nil if the topic has never been refreshed
+}
+
 // TopicWithSources combines a topic with its sources for management
 type TopicWithSources struct {
 	Topic   Topic    `json:"topic"`
@@ -87,11 +217,245 @@ type TopicWithSources struct {
 
 // RefreshStatus tracks the status of topic refreshes
 type RefreshStatus struct {
-	TopicID      int64     `json:"topic_id"`
-	LastRefresh  time.Time `json:"last_refresh"`
-	NextRefresh  time.Time `json:"next_refresh"`
-	Status       string    `json:"status"` // "pending", "in_progress", "completed", "failed"
-	ErrorMessage string    `json:"error_message,omitempty"`
+	TopicID         int64     `json:"topic_id"`
+	LastRefresh     time.Time `json:"last_refresh"`
+	NextRefresh     time.Time `json:"next_refresh"`
+	Status          string    `json:"status"` // "pending", "discovering", "queued", "in_progress", "completed", "failed", "deferred_budget", "disabled"
+	ErrorMessage    string    `json:"error_message,omitempty"`
+	ProgressStage   string    `json:"progress_stage,omitempty"` // e.g. "scraping 3/8", "summarizing", "storing"
+	ProgressPercent int       `json:"progress_percent"`
+	// EmptyRefreshes counts consecutive refreshes that produced no new stories
+	EmptyRefreshes int `json:"empty_refreshes"`
+	// BackoffMultiplier stretches the refresh interval while a topic has no new content
+	BackoffMultiplier int `json:"backoff_multiplier"`
+}
+
+// TopicRefreshStatus is a topic's refresh status along with its name and active source count
+type TopicRefreshStatus struct {
+	RefreshStatus
+	TopicName     string `json:"topic_name"`
+	ActiveSources int    `json:"active_sources"`
+}
+
+// RefreshHistory records the outcome of a single topic refresh
+type RefreshHistory struct {
+	ID             int64     `json:"id"`
+	TopicID        int64     `json:"topic_id"`
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
+	Status         string    `json:"status"` // "completed" or "failed"
+	StoriesCreated int       `json:"stories_created"`
+	SourcesScraped int       `json:"sources_scraped"`
+	SourcesFailed  int       `json:"sources_failed"`
+	Error          string    `json:"error,omitempty"`
+	PromptTokens   int       `json:"prompt_tokens"`
+	OutputTokens   int       `json:"output_tokens"`
+	Cost           float64   `json:"cost"`            // estimated from the configured token prices, in USD
+	Model          string    `json:"model,omitempty"` // model that wrote the stories
+}
+
+// Job statuses
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	JobSkipped   = "skipped" // e.g. a scheduled job that reached the front of the queue during quiet hours
+)
+
+// Job is a queued, running, or recently finished topic refresh
+type Job struct {
+	ID              int64      `json:"id"`
+	TopicID         int64      `json:"topic_id"`
+	Trigger         string     `json:"trigger"` // "scheduled" or "manual"
+	Status          string     `json:"status"`
+	Error           string     `json:"error,omitempty"`
+	QueuedAt        time.Time  `json:"queued_at"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	DurationSeconds float64    `json:"duration_seconds,omitempty"`
+}
+
+// SchedulerHealth reports whether the scheduler loop is alive
+type SchedulerHealth struct {
+	Running     bool       `json:"running"`
+	LastLoop    time.Time  `json:"last_loop"` // last time the loop checked for due topics
+	Restarts    int        `json:"restarts"`  // restarts after a panic since startup
+	LastPanic   string     `json:"last_panic,omitempty"`
+	LastPanicAt *time.Time `json:"last_panic_at,omitempty"`
+	// FallbackModel is set while AI calls are going to the fallback model because the
+	// primary model is failing, and cleared once the primary answers again
+	FallbackModel string     `json:"fallback_model,omitempty"`
+	FallbackSince *time.Time `json:"fallback_since,omitempty"`
+}
+
+// SchedulerStats counts refreshes since the scheduler started. Kept in memory only, so
+// they reset on restart.
+type SchedulerStats struct {
+	StartedAt          time.Time  `json:"started_at"`
+	Uptime             string     `json:"uptime"` // e.g. "26h3m12s"
+	RefreshesAttempted int        `json:"refreshes_attempted"`
+	RefreshesSucceeded int        `json:"refreshes_succeeded"`
+	RefreshesFailed    int        `json:"refreshes_failed"` // failed or aborted
+	SourcesDisabled    int        `json:"sources_disabled"` // sources disabled after repeated failures
+	LastFailureAt      *time.Time `json:"last_failure_at,omitempty"`
+	LastFailure        string     `json:"last_failure,omitempty"`
+	LastFailureTopicID int64      `json:"last_failure_topic_id,omitempty"`
+}
+
+// APIUsage counts the AI API calls and tokens used on one day
+type APIUsage struct {
+	Day      string `json:"day"` // "YYYY-MM-DD" local time
+	Requests int    `json:"requests"`
+	Tokens   int    `json:"tokens"`
+}
+
+// UsageResponse is returned by the usage endpoint. The remaining counts are
+// omitted when the corresponding budget is unlimited.
+type UsageResponse struct {
+	Today             APIUsage     `json:"today"`
+	RequestBudget     int          `json:"request_budget"`
+	TokenBudget       int          `json:"token_budget"`
+	RequestsRemaining *int         `json:"requests_remaining,omitempty"`
+	TokensRemaining   *int         `json:"tokens_remaining,omitempty"`
+	History           []APIUsage   `json:"history"` // recent days, newest first
+	Topics            []TopicUsage `json:"topics"`  // per topic per day over the same days
+}
+
+// TopicUsage is the AI usage and estimated cost of one topic on one day
+type TopicUsage struct {
+	Day          string  `json:"day"` // "YYYY-MM-DD" local time
+	TopicID      int64   `json:"topic_id"`
+	TopicName    string  `json:"topic_name"`
+	Requests     int     `json:"requests"`
+	PromptTokens int     `json:"prompt_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost"` // USD
+}
+
+// PreviewStory is a story a dry-run refresh would have stored
+type PreviewStory struct {
+	Title       string   `json:"title"`
+	Summary     string   `json:"summary"`
+	SourceURL   string   `json:"source_url"`
+	SourceTitle string   `json:"source_title"`
+	Tags        []string `json:"tags"`
+	Importance  int      `json:"importance"`
+	Citations   []string `json:"citations"`
+}
+
+// SourceCandidate is a source the AI suggested for a topic, checked but not stored
+type SourceCandidate struct {
+	URL              string `json:"url"` // normalized the way discovery stores it
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	Reachable        bool   `json:"reachable"`                    // the URL is valid and answered a request
+	Error            string `json:"error,omitempty"`              // why the source would be skipped
+	ExistingSourceID int64  `json:"existing_source_id,omitempty"` // set if the topic already has this source
+}
+
+// KeyTest is the result of testing an API key with a minimal request
+type KeyTest struct {
+	Model  string `json:"model"`
+	Reason string `json:"reason,omitempty"` // why the test failed: invalid_key, quota_exceeded, unknown_model, timeout or error
+}
+
+// SourcePreview is what scraping a URL yields, shown before it's added as a source
+type SourcePreview struct {
+	URL       string  `json:"url"`
+	Title     string  `json:"title,omitempty"` // the page title, or the subreddit for Reddit
+	Feed      bool    `json:"feed"`            // detected as an RSS or Atom feed
+	Reddit    bool    `json:"reddit"`          // fetched through the Reddit API
+	Bytes     int     `json:"bytes"`           // length of the extracted content
+	Content   string  `json:"content"`         // the start of the extracted content
+	Truncated bool    `json:"truncated"`       // Content is cut short of the full text
+	Error     string  `json:"error,omitempty"`
+	Seconds   float64 `json:"seconds"`
+}
+
+// PreviewSource reports how much content a dry-run refresh scraped from a source
+type PreviewSource struct {
+	SourceID int64  `json:"source_id"`
+	URL      string `json:"url"`
+	Name     string `json:"name"`
+	Bytes    int    `json:"bytes"`
+	Error    string `json:"error,omitempty"`
+}
+
+// RefreshPreview is the result of a dry-run refresh, which stores nothing
+type RefreshPreview struct {
+	TopicID          int64           `json:"topic_id"`
+	Stories          []PreviewStory  `json:"stories"`
+	Sources          []PreviewSource `json:"sources"`
+	ScrapeSeconds    float64         `json:"scrape_seconds"`
+	SummarizeSeconds float64         `json:"summarize_seconds"`
+	TotalSeconds     float64         `json:"total_seconds"`
+}
+
+// PreviewChunk is a piece of the AI response streamed during a dry-run refresh
+type PreviewChunk struct {
+	Received int    `json:"received"` // characters received so far, restarting if the call is retried
+	Text     string `json:"text"`
+}
+
+// JSONFeedVersion identifies the JSON Feed spec a JSONFeed follows
+const JSONFeedVersion = "https://jsonfeed.org/version/1.1"
+
+// JSONFeed is a topic's stories as a JSON Feed (https://jsonfeed.org) document
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+// JSONFeedItem is one story in a JSONFeed
+type JSONFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title"`
+	ContentText   string           `json:"content_text"`
+	Image         string           `json:"image,omitempty"`
+	DatePublished time.Time        `json:"date_published"`
+	Authors       []JSONFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+// JSONFeedAuthor credits the source a JSONFeedItem was summarized from
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// OPMLImportResult is returned by the OPML import endpoint
+type OPMLImportResult struct {
+	Topics  []ImportedTopic `json:"topics"`
+	Skipped []SkippedFeed   `json:"skipped"`
+}
+
+// ImportedTopic is a topic an OPML folder's feeds were added to
+type ImportedTopic struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	Created      bool   `json:"created"` // false if the feeds went to an existing topic of the same name
+	SourcesAdded int    `json:"sources_added"`
+}
+
+// SkippedFeed is an OPML feed that wasn't imported, and why
+type SkippedFeed struct {
+	Folder string `json:"folder,omitempty"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+// BulkSourceResult reports what happened to one URL sent to the bulk source endpoint
+type BulkSourceResult struct {
+	URL      string `json:"url"`                 // as sent
+	Status   string `json:"status"`              // added, invalid or duplicate
+	Error    string `json:"error,omitempty"`     // why an invalid URL was rejected
+	SourceID int64  `json:"source_id,omitempty"` // the new source, for added URLs
 }
 
 // APIResponse is the standard response format for the external API
@@ -99,4 +463,5 @@ type APIResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
+	Warning string      `json:"warning,omitempty"` // set when the request succeeded but something looks wrong
 }
@@ -0,0 +1,106 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Folder is a group of feeds, as RSS readers export their folders
+type Folder struct {
+	Title string
+	Feeds []Feed
+}
+
+// Feed is a single subscription
+type Feed struct {
+	Title string
+	URL   string
+}
+
+// Document is the result of parsing an OPML file
+type Document struct {
+	Folders []Folder
+	Loose   []Feed // feeds not inside any folder
+}
+
+// outline is an OPML outline element: a folder if it has children, a feed if it has an xmlUrl
+type outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr"`
+	XMLURL   string    `xml:"xmlUrl,attr"`
+	Outlines []outline `xml:"outline"`
+}
+
+// name returns the outline's title, falling back to its text as OPML allows either
+func (o outline) name() string {
+	if title := strings.TrimSpace(o.Title); title != "" {
+		return title
+	}
+	return strings.TrimSpace(o.Text)
+}
+
+type opml struct {
+	XMLName xml.Name  `xml:"opml"`
+	Body    []outline `xml:"body>outline"`
+}
+
+// Parse reads an OPML document. Each outline holding feeds becomes a folder; feeds in
+// nested folders go to the nested folder rather than its parent, and empty folders are
+// left out.
+func Parse(r io.Reader) (*Document, error) {
+	var doc opml
+	decoder := xml.NewDecoder(r)
+	// Exports in the wild declare all sorts of encodings; feed titles survive being read as-is
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid OPML: %w", err)
+	}
+
+	result := &Document{}
+	for _, o := range doc.Body {
+		if isFeed(o) {
+			result.Loose = append(result.Loose, feed(o))
+			continue
+		}
+		result.Folders = collect(result.Folders, o)
+	}
+	return result, nil
+}
+
+// collect appends the folder o, and any folders nested in it, to folders
+func collect(folders []Folder, o outline) []Folder {
+	folder := Folder{Title: o.name()}
+	var nested []outline
+	for _, child := range o.Outlines {
+		if isFeed(child) {
+			folder.Feeds = append(folder.Feeds, feed(child))
+		} else {
+			nested = append(nested, child)
+		}
+	}
+	if len(folder.Feeds) > 0 {
+		folders = append(folders, folder)
+	}
+	for _, child := range nested {
+		folders = collect(folders, child)
+	}
+	return folders
+}
+
+// isFeed reports whether o is a subscription rather than a folder
+func isFeed(o outline) bool {
+	return strings.TrimSpace(o.XMLURL) != ""
+}
+
+// feed converts a subscription outline, naming it after its URL if it has no title
+func feed(o outline) Feed {
+	f := Feed{Title: o.name(), URL: strings.TrimSpace(o.XMLURL)}
+	if f.Title == "" {
+		f.Title = f.URL
+	}
+	return f
+}
@@ -6,33 +6,92 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
-// Client handles fetching posts from Reddit's JSON API
+// Client handles fetching posts from Reddit's JSON API. It is safe for concurrent use and
+// meant to be shared: every request waits on the client's token bucket, so one client
+// keeps all its callers together under Reddit's limit. Separate clients would each get
+// the full allowance.
 type Client struct {
-	httpClient   *http.Client
-	userAgent    string
-	minWordCount int
-	mu           sync.Mutex
-	lastRequest  time.Time
-	minInterval  time.Duration
+	httpClient *http.Client
+	userAgent  string
+	limiter    *rate.Limiter
+
+	mu               sync.Mutex // guards the filters, which settings can change mid-refresh
+	minWordCount     int
+	minScore         int
+	maxAge           time.Duration
+	includeLinkPosts bool
 }
 
+const (
+	// requestInterval is the steady rate requests are allowed at, ~54 per minute
+	requestInterval = 1100 * time.Millisecond
+	// requestBurst is how many requests may go out back to back after a quiet spell. With
+	// the steady rate it keeps any one minute under Reddit's 60 requests.
+	requestBurst = 5
+	// maxRetryAfter caps how long a rate-limited request waits on Reddit's Retry-After
+	// header before its one retry
+	maxRetryAfter = 30 * time.Second
+)
+
 // Post represents a filtered Reddit post
 type Post struct {
 	Title      string
 	Body       string
 	Permalink  string
+	URL        string // external article link for link posts, empty for self and media posts
 	Subreddit  string
 	Author     string
 	Score      int
 	CreatedUTC time.Time
 }
 
+// Comment represents a top-level comment on a Reddit post
+type Comment struct {
+	Body   string
+	Author string
+	Score  int
+}
+
+// MinCommentScore is the score a comment needs to be returned by FetchTopComments
+const MinCommentScore = 5
+
+// Listing sort orders accepted by FetchPosts
+const (
+	SortHot    = "hot"
+	SortNew    = "new"
+	SortTop    = "top"
+	SortRising = "rising"
+)
+
+// validSorts lists the subreddit listings FetchPosts can request
+var validSorts = map[string]bool{
+	SortHot:    true,
+	SortNew:    true,
+	SortTop:    true,
+	SortRising: true,
+}
+
+// validTimeframes lists the time windows accepted for the "top" listing
+var validTimeframes = map[string]bool{
+	"hour":  true,
+	"day":   true,
+	"week":  true,
+	"month": true,
+	"year":  true,
+	"all":   true,
+}
+
 // New creates a new Reddit client with rate limiting
 func New() *Client {
 	return &Client{
@@ -40,14 +99,55 @@ func New() *Client {
 			Timeout: 30 * time.Second,
 		},
 		userAgent:    "MaggPi/1.0 (Raspberry Pi News Aggregator; +https://github.com/thinkscotty/maggpi_go)",
+		limiter:      rate.NewLimiter(rate.Every(requestInterval), requestBurst),
 		minWordCount: 100,
-		minInterval:  1100 * time.Millisecond, // ~54 req/min to stay under 60/min limit
 	}
 }
 
-// FetchPosts fetches and filters posts from a subreddit
-// Only returns text posts (self posts) with >100 words
-func (c *Client) FetchPosts(ctx context.Context, subredditURL string, topicName string) ([]Post, error) {
+// SetMinWordCount sets the minimum number of words a self post needs to be returned.
+// A value of 0 disables the filter.
+func (c *Client) SetMinWordCount(n int) {
+	if n < 0 {
+		n = 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.minWordCount = n
+}
+
+// SetMinScore sets the minimum score a post needs to be returned. A value of 0 disables the filter.
+func (c *Client) SetMinScore(n int) {
+	if n < 0 {
+		n = 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.minScore = n
+}
+
+// SetMaxAge sets how old a post may be to be returned. A value of 0 disables the filter.
+func (c *Client) SetMaxAge(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxAge = d
+}
+
+// SetIncludeLinkPosts controls whether link posts are returned alongside self posts
+func (c *Client) SetIncludeLinkPosts(include bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.includeLinkPosts = include
+}
+
+// FetchPosts fetches and filters posts from a subreddit listing.
+// sort is one of "hot", "new", "top" or "rising" (empty means "hot"); timeframe only applies
+// to "top" and is one of "hour", "day", "week", "month", "year" or "all" (empty means "day").
+// Returns text posts (self posts) meeting the minimum word count, plus link posts when enabled.
+// Posts below the minimum score or older than the maximum age are dropped when those filters are set.
+func (c *Client) FetchPosts(ctx context.Context, subredditURL string, topicName string, sort string, timeframe string) ([]Post, error) {
 	// Check context before starting
 	select {
 	case <-ctx.Done():
@@ -61,25 +161,24 @@ func (c *Client) FetchPosts(ctx context.Context, subredditURL string, topicName
 		return nil, err
 	}
 
-	// Rate limit (context-aware)
-	if err := c.waitForRateLimitWithContext(ctx); err != nil {
+	if err := ValidateListing(sort, timeframe); err != nil {
 		return nil, err
 	}
+	if sort == "" {
+		sort = SortHot
+	}
+	if sort == SortTop && timeframe == "" {
+		timeframe = "day"
+	}
 
 	// Build the JSON API URL
-	apiURL := fmt.Sprintf("https://www.reddit.com/r/%s.json?limit=25", subreddit)
-
-	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	apiURL := fmt.Sprintf("https://www.reddit.com/r/%s/%s.json?limit=25", subreddit, sort)
+	if sort == SortTop {
+		apiURL = fmt.Sprintf("https://www.reddit.com/r/%s/%s.json?t=%s&limit=25", subreddit, sort, timeframe)
 	}
 
-	// Reddit requires a User-Agent header
-	req.Header.Set("User-Agent", c.userAgent)
-
 	// Make the request
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.get(ctx, apiURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch subreddit %s: %w", subreddit, err)
 	}
@@ -111,54 +210,184 @@ func (c *Client) FetchPosts(ctx context.Context, subredditURL string, topicName
 		return nil, fmt.Errorf("failed to parse Reddit JSON: %w", err)
 	}
 
+	c.mu.Lock()
+	includeLinkPosts := c.includeLinkPosts
+	minWordCount := c.minWordCount
+	minScore := c.minScore
+	maxAge := c.maxAge
+	c.mu.Unlock()
+
 	// Filter and convert posts
 	var posts []Post
 	for _, child := range listing.Data.Children {
 		post := child.Data
+		createdAt := time.Unix(int64(post.CreatedUTC), 0)
 
-		// Only include self posts (text posts, not links/images)
-		if !post.IsSelf {
+		if minScore > 0 && post.Score < minScore {
 			continue
 		}
-
-		// Check word count
-		wordCount := countWords(post.Selftext)
-		if wordCount < c.minWordCount {
+		if maxAge > 0 && time.Since(createdAt) > maxAge {
 			continue
 		}
 
+		var linkURL string
+		if post.IsSelf {
+			// Check word count - link posts often have empty bodies, so only self posts are filtered
+			if minWordCount > 0 && countWords(post.Selftext) < minWordCount {
+				continue
+			}
+		} else {
+			// Link posts (links/images) are only included when enabled
+			if !includeLinkPosts {
+				continue
+			}
+			// Only article links are worth scraping; images, videos and crossposts keep just the title
+			if IsArticleURL(post.URL) {
+				linkURL = post.URL
+			}
+		}
+
 		// Add to results
 		posts = append(posts, Post{
 			Title:      post.Title,
 			Body:       post.Selftext,
 			Permalink:  post.Permalink,
+			URL:        linkURL,
 			Subreddit:  post.Subreddit,
 			Author:     post.Author,
 			Score:      post.Score,
-			CreatedUTC: time.Unix(int64(post.CreatedUTC), 0),
+			CreatedUTC: createdAt,
 		})
 	}
 
 	return posts, nil
 }
 
-// waitForRateLimitWithContext ensures we don't exceed Reddit's rate limit while respecting context
-func (c *Client) waitForRateLimitWithContext(ctx context.Context) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// FetchTopComments fetches up to n of the highest scored top-level comments on a post,
+// skipping stickied and deleted comments and those scoring below MinCommentScore.
+// permalink is the post's path as returned in Post.Permalink, e.g. "/r/golang/comments/abc123/title/"
+func (c *Client) FetchTopComments(ctx context.Context, permalink string, n int) ([]Comment, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	if !strings.HasPrefix(permalink, "/r/") {
+		return nil, fmt.Errorf("invalid Reddit permalink: %s", permalink)
+	}
+
+	apiURL := fmt.Sprintf("https://www.reddit.com%s.json?sort=top&depth=1&limit=%d", strings.TrimSuffix(permalink, "/"), n*3)
+	resp, err := c.get(ctx, apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return nil, fmt.Errorf("Reddit rate limit exceeded")
+		}
+		return nil, fmt.Errorf("Reddit API returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
 
-	elapsed := time.Since(c.lastRequest)
-	if elapsed < c.minInterval {
-		waitTime := c.minInterval - elapsed
+	// The response is two listings: the post itself, then its comments
+	var listings []redditCommentListing
+	if err := json.Unmarshal(body, &listings); err != nil {
+		return nil, fmt.Errorf("failed to parse Reddit JSON: %w", err)
+	}
+	if len(listings) < 2 {
+		return nil, nil
+	}
+
+	var comments []Comment
+	for _, child := range listings[1].Data.Children {
+		if child.Kind != "t1" {
+			continue // "more" placeholders
+		}
+		comment := child.Data
+		if comment.Stickied || comment.Score < MinCommentScore {
+			continue
+		}
+		if comment.Body == "" || comment.Body == "[deleted]" || comment.Body == "[removed]" {
+			continue
+		}
+		comments = append(comments, Comment{
+			Body:   comment.Body,
+			Author: comment.Author,
+			Score:  comment.Score,
+		})
+	}
+
+	sort.Slice(comments, func(i, j int) bool { return comments[i].Score > comments[j].Score })
+	if len(comments) > n {
+		comments = comments[:n]
+	}
+	return comments, nil
+}
+
+// get requests apiURL once the rate limiter allows it. A 429 that says when to come back
+// with a Retry-After header is retried once after waiting that long, up to maxRetryAfter;
+// if the retry is rate limited too, its 429 response is returned for the caller to report.
+func (c *Client) get(ctx context.Context, apiURL string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		// Rate limit (context-aware)
+		if err := c.waitForRateLimit(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		// Reddit requires a User-Agent header
+		req.Header.Set("User-Agent", c.userAgent)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusTooManyRequests || attempt > 0 {
+			return resp, nil
+		}
+		wait, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if !ok {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		timer := time.NewTimer(min(wait, maxRetryAfter))
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(waitTime):
-			// Rate limit wait completed
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
 		}
 	}
-	c.lastRequest = time.Now()
-	return nil
+}
+
+// retryAfter parses a Retry-After header, given either as seconds or as an HTTP date,
+// into how long to wait from now. ok is false if the header is missing or malformed.
+func retryAfter(header string, now time.Time) (wait time.Duration, ok bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// waitForRateLimit blocks until the token bucket allows another request or ctx is done.
+// Goroutines wait independently, so a cancelled caller doesn't hold up the others.
+func (c *Client) waitForRateLimit(ctx context.Context) error {
+	return c.limiter.Wait(ctx)
 }
 
 // extractSubreddit extracts the subreddit name from various URL formats
@@ -187,11 +416,71 @@ func extractSubreddit(url string) (string, error) {
 	return "", fmt.Errorf("could not extract subreddit from URL: %s", url)
 }
 
+// ListingOptions returns the sort and timeframe encoded in a source URL's query string,
+// e.g. reddit.com/r/golang?sort=top&t=week. Missing values are returned empty.
+func ListingOptions(sourceURL string) (sort, timeframe string) {
+	idx := strings.Index(sourceURL, "?")
+	if idx == -1 {
+		return "", ""
+	}
+	query, err := url.ParseQuery(sourceURL[idx+1:])
+	if err != nil {
+		return "", ""
+	}
+	return strings.ToLower(query.Get("sort")), strings.ToLower(query.Get("t"))
+}
+
+// ValidateListing checks a sort and timeframe pair; empty values are allowed and use defaults
+func ValidateListing(sort, timeframe string) error {
+	if sort != "" && !validSorts[sort] {
+		return fmt.Errorf("invalid Reddit sort %q (use hot, new, top or rising)", sort)
+	}
+	if timeframe != "" && !validTimeframes[timeframe] {
+		return fmt.Errorf("invalid Reddit timeframe %q (use hour, day, week, month, year or all)", timeframe)
+	}
+	return nil
+}
+
 // countWords counts words in a string
 func countWords(s string) int {
 	return len(strings.Fields(s))
 }
 
+// mediaHosts are link post domains that serve images or video rather than articles
+var mediaHosts = []string{
+	"reddit.com",
+	"redd.it",
+	"imgur.com",
+	"gfycat.com",
+	"youtube.com",
+	"youtu.be",
+	"streamable.com",
+}
+
+// mediaExtensions are file extensions of link post URLs that point straight at media
+var mediaExtensions = []string{".jpg", ".jpeg", ".png", ".gif", ".gifv", ".webp", ".mp4", ".webm", ".pdf"}
+
+// IsArticleURL reports whether a link post URL likely points at an article that can be scraped
+func IsArticleURL(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, media := range mediaHosts {
+		if host == media || strings.HasSuffix(host, "."+media) {
+			return false
+		}
+	}
+	path := strings.ToLower(u.Path)
+	for _, ext := range mediaExtensions {
+		if strings.HasSuffix(path, ext) {
+			return false
+		}
+	}
+	return true
+}
+
 // IsRedditURL checks if a URL is a Reddit subreddit URL
 func IsRedditURL(url string) bool {
 	return strings.Contains(url, "reddit.com/r/") || strings.HasPrefix(url, "r/")
@@ -212,8 +501,25 @@ type redditPost struct {
 	Selftext   string  `json:"selftext"`
 	IsSelf     bool    `json:"is_self"`
 	Permalink  string  `json:"permalink"`
+	URL        string  `json:"url"`
 	Subreddit  string  `json:"subreddit"`
 	Author     string  `json:"author"`
 	Score      int     `json:"score"`
 	CreatedUTC float64 `json:"created_utc"`
 }
+
+type redditCommentListing struct {
+	Data struct {
+		Children []struct {
+			Kind string        `json:"kind"`
+			Data redditComment `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+type redditComment struct {
+	Body     string `json:"body"`
+	Author   string `json:"author"`
+	Score    int    `json:"score"`
+	Stickied bool   `json:"stickied"`
+}
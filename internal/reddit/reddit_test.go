@@ -0,0 +1,223 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripFunc serves requests without touching the network
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// listingClient returns a client whose requests are answered with a listing of the given self posts,
+// keyed by title with the body as value
+func listingClient(t *testing.T, posts map[string]string) *Client {
+	t.Helper()
+	payload := listingJSON(t, posts)
+	c := New()
+	c.httpClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(req, http.StatusOK, nil, payload), nil
+	})}
+	return c
+}
+
+// jsonResponse builds a response with the given status, extra headers and body
+func jsonResponse(req *http.Request, status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", "application/json")
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+// listingJSON encodes a subreddit listing of the given self posts, keyed by title with the body as value
+func listingJSON(t *testing.T, posts map[string]string) string {
+	t.Helper()
+
+	var listing redditListing
+	for title, body := range posts {
+		child := struct {
+			Data redditPost `json:"data"`
+		}{Data: redditPost{
+			Title:      title,
+			Selftext:   body,
+			IsSelf:     true,
+			Subreddit:  "golang",
+			CreatedUTC: float64(time.Now().Unix()),
+		}}
+		listing.Data.Children = append(listing.Data.Children, child)
+	}
+	payload, err := json.Marshal(listing)
+	if err != nil {
+		t.Fatalf("marshal listing: %v", err)
+	}
+	return string(payload)
+}
+
+func TestFetchPostsMinWordCount(t *testing.T) {
+	posts := map[string]string{
+		"empty":       "",
+		"below min":   "one two three four",
+		"exactly min": "one two three four five",
+	}
+
+	tests := []struct {
+		name     string
+		minWords int
+		want     []string
+	}{
+		{"below min is dropped, min is kept", 5, []string{"exactly min"}},
+		{"zero disables the filter", 0, []string{"empty", "below min", "exactly min"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := listingClient(t, posts)
+			c.SetMinWordCount(tt.minWords)
+
+			got, err := c.FetchPosts(context.Background(), "https://www.reddit.com/r/golang", "", "", "")
+			if err != nil {
+				t.Fatalf("FetchPosts: %v", err)
+			}
+
+			kept := make(map[string]bool)
+			for _, p := range got {
+				kept[p.Title] = true
+			}
+			if len(kept) != len(tt.want) {
+				t.Errorf("kept %d posts, want %d: %v", len(kept), len(tt.want), kept)
+			}
+			for _, title := range tt.want {
+				if !kept[title] {
+					t.Errorf("post %q was filtered out, want it kept", title)
+				}
+			}
+		})
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"   ", 0},
+		{"one", 1},
+		{"one two\nthree\tfour", 4},
+		{"  leading and trailing  ", 3},
+	}
+	for _, tt := range tests {
+		if got := countWords(tt.in); got != tt.want {
+			t.Errorf("countWords(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFetchPostsRetryAfter(t *testing.T) {
+	listing := listingJSON(t, map[string]string{"post": "some words in the body"})
+	limited := func(req *http.Request, retryAfter string) *http.Response {
+		header := http.Header{}
+		if retryAfter != "" {
+			header.Set("Retry-After", retryAfter)
+		}
+		return jsonResponse(req, http.StatusTooManyRequests, header, `{"message": "Too Many Requests", "error": 429}`)
+	}
+
+	tests := []struct {
+		name         string
+		responses    []string // Retry-After of each 429 in turn, then a 200 once they run out; "" sends no header
+		wantRequests int
+		wantErr      bool
+	}{
+		{"429 then 200 succeeds", []string{"0"}, 2, false},
+		{"429 twice returns the rate limit error", []string{"0", "0"}, 2, true},
+		{"429 without Retry-After is not retried", []string{""}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			c := New()
+			c.SetMinWordCount(0)
+			c.httpClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				requests++
+				if requests <= len(tt.responses) {
+					return limited(req, tt.responses[requests-1]), nil
+				}
+				return jsonResponse(req, http.StatusOK, nil, listing), nil
+			})}
+
+			posts, err := c.FetchPosts(context.Background(), "r/golang", "", "", "")
+			if requests != tt.wantRequests {
+				t.Errorf("made %d requests, want %d", requests, tt.wantRequests)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("FetchPosts succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchPosts: %v", err)
+			}
+			if len(posts) != 1 {
+				t.Errorf("got %d posts, want 1", len(posts))
+			}
+		})
+	}
+}
+
+func TestFetchPostsRetryAfterContextDone(t *testing.T) {
+	c := New()
+	c.httpClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(req, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"3600"}}, `{}`), nil
+	})}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.FetchPosts(ctx, "r/golang", "", "", "")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("FetchPosts error = %v, want the context deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("FetchPosts waited %v, want it to stop at the context deadline", elapsed)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"soon", 0, false},
+		{"0", 0, true},
+		{"7", 7 * time.Second, true},
+		{" 12 ", 12 * time.Second, true},
+		{"-3", 0, true},
+		{"Fri, 16 Oct 2026 12:00:30 GMT", 30 * time.Second, true},
+		{"Fri, 16 Oct 2026 11:59:00 GMT", 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.header, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.header, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
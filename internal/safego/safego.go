@@ -2,7 +2,7 @@ package safego
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"runtime/debug"
 )
 
@@ -12,7 +12,7 @@ func Go(name string, fn func()) {
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
-				log.Printf("[PANIC RECOVERED] in %s: %v\n%s", name, r, debug.Stack())
+				slog.Error("Panic recovered", "goroutine", name, "panic", r, "stack", string(debug.Stack()))
 			}
 		}()
 		fn()
@@ -25,7 +25,7 @@ func GoWithContext(ctx context.Context, name string, fn func(context.Context)) {
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
-				log.Printf("[PANIC RECOVERED] in %s: %v\n%s", name, r, debug.Stack())
+				slog.Error("Panic recovered", "goroutine", name, "panic", r, "stack", string(debug.Stack()))
 			}
 		}()
 		fn(ctx)
@@ -37,7 +37,7 @@ func GoWithContext(ctx context.Context, name string, fn func(context.Context)) {
 // Example: defer safego.Recover("functionName")
 func Recover(name string) {
 	if r := recover(); r != nil {
-		log.Printf("[PANIC RECOVERED] in %s: %v\n%s", name, r, debug.Stack())
+		slog.Error("Panic recovered", "goroutine", name, "panic", r, "stack", string(debug.Stack()))
 	}
 }
 
@@ -45,7 +45,7 @@ func Recover(name string) {
 // logs them, and calls a callback function. Useful for cleanup or retry logic.
 func RecoverWithCallback(name string, callback func(panicValue interface{})) {
 	if r := recover(); r != nil {
-		log.Printf("[PANIC RECOVERED] in %s: %v\n%s", name, r, debug.Stack())
+		slog.Error("Panic recovered", "goroutine", name, "panic", r, "stack", string(debug.Stack()))
 		if callback != nil {
 			callback(r)
 		}
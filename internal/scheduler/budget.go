@@ -0,0 +1,172 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/thinkscotty/maggpi_go/internal/gemini"
+	"github.com/thinkscotty/maggpi_go/internal/llm"
+	"github.com/thinkscotty/maggpi_go/internal/models"
+)
+
+// ErrBudgetExhausted is returned when the daily AI request or token budget has been used up
+var ErrBudgetExhausted = errors.New("daily AI budget exhausted")
+
+// DefaultUsageDays is how many days of usage GET /api/usage returns by default
+const DefaultUsageDays = 30
+
+// usageDay returns the usage table key for the day containing t
+func usageDay(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// usageTally adds up the AI usage of a single refresh
+type usageTally struct {
+	mu    sync.Mutex
+	usage gemini.Usage
+	cost  float64
+	model string // model behind the latest successful call
+}
+
+// add records one API call in the tally
+func (t *usageTally) add(usage gemini.Usage, cost float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.usage.PromptTokens += usage.PromptTokens
+	t.usage.OutputTokens += usage.OutputTokens
+	t.cost += cost
+	if usage.Model != "" {
+		t.model = usage.Model
+	}
+}
+
+// fill copies the tally into a refresh history entry
+func (t *usageTally) fill(h *models.RefreshHistory) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h.PromptTokens = t.usage.PromptTokens
+	h.OutputTokens = t.usage.OutputTokens
+	h.Cost = t.cost
+	h.Model = t.model
+}
+
+// tokenCost estimates the cost in USD of an API call from the configured per-million token prices
+func tokenCost(settings *models.Settings, usage gemini.Usage) float64 {
+	return (float64(usage.PromptTokens)*settings.PromptTokenPrice + float64(usage.OutputTokens)*settings.OutputTokenPrice) / 1e6
+}
+
+// newAIClient creates the configured AI client with its API calls counted towards the daily usage
+// and the topic's usage, and its prompts built from the configured content cap and summary length.
+// A topicID of 0 counts calls that aren't for any one topic towards the daily usage only. If
+// tally is non-nil, each call is also added to it.
+func (s *Scheduler) newAIClient(settings *models.Settings, topicID int64, tally *usageTally) (llm.Summarizer, error) {
+	client, err := llm.New(settings)
+	if err != nil {
+		return nil, err
+	}
+	client.SetUsageFunc(func(usage gemini.Usage) {
+		day := usageDay(time.Now())
+		cost := tokenCost(settings, usage)
+		if err := s.db.RecordAPIUsage(day, usage.Total()); err != nil {
+			slog.Error("Error recording API usage", "topic_id", topicID, "error", err)
+		}
+		if topicID != 0 {
+			if err := s.db.RecordTopicUsage(day, topicID, usage.PromptTokens, usage.OutputTokens, cost); err != nil {
+				slog.Error("Error recording topic API usage", "topic_id", topicID, "error", err)
+			}
+		}
+		if tally != nil {
+			tally.add(usage, cost)
+		}
+		s.trackFallback(usage)
+	})
+	client.SetMaxContentLength(settings.MaxPromptChars)
+	client.SetChunkThreshold(settings.ChunkThresholdChars)
+	client.SetSummaryLength(settings.SummaryMinWords, settings.SummaryMaxWords)
+	client.SetSourceCount(settings.MinSources, settings.MaxSources)
+	return client, nil
+}
+
+// checkBudget returns an error wrapping ErrBudgetExhausted if today's usage has reached
+// either daily budget. A budget of 0 is unlimited.
+func (s *Scheduler) checkBudget(settings *models.Settings) error {
+	if settings.DailyRequestBudget <= 0 && settings.DailyTokenBudget <= 0 {
+		return nil
+	}
+
+	usage, err := s.db.GetAPIUsage(usageDay(time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to get API usage: %w", err)
+	}
+	if settings.DailyRequestBudget > 0 && usage.Requests >= settings.DailyRequestBudget {
+		return fmt.Errorf("%w: %d of %d requests used today", ErrBudgetExhausted, usage.Requests, settings.DailyRequestBudget)
+	}
+	if settings.DailyTokenBudget > 0 && usage.Tokens >= settings.DailyTokenBudget {
+		return fmt.Errorf("%w: %d of %d tokens used today", ErrBudgetExhausted, usage.Tokens, settings.DailyTokenBudget)
+	}
+	return nil
+}
+
+// deferForBudget marks a topic as deferred until the budget resets at midnight
+func (s *Scheduler) deferForBudget(topicID int64, reason error) {
+	now := time.Now()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+
+	status := s.currentStatus(topicID)
+	// Spread deferred topics over the first minutes of the day instead of all at midnight
+	status.NextRefresh = tomorrow.Add(rand.N(5 * time.Minute))
+	status.Status = "deferred_budget"
+	status.ErrorMessage = reason.Error()
+	status.ProgressStage = ""
+	status.ProgressPercent = 0
+	s.updateStatus(status)
+}
+
+// Usage returns today's AI usage, the remaining budget, and daily totals overall and
+// per topic for the last days days
+func (s *Scheduler) Usage(days int) (*models.UsageResponse, error) {
+	settings, err := s.db.GetSettings()
+	if err != nil || settings == nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+
+	today, err := s.db.GetAPIUsage(usageDay(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.db.GetAPIUsageHistory(days)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []models.APIUsage{}
+	}
+	topics, err := s.db.GetTopicUsage(usageDay(time.Now().AddDate(0, 0, 1-days)))
+	if err != nil {
+		return nil, err
+	}
+	if topics == nil {
+		topics = []models.TopicUsage{}
+	}
+
+	resp := &models.UsageResponse{
+		Today:         today,
+		RequestBudget: settings.DailyRequestBudget,
+		TokenBudget:   settings.DailyTokenBudget,
+		History:       history,
+		Topics:        topics,
+	}
+	if settings.DailyRequestBudget > 0 {
+		remaining := max(settings.DailyRequestBudget-today.Requests, 0)
+		resp.RequestsRemaining = &remaining
+	}
+	if settings.DailyTokenBudget > 0 {
+		remaining := max(settings.DailyTokenBudget-today.Tokens, 0)
+		resp.TokensRemaining = &remaining
+	}
+	return resp, nil
+}
@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/thinkscotty/maggpi_go/internal/embeddings"
+	"github.com/thinkscotty/maggpi_go/internal/gemini"
+	"github.com/thinkscotty/maggpi_go/internal/logging"
+	"github.com/thinkscotty/maggpi_go/internal/models"
+)
+
+const (
+	// dedupWindow is how many of a topic's newest stories new stories are compared against
+	dedupWindow = 50
+	// embedTimeout bounds the embedding call made for each refresh
+	embedTimeout = 30 * time.Second
+)
+
+// semanticDedup spots new stories that cover the same event as a recent story in
+// different words, by comparing their embeddings
+type semanticDedup struct {
+	logger    *slog.Logger
+	threshold float64
+	vectors   [][]float32 // embeddings of the new stories, by index
+	known     []knownStory
+}
+
+// knownStory is a stored story to compare new ones against
+type knownStory struct {
+	title  string
+	vector []float32
+}
+
+// newSemanticDedup embeds the new stories and loads the topic's recent embeddings. It
+// returns nil, so nothing is skipped, if the feature is off or the embeddings can't be
+// fetched; a refresh never fails over it. ctx is the refresh's context, whose logger is used.
+func (s *Scheduler) newSemanticDedup(ctx context.Context, settings *models.Settings, topicID int64, stories []gemini.SummarizedStory) *semanticDedup {
+	if !settings.SemanticDedup || settings.GeminiAPIKey == "" || len(stories) == 0 {
+		return nil
+	}
+	logger := logging.FromContext(ctx)
+
+	client, err := embeddings.New(settings.GeminiAPIKey)
+	if err != nil {
+		logger.Warn("Semantic deduplication unavailable", "topic_id", topicID, "error", err)
+		return nil
+	}
+
+	texts := make([]string, len(stories))
+	for i, story := range stories {
+		texts[i] = embeddings.StoryText(story.Title, story.Summary)
+	}
+	embedCtx, cancel := context.WithTimeout(ctx, embedTimeout)
+	defer cancel()
+	vectors, err := client.Embed(embedCtx, texts)
+	// The call counts against the daily request budget like any other
+	if err := s.db.RecordAPIUsage(usageDay(time.Now()), 0); err != nil {
+		logger.Error("Error recording API usage", "topic_id", topicID, "error", err)
+	}
+	if err != nil {
+		logger.Warn("Skipping semantic deduplication", "topic_id", topicID, "error", err)
+		return nil
+	}
+
+	stored, err := s.db.GetRecentStoryEmbeddings(topicID, dedupWindow)
+	if err != nil {
+		logger.Warn("Skipping semantic deduplication", "topic_id", topicID, "error", err)
+		return nil
+	}
+	d := &semanticDedup{logger: logger, threshold: settings.SemanticDedupThreshold, vectors: vectors}
+	for _, e := range stored {
+		if vector := embeddings.Decode(e.Embedding); vector != nil {
+			d.known = append(d.known, knownStory{title: e.Title, vector: vector})
+		}
+	}
+	return d
+}
+
+// duplicateOf returns the title of a known story the i'th new story duplicates and how
+// similar they are, or false if it duplicates none
+func (d *semanticDedup) duplicateOf(i int) (string, float64, bool) {
+	if d == nil {
+		return "", 0, false
+	}
+	for _, k := range d.known {
+		if similarity := embeddings.Similarity(d.vectors[i], k.vector); similarity >= d.threshold {
+			return k.title, similarity, true
+		}
+	}
+	return "", 0, false
+}
+
+// storeEmbedding saves the i'th new story's embedding with it and adds it to the known stories,
+// so later stories from the same refresh are compared against it too
+func (s *Scheduler) storeEmbedding(d *semanticDedup, i int, story *models.Story) {
+	if d == nil {
+		return
+	}
+	if err := s.db.SetStoryEmbedding(story.ID, embeddings.Encode(d.vectors[i])); err != nil {
+		d.logger.Error("Error storing story embedding", "topic_id", story.TopicID, "error", err)
+	}
+	d.known = append(d.known, knownStory{title: story.Title, vector: d.vectors[i]})
+}
@@ -0,0 +1,167 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/thinkscotty/maggpi_go/internal/models"
+)
+
+// Job triggers
+const (
+	TriggerScheduled = "scheduled"
+	TriggerManual    = "manual"
+	TriggerCreated   = "created" // first refresh of a newly created topic
+)
+
+// maxRecentJobs is how many finished jobs are kept for GET /api/jobs
+const maxRecentJobs = 50
+
+// jobQueue is a FIFO of topic refresh jobs. A topic has at most one job queued or
+// running at a time; enqueueing it again returns the existing job.
+type jobQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	nextID  int64
+	pending []*models.Job
+	active  map[int64]*models.Job // queued or running jobs by topic ID
+	recent  []*models.Job         // finished jobs, oldest first
+	running int
+	closed  bool
+}
+
+func newJobQueue() *jobQueue {
+	q := &jobQueue{active: make(map[int64]*models.Job)}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// enqueue adds a refresh job for a topic. If the topic already has a job queued
+// or running, that job is returned and added is false.
+func (q *jobQueue) enqueue(topicID int64, trigger string) (job models.Job, added bool) {
+	return q.add(topicID, trigger, false)
+}
+
+// enqueueFront is like enqueue but puts a new job ahead of all pending jobs
+func (q *jobQueue) enqueueFront(topicID int64, trigger string) (job models.Job, added bool) {
+	return q.add(topicID, trigger, true)
+}
+
+func (q *jobQueue) add(topicID int64, trigger string, front bool) (job models.Job, added bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if existing, ok := q.active[topicID]; ok {
+		return *existing, false
+	}
+
+	q.nextID++
+	j := &models.Job{
+		ID:       q.nextID,
+		TopicID:  topicID,
+		Trigger:  trigger,
+		Status:   models.JobQueued,
+		QueuedAt: time.Now(),
+	}
+	if front {
+		q.pending = append([]*models.Job{j}, q.pending...)
+	} else {
+		q.pending = append(q.pending, j)
+	}
+	q.active[topicID] = j
+	q.cond.Broadcast()
+	return *j, true
+}
+
+// next blocks until a job is pending and fewer than limit() jobs are running, then
+// marks it running and returns it. It returns false once the queue is closed.
+func (q *jobQueue) next(limit func() int) (*models.Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && (len(q.pending) == 0 || q.running >= limit()) {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return nil, false
+	}
+
+	j := q.pending[0]
+	q.pending = q.pending[1:]
+	now := time.Now()
+	j.Status = models.JobRunning
+	j.StartedAt = &now
+	q.running++
+	return j, true
+}
+
+// finish records the outcome of a running job. The worker slot stays taken until release.
+func (q *jobQueue) finish(j *models.Job, status string, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	j.Status = status
+	j.FinishedAt = &now
+	if j.StartedAt != nil {
+		j.DurationSeconds = now.Sub(*j.StartedAt).Seconds()
+	}
+	if err != nil {
+		j.Error = err.Error()
+	}
+
+	delete(q.active, j.TopicID)
+	q.recent = append(q.recent, j)
+	if len(q.recent) > maxRecentJobs {
+		q.recent = q.recent[len(q.recent)-maxRecentJobs:]
+	}
+}
+
+// release frees the worker slot taken by next
+func (q *jobQueue) release() {
+	q.mu.Lock()
+	q.running--
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// wake re-checks waiting dispatchers, e.g. after the worker limit changed
+func (q *jobQueue) wake() {
+	q.cond.Broadcast()
+}
+
+// close stops handing out jobs and returns the jobs that were still pending
+func (q *jobQueue) close() []models.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	dropped := make([]models.Job, 0, len(q.pending))
+	for _, j := range q.pending {
+		dropped = append(dropped, *j)
+		delete(q.active, j.TopicID)
+	}
+	q.pending = nil
+	q.cond.Broadcast()
+	return dropped
+}
+
+// list returns running and queued jobs followed by recently finished jobs, newest first
+func (q *jobQueue) list() []models.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]models.Job, 0, len(q.active)+len(q.recent))
+	for _, j := range q.active {
+		if j.Status == models.JobRunning {
+			jobs = append(jobs, *j)
+		}
+	}
+	for _, j := range q.pending {
+		jobs = append(jobs, *j)
+	}
+	for i := len(q.recent) - 1; i >= 0; i-- {
+		jobs = append(jobs, *q.recent[i])
+	}
+	return jobs
+}
@@ -0,0 +1,189 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/thinkscotty/maggpi_go/internal/gemini"
+	"github.com/thinkscotty/maggpi_go/internal/llm"
+	"github.com/thinkscotty/maggpi_go/internal/models"
+	"github.com/thinkscotty/maggpi_go/internal/reddit"
+	"github.com/thinkscotty/maggpi_go/internal/scraper"
+)
+
+// PreviewRefresh runs a topic's scrape and summarize pipeline and returns what a refresh
+// would produce, without storing stories or touching the refresh status or source
+// failure tracking. Useful for tuning prompts. ctx bounds the whole run. If progress is
+// non-nil, the summary is streamed to it as it's generated.
+func (s *Scheduler) PreviewRefresh(ctx context.Context, topicID int64, progress gemini.ProgressFunc) (*models.RefreshPreview, error) {
+	s.maintenanceMu.RLock()
+	defer s.maintenanceMu.RUnlock()
+
+	start := time.Now()
+
+	topic, err := s.db.GetTopic(topicID)
+	if err != nil || topic == nil {
+		return nil, fmt.Errorf("topic not found: %d", topicID)
+	}
+
+	settings, err := s.db.GetSettings()
+	if err != nil || settings == nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	if err := llm.CheckConfigured(settings); err != nil {
+		return nil, err
+	}
+	if err := s.checkBudget(settings); err != nil {
+		return nil, err
+	}
+
+	sources, err := s.db.GetActiveSourcesForTopic(topicID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sources: %w", err)
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no active sources for topic")
+	}
+
+	preview := &models.RefreshPreview{TopicID: topicID, Stories: []models.PreviewStory{}}
+
+	// Scrape
+	s.scraper.ApplySettings(settings)
+	var scrapedContent []gemini.ScrapedContent
+	for _, result := range s.scraper.ScrapeSources(ctx, sources, nil) {
+		ps := models.PreviewSource{
+			SourceID: result.Source.ID,
+			URL:      result.Source.URL,
+			Name:     result.Source.Name,
+		}
+		if result.Error != nil {
+			ps.Error = result.Error.Error()
+		} else {
+			ps.Bytes = len(result.Content.Content)
+			scrapedContent = append(scrapedContent, *result.Content)
+		}
+		preview.Sources = append(preview.Sources, ps)
+	}
+	preview.ScrapeSeconds = time.Since(start).Seconds()
+
+	if len(scrapedContent) == 0 {
+		preview.TotalSeconds = preview.ScrapeSeconds
+		return preview, fmt.Errorf("failed to scrape any content from active sources")
+	}
+
+	// Summarize
+	summarizeStart := time.Now()
+	aiClient, err := s.newAIClient(settings, topicID, nil)
+	if err != nil {
+		return preview, fmt.Errorf("failed to create AI client: %w", err)
+	}
+	defer aiClient.Close()
+	if progress != nil {
+		aiClient.SetProgressFunc(progress)
+	}
+	setSummaryLength(aiClient, topic, settings)
+
+	aiCtx, cancel := context.WithTimeout(ctx, llm.Timeout(settings))
+	defer cancel()
+	stories, err := aiClient.SummarizeContent(aiCtx, topic.Name, scrapedContent, summarizingPrompt(topic, settings), settings.StoriesPerTopic)
+	preview.SummarizeSeconds = time.Since(summarizeStart).Seconds()
+	preview.TotalSeconds = time.Since(start).Seconds()
+	if err != nil {
+		return preview, fmt.Errorf("failed to summarize content: %w", err)
+	}
+
+	for _, story := range stories {
+		preview.Stories = append(preview.Stories, models.PreviewStory{
+			Title:       story.Title,
+			Summary:     story.Summary,
+			SourceURL:   story.SourceURL,
+			SourceTitle: story.SourceTitle,
+			Tags:        gemini.NormalizeTags(story.Tags),
+			Importance:  gemini.ClampImportance(story.Importance),
+			Citations:   gemini.Citations(story, scrapedContent),
+		})
+	}
+	return preview, nil
+}
+
+// PreviewDiscovery asks the AI for sources for a topic and checks each one the way
+// discovery does, but stores nothing, so the suggestions can be vetted before any are
+// added. ctx bounds the whole run.
+func (s *Scheduler) PreviewDiscovery(ctx context.Context, topicID int64) ([]models.SourceCandidate, error) {
+	topic, err := s.db.GetTopic(topicID)
+	if err != nil || topic == nil {
+		return nil, fmt.Errorf("topic not found: %d", topicID)
+	}
+
+	settings, err := s.db.GetSettings()
+	if err != nil || settings == nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+
+	sources, err := s.suggestSources(ctx, topic, settings)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.db.GetSourcesForTopic(topicID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing sources: %w", err)
+	}
+
+	candidates := []models.SourceCandidate{}
+	// Reachability is checked whatever the setting says, since vetting is the point here
+	for i, check := range s.checkSuggestedSources(ctx, sources, true) {
+		candidate := models.SourceCandidate{
+			URL:         check.url,
+			Name:        sources[i].Name,
+			Description: sources[i].Description,
+			Reachable:   check.err == nil,
+		}
+		if check.err != nil {
+			candidate.Error = check.err.Error()
+		}
+		for _, source := range existing {
+			if scraper.SameSource(source.URL, check.url) {
+				candidate.ExistingSourceID = source.ID
+				break
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// sourcePreviewLength is how much extracted content a source preview shows
+const sourcePreviewLength = 2000
+
+// PreviewSource scrapes a URL the way a refresh would scrape a source and returns what
+// was extracted, without storing anything. A failed scrape is reported in the preview's
+// Error rather than returned, so the reason a source would be skipped can be shown.
+func (s *Scheduler) PreviewSource(ctx context.Context, urlStr string) (*models.SourcePreview, error) {
+	settings, err := s.db.GetSettings()
+	if err != nil || settings == nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+
+	start := time.Now()
+	preview := &models.SourcePreview{URL: urlStr, Reddit: reddit.IsRedditURL(urlStr)}
+
+	s.scraper.ApplySettings(settings)
+	content, err := s.scraper.ScrapeSource(ctx, models.Source{URL: urlStr})
+	preview.Seconds = time.Since(start).Seconds()
+	if err != nil {
+		preview.Error = err.Error()
+		return preview, nil
+	}
+
+	preview.Title = content.SourceName
+	preview.Feed = content.Feed
+	preview.Bytes = len(content.Content)
+	preview.Content = content.Content
+	if utf8.RuneCountInString(preview.Content) > sourcePreviewLength {
+		preview.Content = string([]rune(preview.Content)[:sourcePreviewLength])
+		preview.Truncated = true
+	}
+	return preview, nil
+}
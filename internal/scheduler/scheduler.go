@@ -2,15 +2,27 @@ package scheduler
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math/rand/v2"
+	"net/url"
 	"runtime/debug"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/robfig/cron/v3"
 	"github.com/thinkscotty/maggpi_go/internal/database"
+	"github.com/thinkscotty/maggpi_go/internal/events"
 	"github.com/thinkscotty/maggpi_go/internal/gemini"
+	"github.com/thinkscotty/maggpi_go/internal/llm"
+	"github.com/thinkscotty/maggpi_go/internal/logging"
 	"github.com/thinkscotty/maggpi_go/internal/models"
+	"github.com/thinkscotty/maggpi_go/internal/safego"
 	"github.com/thinkscotty/maggpi_go/internal/scraper"
 )
 
@@ -18,20 +30,68 @@ import (
 type Scheduler struct {
 	db       *database.DB
 	scraper  *scraper.Scraper
+	events   *events.Broker
+	jobs     *jobQueue
 	interval time.Duration
+	workers  int           // max refresh jobs running at once
+	stagger  time.Duration // pause a worker slot takes after each job
 	stopCh   chan struct{}
+	ctx      context.Context // cancelled by Stop so in-flight scrapes and AI calls abort
+	cancel   context.CancelFunc
+	wakeCh   chan struct{} // makes the run loop check for due topics right away
 	wg       sync.WaitGroup
 	mu       sync.Mutex
 	running  bool
+	quiet    bool // whether the last check fell inside quiet hours
+	health   models.SchedulerHealth
+	stats    models.SchedulerStats // refresh counters since startup
+	restarts []time.Time           // restart times within the last hour, for capping restarts
+
+	inFlightMu sync.Mutex
+	inFlight   map[int64]struct{} // topics currently being refreshed
+
+	// maintenanceMu keeps database maintenance from running during a refresh:
+	// refreshes hold a read lock, maintenance takes the write lock
+	maintenanceMu   sync.RWMutex
+	lastMaintenance time.Time
 }
 
+// maintenanceInterval is how often the database is checkpointed and vacuumed automatically
+const maintenanceInterval = 24 * time.Hour
+
+// scrapeTimeout bounds the scraping stage of a refresh; slower sources are left out
+const scrapeTimeout = 5 * time.Minute
+
+// ErrAlreadyRefreshing is returned when a refresh is requested for a topic that is already being refreshed
+var ErrAlreadyRefreshing = errors.New("topic is already being refreshed")
+
+// ErrTopicDisabled is returned when a refresh is requested for a disabled topic
+var ErrTopicDisabled = errors.New("topic is disabled")
+
+const (
+	// restartDelay is how long the supervisor waits before restarting a crashed loop
+	restartDelay = 30 * time.Second
+	// maxRestartsPerHour caps restarts so a persistent bug doesn't spin forever
+	maxRestartsPerHour = 5
+)
+
 // New creates a new Scheduler
 func New(db *database.DB) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Scheduler{
 		db:       db,
 		scraper:  scraper.New(),
+		events:   events.NewBroker(),
+		jobs:     newJobQueue(),
 		interval: 120 * time.Minute, // Default, will be overwritten from settings
+		workers:  1,
+		stagger:  30 * time.Second,
 		stopCh:   make(chan struct{}),
+		wakeCh:   make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+		inFlight: make(map[int64]struct{}),
+		stats:    models.SchedulerStats{StartedAt: time.Now()},
 	}
 }
 
@@ -45,51 +105,298 @@ func (s *Scheduler) Start() {
 	s.running = true
 	s.mu.Unlock()
 
-	s.wg.Add(1)
-	go s.run()
-	log.Println("Scheduler started")
+	if settings, err := s.db.GetSettings(); err == nil && settings != nil {
+		// Set the stored interval directly; there is nothing to reschedule at startup
+		s.mu.Lock()
+		s.interval = time.Duration(settings.RefreshIntervalMinutes) * time.Minute
+		s.mu.Unlock()
+		s.UpdateConcurrency(settings.MaxConcurrentRefreshes, settings.RefreshStaggerSeconds)
+	}
+
+	s.wg.Add(2)
+	go s.supervise()
+	go s.dispatchJobs()
+	slog.Info("Scheduler started")
 }
 
-// Stop halts the scheduler
+// Stop halts the scheduler, cancelling running refreshes and discoveries and waiting for them to return
 func (s *Scheduler) Stop() {
+	s.Shutdown(context.Background())
+}
+
+// Shutdown stops the scheduler like Stop, cancelling in-flight scrapes and AI calls, but
+// waits for running refreshes to wind down only until ctx is done. It returns ctx's error
+// if they haven't by then; they are abandoned and exit on their own once they notice.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
 	s.mu.Lock()
 	if !s.running {
 		s.mu.Unlock()
-		return
+		return nil
 	}
 	s.running = false
 	s.mu.Unlock()
 
 	close(s.stopCh)
-	s.wg.Wait()
-	log.Println("Scheduler stopped")
+	s.cancel()
+	if dropped := s.jobs.close(); len(dropped) > 0 {
+		slog.Info("Dropped queued refresh jobs", "count", len(dropped))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		slog.Info("Scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// goBackground runs fn in a goroutine with panic recovery, counted so Stop waits for it.
+// Nothing is started once the scheduler has stopped.
+func (s *Scheduler) goBackground(name string, fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.running {
+		slog.Warn("Scheduler is stopped, not starting background task", "task", name)
+		return
+	}
+	s.wg.Add(1)
+	safego.Go(name, func() {
+		defer s.wg.Done()
+		fn()
+	})
+}
+
+// Subscribe registers for refresh status updates published by the scheduler.
+// Call the returned function to unsubscribe.
+func (s *Scheduler) Subscribe() (<-chan models.RefreshStatus, func()) {
+	return s.events.Subscribe()
+}
+
+// updateStatus persists a refresh status and publishes it to subscribers
+func (s *Scheduler) updateStatus(status *models.RefreshStatus) error {
+	if err := s.db.UpdateRefreshStatus(status); err != nil {
+		slog.Error("Error updating refresh status", "topic_id", status.TopicID, "error", err)
+		return err
+	}
+	s.events.Publish(*status)
+	return nil
+}
+
+// reportProgress records the stage of a running refresh and publishes it to subscribers
+func (s *Scheduler) reportProgress(status *models.RefreshStatus, stage string, percent int) {
+	status.ProgressStage = stage
+	status.ProgressPercent = percent
+	if err := s.db.UpdateRefreshProgress(status.TopicID, stage, percent); err != nil {
+		slog.Error("Error updating refresh progress", "topic_id", status.TopicID, "error", err)
+		return
+	}
+	s.events.Publish(*status)
 }
 
-// UpdateInterval updates the refresh interval
+// summarizeProgress returns a progress function that reports a streamed summary's growth
+// between the summarizing and storing stages, at most once a second. The percentage is
+// estimated from the longest response the prompt asks for; with settings that make no
+// estimate possible, nil is returned and no progress is reported.
+func (s *Scheduler) summarizeProgress(status *models.RefreshStatus, settings *models.Settings) gemini.ProgressFunc {
+	// Roughly 6 characters per word, plus the JSON around each story
+	expected := settings.StoriesPerTopic * (settings.SummaryMaxWords*6 + 300)
+	if expected <= 0 {
+		return nil
+	}
+	var last time.Time
+	return func(received int, _ string) {
+		if time.Since(last) < time.Second {
+			return
+		}
+		last = time.Now()
+		s.reportProgress(status, fmt.Sprintf("summarizing (%d characters received)", received), 65+24*min(received, expected)/expected)
+	}
+}
+
+// UpdateInterval updates the refresh interval. If it changed, pending interval-based
+// refreshes are rescheduled from their last refresh and the run loop is woken so
+// topics that are now due refresh right away.
 func (s *Scheduler) UpdateInterval(minutes int) {
+	interval := time.Duration(minutes) * time.Minute
+	s.mu.Lock()
+	if interval == s.interval {
+		s.mu.Unlock()
+		return
+	}
+	s.interval = interval
+	s.mu.Unlock()
+	slog.Info("Scheduler interval updated", "minutes", minutes)
+
+	s.rescheduleIntervalTopics()
+	s.wake()
+}
+
+// rescheduleIntervalTopics recomputes the next refresh of every topic that follows the
+// global interval as its last refresh plus the current interval, but no earlier than now
+func (s *Scheduler) rescheduleIntervalTopics() {
+	topics, err := s.db.GetTopics()
+	if err != nil {
+		slog.Error("Error getting topics for rescheduling", "error", err)
+		return
+	}
+
+	now := time.Now()
+	for _, topic := range topics {
+		if topic.CronSchedule != "" || !topic.AutoRefresh || !topic.Enabled {
+			continue
+		}
+		status, err := s.db.GetRefreshStatus(topic.ID)
+		if err != nil || status == nil || status.LastRefresh.IsZero() {
+			continue
+		}
+		// Busy topics schedule their next refresh when they finish; deferred ones wait for the budget
+		if status.Status != "completed" && status.Status != "failed" && status.Status != "pending" {
+			continue
+		}
+
+		next := s.nextRefreshTime(&topic, status.LastRefresh, status.BackoffMultiplier)
+		if next.Before(now) {
+			next = now
+		}
+		status.NextRefresh = next
+		s.updateStatus(status)
+	}
+}
+
+// wake makes the run loop check for due topics without waiting for its next tick
+func (s *Scheduler) wake() {
+	select {
+	case s.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// UpdateConcurrency updates how many refresh jobs may run at once and the
+// pause each worker takes between jobs
+func (s *Scheduler) UpdateConcurrency(workers, staggerSeconds int) {
+	if workers < 1 {
+		workers = 1
+	}
+	if staggerSeconds < 0 {
+		staggerSeconds = 0
+	}
+
+	s.mu.Lock()
+	changed := workers != s.workers
+	s.workers = workers
+	s.stagger = time.Duration(staggerSeconds) * time.Second
+	s.mu.Unlock()
+
+	if changed {
+		slog.Info("Scheduler concurrency updated", "workers", workers)
+		s.jobs.wake()
+	}
+}
+
+// Health returns a snapshot of the scheduler loop's health
+func (s *Scheduler) Health() models.SchedulerHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.health
+}
+
+// trackFallback notes in the scheduler health when an AI call was answered by the
+// fallback model, and clears the note once the primary model answers again
+func (s *Scheduler) trackFallback(usage gemini.Usage) {
+	if usage.Model == "" {
+		return
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.interval = time.Duration(minutes) * time.Minute
-	log.Printf("Scheduler interval updated to %d minutes", minutes)
+	switch {
+	case usage.Fallback && s.health.FallbackModel == "":
+		now := time.Now()
+		s.health.FallbackModel = usage.Model
+		s.health.FallbackSince = &now
+		slog.Warn("Primary AI model is failing, using fallback model", "model", usage.Model)
+	case usage.Fallback:
+		s.health.FallbackModel = usage.Model
+	case s.health.FallbackModel != "":
+		s.health.FallbackModel = ""
+		s.health.FallbackSince = nil
+		slog.Info("Primary AI model has recovered", "model", usage.Model)
+	}
 }
 
-// run is the main scheduler loop
-func (s *Scheduler) run() {
+// supervise runs the scheduler loop and restarts it after a short delay if it panics,
+// giving up once it has restarted maxRestartsPerHour times within the last hour
+func (s *Scheduler) supervise() {
 	defer s.wg.Done()
 
-	// Recover from panics - restart the loop if it crashes
+	s.mu.Lock()
+	s.health.Running = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.health.Running = false
+		s.mu.Unlock()
+	}()
+
+	for {
+		if !s.run() {
+			return // stopped normally
+		}
+
+		s.mu.Lock()
+		now := time.Now()
+		recent := s.restarts[:0]
+		for _, t := range s.restarts {
+			if now.Sub(t) < time.Hour {
+				recent = append(recent, t)
+			}
+		}
+		s.restarts = recent
+		if len(s.restarts) >= maxRestartsPerHour {
+			s.mu.Unlock()
+			slog.Error("Scheduler loop crashed too often in the last hour, not restarting", "crashes", len(recent))
+			return
+		}
+		s.restarts = append(s.restarts, now)
+		s.health.Restarts++
+		s.mu.Unlock()
+
+		slog.Warn("Restarting scheduler loop", "delay", restartDelay)
+		select {
+		case <-s.stopCh:
+			return
+		case <-time.After(restartDelay):
+		}
+	}
+}
+
+// run is the main scheduler loop. It returns true if the loop exited because of a panic.
+func (s *Scheduler) run() (crashed bool) {
+	// Recover from panics so the supervisor can restart the loop
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("[SCHEDULER PANIC] Recovered from panic in scheduler loop: %v\n%s", r, debug.Stack())
-			// Mark as not running so it can be restarted
+			slog.Error("Recovered from panic in scheduler loop", "panic", r, "stack", string(debug.Stack()))
+			now := time.Now()
 			s.mu.Lock()
-			s.running = false
+			s.health.LastPanic = fmt.Sprint(r)
+			s.health.LastPanicAt = &now
 			s.mu.Unlock()
+			crashed = true
 		}
 	}()
 
 	// Initial delay to let the server start
-	time.Sleep(10 * time.Second)
+	select {
+	case <-s.stopCh:
+		return
+	case <-time.After(10 * time.Second):
+	}
 
 	// Check for topics that need initial sources (with recovery)
 	s.safeInitializeTopics()
@@ -101,99 +408,285 @@ func (s *Scheduler) run() {
 		default:
 		}
 
+		s.mu.Lock()
+		s.health.LastLoop = time.Now()
+		s.mu.Unlock()
+
 		// Get settings for interval
 		settings, err := s.db.GetSettings()
 		if err == nil && settings != nil {
-			s.mu.Lock()
-			s.interval = time.Duration(settings.RefreshIntervalMinutes) * time.Minute
-			s.mu.Unlock()
+			s.UpdateInterval(settings.RefreshIntervalMinutes)
+			s.UpdateConcurrency(settings.MaxConcurrentRefreshes, settings.RefreshStaggerSeconds)
+		}
+
+		// Skip scheduled refreshes entirely during quiet hours
+		if s.isQuietTime(settings) {
+			select {
+			case <-s.stopCh:
+				return
+			case <-time.After(time.Minute):
+			}
+			continue
 		}
 
+		s.maybeRunMaintenance()
+
 		// Find topics that need refresh
 		topics, err := s.db.GetTopics()
 		if err != nil {
-			log.Printf("Error getting topics: %v", err)
+			slog.Error("Error getting topics", "error", err)
 			time.Sleep(time.Minute)
 			continue
 		}
 
-		// Stagger refreshes to avoid API overload
-		topicsToRefresh := s.getTopicsNeedingRefresh(topics)
-		for _, topic := range topicsToRefresh {
-			select {
-			case <-s.stopCh:
-				return
-			default:
-			}
-
-			// Use safe wrapper to prevent panics from crashing the scheduler
-			s.safeRefreshTopic(topic.ID)
-
-			// Wait between topic refreshes to be gentle on the Pi
-			time.Sleep(30 * time.Second)
+		// Queue due topics; jobs already queued or running are coalesced
+		for _, topic := range s.getTopicsNeedingRefresh(topics) {
+			s.jobs.enqueue(topic.ID, TriggerScheduled)
 		}
 
 		// Sleep until next check
 		select {
 		case <-s.stopCh:
 			return
+		case <-s.wakeCh:
 		case <-time.After(time.Minute):
 		}
 	}
 }
 
+// dispatchJobs hands queued refresh jobs to workers, running at most the configured
+// number at once, until the job queue is closed
+func (s *Scheduler) dispatchJobs() {
+	defer s.wg.Done()
+
+	limit := func() int {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.workers
+	}
+	for {
+		job, ok := s.jobs.next(limit)
+		if !ok {
+			return
+		}
+		s.wg.Add(1)
+		go s.runJob(job)
+	}
+}
+
+// runJob refreshes the job's topic, then holds the worker slot for the stagger delay
+// to be gentle on the Pi
+func (s *Scheduler) runJob(job *models.Job) {
+	defer s.wg.Done()
+	defer s.jobs.release()
+
+	// Scheduled jobs that reach the front of the queue during quiet hours wait for the next check
+	if job.Trigger == TriggerScheduled {
+		if settings, err := s.db.GetSettings(); err == nil && s.isQuietTime(settings) {
+			s.jobs.finish(job, models.JobSkipped, nil)
+			return
+		}
+	}
+
+	// Use safe wrapper to prevent panics from crashing the scheduler
+	err := s.safeRefreshTopic(job.TopicID)
+	switch {
+	case errors.Is(err, ErrAlreadyRefreshing), errors.Is(err, ErrBudgetExhausted), errors.Is(err, ErrTopicDisabled):
+		s.jobs.finish(job, models.JobSkipped, err)
+		return
+	case err != nil:
+		s.jobs.finish(job, models.JobFailed, err)
+	default:
+		s.jobs.finish(job, models.JobCompleted, nil)
+	}
+
+	s.mu.Lock()
+	stagger := s.stagger
+	s.mu.Unlock()
+	select {
+	case <-s.stopCh:
+	case <-time.After(stagger):
+	}
+}
+
+// EnqueueRefresh queues a manual refresh for a topic and returns its job. If the topic
+// is already queued or being refreshed, the existing job is returned and added is false.
+func (s *Scheduler) EnqueueRefresh(topicID int64) (job models.Job, added bool) {
+	job, added = s.jobs.enqueue(topicID, TriggerManual)
+	if added {
+		s.markQueued(topicID)
+	}
+	return job, added
+}
+
+// Jobs returns running, queued, and recently finished refresh jobs
+func (s *Scheduler) Jobs() []models.Job {
+	return s.jobs.list()
+}
+
+// markQueued sets a topic's refresh status to queued, keeping the existing
+// timestamps so the UI still shows the last refresh
+func (s *Scheduler) markQueued(topicID int64) {
+	status, err := s.db.GetRefreshStatus(topicID)
+	if err != nil {
+		slog.Error("Error getting refresh status", "topic_id", topicID, "error", err)
+		return
+	}
+	if status == nil {
+		status = &models.RefreshStatus{TopicID: topicID}
+	}
+	if status.Status == "in_progress" {
+		return
+	}
+	status.Status = "queued"
+	status.ErrorMessage = ""
+	s.updateStatus(status)
+}
+
+// RunMaintenance checkpoints and vacuums the database, waiting for running refreshes to finish first
+func (s *Scheduler) RunMaintenance() error {
+	s.maintenanceMu.Lock()
+	defer s.maintenanceMu.Unlock()
+
+	slog.Info("Running database maintenance")
+	if err := s.db.Maintain(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.lastMaintenance = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// maybeRunMaintenance runs database maintenance if it hasn't run within maintenanceInterval.
+// The first run happens a day after startup rather than right away.
+func (s *Scheduler) maybeRunMaintenance() {
+	s.mu.Lock()
+	if s.lastMaintenance.IsZero() {
+		s.lastMaintenance = time.Now()
+	}
+	due := time.Since(s.lastMaintenance) >= maintenanceInterval
+	s.mu.Unlock()
+
+	if !due {
+		return
+	}
+	if err := s.RunMaintenance(); err != nil {
+		slog.Error("Database maintenance failed", "error", err)
+		// Don't retry every minute
+		s.mu.Lock()
+		s.lastMaintenance = time.Now()
+		s.mu.Unlock()
+	}
+}
+
+// isQuietTime reports whether scheduled refreshes should be held back right now
+func (s *Scheduler) isQuietTime(settings *models.Settings) bool {
+	if settings == nil {
+		return false
+	}
+	quiet := inQuietHours(time.Now(), settings.QuietHoursStart, settings.QuietHoursEnd)
+
+	s.mu.Lock()
+	changed := quiet != s.quiet
+	s.quiet = quiet
+	s.mu.Unlock()
+
+	if changed {
+		if quiet {
+			slog.Info("Quiet hours started, pausing scheduled refreshes", "start", settings.QuietHoursStart, "end", settings.QuietHoursEnd)
+		} else {
+			slog.Info("Quiet hours ended, resuming scheduled refreshes")
+		}
+	}
+	return quiet
+}
+
+// inQuietHours reports whether t falls inside the [start, end) window given as "HH:MM".
+// A window whose end is earlier than its start wraps past midnight (e.g. 22:00-06:00).
+// An empty, invalid, or zero-length window never matches.
+func inQuietHours(t time.Time, start, end string) bool {
+	if start == "" || end == "" {
+		return false
+	}
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return false
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return false
+	}
+
+	startMin := startTime.Hour()*60 + startTime.Minute()
+	endMin := endTime.Hour()*60 + endTime.Minute()
+	nowMin := t.Hour()*60 + t.Minute()
+
+	switch {
+	case startMin == endMin:
+		return false
+	case startMin < endMin:
+		return nowMin >= startMin && nowMin < endMin
+	default:
+		return nowMin >= startMin || nowMin < endMin
+	}
+}
+
 // safeInitializeTopics wraps initializeTopics with panic recovery
 func (s *Scheduler) safeInitializeTopics() {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("[SCHEDULER PANIC] Recovered from panic in initializeTopics: %v\n%s", r, debug.Stack())
+			slog.Error("Recovered from panic in initializeTopics", "panic", r, "stack", string(debug.Stack()))
 		}
 	}()
 	s.initializeTopics()
 }
 
-// safeRefreshTopic wraps refreshTopic with panic recovery
-func (s *Scheduler) safeRefreshTopic(topicID int64) {
+// safeRefreshTopic wraps refreshTopic with panic recovery, reporting a panic as an error
+func (s *Scheduler) safeRefreshTopic(topicID int64) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("[SCHEDULER PANIC] Recovered from panic in refreshTopic for topic %d: %v\n%s", topicID, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+			slog.Error("Recovered from panic in refreshTopic", "topic_id", topicID, "panic", r, "stack", string(debug.Stack()))
 			// Mark the topic as failed
-			status := &models.RefreshStatus{
-				TopicID:      topicID,
-				NextRefresh:  time.Now().Add(5 * time.Minute),
-				Status:       "failed",
-				ErrorMessage: fmt.Sprintf("panic: %v", r),
-			}
-			s.db.UpdateRefreshStatus(status)
+			s.markFailed(topicID, err)
 		}
 	}()
-	s.refreshTopic(topicID)
+	return s.refreshTopic(topicID)
 }
 
-// initializeTopics discovers sources for topics that have none
+// initializeTopics discovers sources for enabled topics that have none, leaving out
+// topics with automatic discovery turned off
 func (s *Scheduler) initializeTopics() {
 	topics, err := s.db.GetTopics()
 	if err != nil {
-		log.Printf("Error getting topics for initialization: %v", err)
+		slog.Error("Error getting topics for initialization", "error", err)
 		return
 	}
 
 	settings, err := s.db.GetSettings()
-	if err != nil || settings == nil || settings.GeminiAPIKey == "" {
-		log.Println("Gemini API key not configured, skipping topic initialization")
+	if err != nil || settings == nil {
+		slog.Error("Error getting settings for initialization", "error", err)
+		return
+	}
+	if err := llm.CheckConfigured(settings); err != nil {
+		slog.Warn("Skipping topic initialization", "error", err)
 		return
 	}
 
 	for _, topic := range topics {
+		if !topic.Enabled || !topic.AutoDiscover {
+			continue
+		}
 		sources, err := s.db.GetSourcesForTopic(topic.ID)
 		if err != nil {
-			log.Printf("Error getting sources for topic %d: %v", topic.ID, err)
+			slog.Error("Error getting sources", "topic_id", topic.ID, "error", err)
 			continue
 		}
 
 		if len(sources) == 0 {
-			log.Printf("Discovering sources for topic: %s", topic.Name)
-			s.discoverSources(topic.ID)
+			slog.Info("Discovering sources", "topic_id", topic.ID, "topic", topic.Name)
+			s.discoverSources(s.ctx, topic.ID)
 			time.Sleep(5 * time.Second) // Rate limit
 		}
 	}
@@ -205,16 +698,22 @@ func (s *Scheduler) getTopicsNeedingRefresh(topics []models.Topic) []models.Topi
 	now := time.Now()
 
 	for _, topic := range topics {
+		// Topics with auto-refresh off are only refreshed manually, disabled ones not at all
+		if !topic.AutoRefresh || !topic.Enabled {
+			continue
+		}
+
 		status, err := s.db.GetRefreshStatus(topic.ID)
 		if err != nil {
-			log.Printf("Error getting refresh status for topic %d: %v", topic.ID, err)
+			slog.Error("Error getting refresh status", "topic_id", topic.ID, "error", err)
 			continue
 		}
 
 		// If no status exists or refresh time has passed, need refresh
 		if status == nil {
 			needRefresh = append(needRefresh, topic)
-		} else if now.After(status.NextRefresh) && status.Status != "in_progress" {
+		} else if now.After(status.NextRefresh) && status.Status != "in_progress" && status.Status != "queued" &&
+			status.Status != "discovering" {
 			needRefresh = append(needRefresh, topic)
 		}
 	}
@@ -222,83 +721,182 @@ func (s *Scheduler) getTopicsNeedingRefresh(topics []models.Topic) []models.Topi
 	return needRefresh
 }
 
-// RefreshTopic manually triggers a topic refresh
+// RefreshTopic manually triggers a topic refresh and waits for it to finish.
+// Manual refreshes are not subject to quiet hours. Returns ErrAlreadyRefreshing
+// if the topic is already being refreshed.
 func (s *Scheduler) RefreshTopic(topicID int64) error {
 	return s.refreshTopic(topicID)
 }
 
-// SafeRefreshTopic triggers a topic refresh with panic recovery (for background use)
-func (s *Scheduler) SafeRefreshTopic(topicID int64) {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("[SCHEDULER PANIC] Recovered from panic in RefreshTopic for topic %d: %v\n%s", topicID, r, debug.Stack())
-			// Mark the topic as failed
-			status := &models.RefreshStatus{
-				TopicID:      topicID,
-				NextRefresh:  time.Now().Add(5 * time.Minute),
-				Status:       "failed",
-				ErrorMessage: fmt.Sprintf("panic: %v", r),
-			}
-			s.db.UpdateRefreshStatus(status)
+// RefreshAll queues a manual refresh job for every enabled topic. Topics already queued
+// or being refreshed are skipped. Returns the number of topics queued.
+func (s *Scheduler) RefreshAll() (int, error) {
+	topics, err := s.db.GetTopics()
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, topic := range topics {
+		if !topic.Enabled {
+			continue
 		}
-	}()
-	if err := s.refreshTopic(topicID); err != nil {
-		log.Printf("Error refreshing topic %d: %v", topicID, err)
+		if _, added := s.jobs.enqueue(topic.ID, TriggerManual); added {
+			s.markQueued(topic.ID)
+			queued++
+		}
+	}
+
+	if queued > 0 {
+		slog.Info("Queued topics for refresh", "count", queued)
 	}
+	return queued, nil
+}
+
+// beginRefresh marks a topic as being refreshed. It returns false if a refresh
+// is already running, so callers don't have to wait for the DB status to land.
+func (s *Scheduler) beginRefresh(topicID int64) bool {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+	if _, ok := s.inFlight[topicID]; ok {
+		return false
+	}
+	s.inFlight[topicID] = struct{}{}
+	return true
+}
+
+// endRefresh clears the in-flight mark set by beginRefresh
+func (s *Scheduler) endRefresh(topicID int64) {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+	delete(s.inFlight, topicID)
+}
+
+// IsRefreshing reports whether a topic is being refreshed right now
+func (s *Scheduler) IsRefreshing(topicID int64) bool {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+	_, ok := s.inFlight[topicID]
+	return ok
 }
 
-// refreshTopic performs the actual refresh for a topic
-func (s *Scheduler) refreshTopic(topicID int64) error {
+// refreshTopic performs the actual refresh for a topic.
+// It returns ErrAlreadyRefreshing if the topic is already being refreshed, or
+// ErrTopicDisabled if it has been disabled.
+func (s *Scheduler) refreshTopic(topicID int64) (err error) {
+	if !s.beginRefresh(topicID) {
+		return ErrAlreadyRefreshing
+	}
+	defer s.endRefresh(topicID)
+
+	s.maintenanceMu.RLock()
+	defer s.maintenanceMu.RUnlock()
+
+	// Tag every log line of this run, so interleaved refreshes can be told apart
+	logger := slog.With("refresh_id", newRefreshID())
+	ctx := logging.WithLogger(s.ctx, logger)
+
 	topic, err := s.db.GetTopic(topicID)
 	if err != nil || topic == nil {
 		return fmt.Errorf("topic not found: %d", topicID)
 	}
+	if !topic.Enabled {
+		// Disabled after the refresh was queued
+		return ErrTopicDisabled
+	}
 
 	settings, err := s.db.GetSettings()
 	if err != nil || settings == nil {
 		return fmt.Errorf("failed to get settings: %w", err)
 	}
 
-	if settings.GeminiAPIKey == "" {
-		return fmt.Errorf("Gemini API key not configured")
+	if err := llm.CheckConfigured(settings); err != nil {
+		return err
 	}
 
-	// Update status to in_progress
-	status := &models.RefreshStatus{
-		TopicID: topicID,
-		Status:  "in_progress",
+	// Leave the topic for tomorrow once the day's AI budget is spent
+	if err := s.checkBudget(settings); err != nil {
+		if errors.Is(err, ErrBudgetExhausted) {
+			logger.Info("Deferring refresh", "topic_id", topic.ID, "topic", topic.Name, "error", err)
+			s.deferForBudget(topicID, err)
+		}
+		return err
 	}
-	s.db.UpdateRefreshStatus(status)
 
-	log.Printf("Refreshing topic: %s", topic.Name)
+	// Update status to in_progress, keeping the last refresh time and backoff state
+	status := s.currentStatus(topicID)
+	status.Status = "in_progress"
+	status.ErrorMessage = ""
+	s.updateStatus(status)
+
+	// Record the outcome and AI usage in the refresh history however the refresh ends
+	history := &models.RefreshHistory{TopicID: topicID, StartedAt: time.Now(), Status: "failed"}
+	tally := &usageTally{}
+	defer func() {
+		history.FinishedAt = time.Now()
+		tally.fill(history)
+		if err != nil {
+			history.Error = err.Error()
+		} else if history.Status != "completed" {
+			history.Error = "refresh aborted"
+		}
+		if err := s.db.AddRefreshHistory(history); err != nil {
+			logger.Error("Error recording refresh history", "topic_id", topicID, "error", err)
+		}
+		s.recordRefresh(history)
+	}()
+
+	logger.Info("Refreshing topic", "topic_id", topic.ID, "topic", topic.Name)
 
 	// Get active sources for this topic
 	sources, err := s.db.GetActiveSourcesForTopic(topicID)
 	if err != nil {
-		return s.handleRefreshError(topicID, fmt.Errorf("failed to get sources: %w", err))
+		return s.handleRefreshError(ctx, topicID, fmt.Errorf("failed to get sources: %w", err))
 	}
 
 	if len(sources) == 0 {
+		if !topic.AutoDiscover {
+			return s.handleRefreshError(ctx, topicID, fmt.Errorf("no sources available for topic, and automatic source discovery is off"))
+		}
 		// Try to discover sources first
-		if err := s.discoverSources(topicID); err != nil {
-			return s.handleRefreshError(topicID, fmt.Errorf("failed to discover sources: %w", err))
+		if err := s.discoverSources(ctx, topicID); err != nil {
+			return s.handleRefreshError(ctx, topicID, fmt.Errorf("failed to discover sources: %w", err))
 		}
 		sources, _ = s.db.GetActiveSourcesForTopic(topicID)
 		if len(sources) == 0 {
-			return s.handleRefreshError(topicID, fmt.Errorf("no sources available for topic"))
+			return s.handleRefreshError(ctx, topicID, fmt.Errorf("no sources available for topic"))
 		}
 	}
 
-	// Scrape content from sources
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
-
-	scrapeResults := s.scraper.ScrapeSources(ctx, sources)
-
-	// Process results and update source statuses
+	// Scrape content from sources. Sources still going when the deadline hits are
+	// dropped so the refresh carries on with whatever was collected.
+	scrapeCtx, cancelScrape := context.WithTimeout(ctx, scrapeTimeout)
+	defer cancelScrape()
+
+	s.scraper.ApplySettings(settings)
+	s.reportProgress(status, fmt.Sprintf("scraping 0/%d", len(sources)), 5)
+	var progressMu sync.Mutex
+	scrapeResults := s.scraper.ScrapeSources(scrapeCtx, sources, func(done, total int) {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		s.reportProgress(status, fmt.Sprintf("scraping %d/%d", done, total), 5+55*done/total)
+	})
+
+	// Process results and update source statuses. Sources whose content hasn't changed
+	// since it was last summarized are left out so the AI isn't asked about them again.
 	var scrapedContent []gemini.ScrapedContent
+	changedHashes := make(map[int64]string)
+	unchanged := 0
+	scrapedAt := time.Now()
 	for _, result := range scrapeResults {
+		if err := s.db.RecordSourceScrape(result.Source.ID, result.Error == nil, scrapedAt); err != nil {
+			logger.Error("Error recording scrape", "source_id", result.Source.ID, "error", err)
+		}
+
 		if result.Error != nil {
+			history.SourcesFailed++
+			logger.Warn("Failed to scrape source", "topic_id", topicID, "source_id", result.Source.ID, "source_url", result.Source.URL, "error", result.Error)
+
 			// Increment failure count
 			newFailureCount := result.Source.FailureCount + 1
 			isActive := newFailureCount < 3 // Disable after 3 failures
@@ -309,108 +907,527 @@ func (s *Scheduler) refreshTopic(topicID int64) error {
 			}
 
 			if err := s.db.UpdateSourceStatus(result.Source.ID, isActive, newFailureCount, errMsg); err != nil {
-				log.Printf("Error updating source status: %v", err)
+				logger.Error("Error updating source status", "source_id", result.Source.ID, "error", err)
 			}
 
 			if !isActive {
-				log.Printf("Source disabled after %d failures: %s", newFailureCount, result.Source.URL)
+				s.recordSourceDisabled()
+				logger.Warn("Source disabled after repeated failures", "topic_id", topicID, "source_id", result.Source.ID, "source_url", result.Source.URL, "failures", newFailureCount)
 			}
 		} else {
 			// Success - reset failure count
 			if result.Source.FailureCount > 0 {
 				if err := s.db.UpdateSourceStatus(result.Source.ID, true, 0, ""); err != nil {
-					log.Printf("Error resetting source status: %v", err)
+					logger.Error("Error resetting source status", "source_id", result.Source.ID, "error", err)
 				}
 			}
+			history.SourcesScraped++
+
+			hash := contentHash(result.Content.Content)
+			if hash == result.Source.ContentHash {
+				unchanged++
+				continue
+			}
+			changedHashes[result.Source.ID] = hash
 			scrapedContent = append(scrapedContent, *result.Content)
 		}
 	}
 
-	if len(scrapedContent) == 0 {
-		return s.handleRefreshError(topicID, fmt.Errorf("failed to scrape any content from active sources"))
+	if history.SourcesScraped == 0 {
+		return s.handleRefreshError(ctx, topicID, fmt.Errorf("failed to scrape any content from active sources"))
 	}
 
-	// Summarize with Gemini
-	geminiClient, err := gemini.New(settings.GeminiAPIKey)
-	if err != nil {
-		return s.handleRefreshError(topicID, fmt.Errorf("failed to create Gemini client: %w", err))
-	}
-	defer geminiClient.Close()
+	// Summarize with the configured AI provider
+	var stories []gemini.SummarizedStory
+	if len(scrapedContent) == 0 {
+		logger.Info("Skipping summarization, no source changed since the last refresh", "topic_id", topicID, "topic", topic.Name, "unchanged", unchanged)
+	} else {
+		s.reportProgress(status, "summarizing", 65)
+		aiClient, err := s.newAIClient(settings, topicID, tally)
+		if err != nil {
+			return s.handleRefreshError(ctx, topicID, fmt.Errorf("failed to create AI client: %w", err))
+		}
+		defer aiClient.Close()
+		aiClient.SetProgressFunc(s.summarizeProgress(status, settings))
+		setSummaryLength(aiClient, topic, settings)
 
-	stories, err := geminiClient.SummarizeContent(ctx, topic.Name, scrapedContent, settings.GlobalSummarizingPrompt, settings.StoriesPerTopic)
-	if err != nil {
-		return s.handleRefreshError(topicID, fmt.Errorf("failed to summarize content: %w", err))
+		aiCtx, cancel := context.WithTimeout(ctx, llm.Timeout(settings))
+		defer cancel()
+		stories, err = aiClient.SummarizeContent(aiCtx, topic.Name, scrapedContent, summarizingPrompt(topic, settings), settings.StoriesPerTopic)
+		if err != nil {
+			return s.handleRefreshError(ctx, topicID, fmt.Errorf("failed to summarize content: %w", err))
+		}
 	}
 
-	// Store stories
-	for _, story := range stories {
+	// Store stories, skipping ones already stored by an earlier refresh
+	s.reportProgress(status, "storing", 90)
+	dedup := s.newSemanticDedup(ctx, settings, topicID, stories)
+	for i, story := range stories {
+		exists, err := s.db.StoryExists(topicID, story.Title)
+		if err != nil {
+			logger.Error("Error checking for duplicate story", "topic_id", topicID, "error", err)
+		} else if exists {
+			continue
+		}
+		if title, similarity, ok := dedup.duplicateOf(i); ok {
+			logger.Info("Skipping story that repeats a recent one", "topic_id", topicID, "story", story.Title, "duplicate_of", title, "similarity", similarity)
+			continue
+		}
+
 		dbStory := &models.Story{
-			TopicID:     topicID,
-			Title:       story.Title,
-			Summary:     story.Summary,
-			SourceURL:   story.SourceURL,
-			SourceTitle: story.SourceTitle,
-			PublishedAt: time.Now(),
+			TopicID:       topicID,
+			Title:         story.Title,
+			Summary:       story.Summary,
+			SourceURL:     story.SourceURL,
+			SourceTitle:   story.SourceTitle,
+			ImageURL:      storyImage(story.SourceURL, scrapedContent),
+			Tags:          gemini.NormalizeTags(story.Tags),
+			Importance:    gemini.ClampImportance(story.Importance),
+			Citations:     gemini.Citations(story, scrapedContent),
+			SummaryLength: summaryLength(topic, settings),
+			PublishedAt:   storyPublished(story.SourceURL, scrapedContent, time.Now()),
 		}
 		if err := s.db.CreateStory(dbStory); err != nil {
-			log.Printf("Error creating story: %v", err)
+			logger.Error("Error creating story", "topic_id", topicID, "error", err)
+			continue
+		}
+		s.storeEmbedding(dedup, i, dbStory)
+		history.StoriesCreated++
+	}
+
+	// Remember what was summarized only now, so content from a failed refresh is tried again
+	for sourceID, hash := range changedHashes {
+		if err := s.db.UpdateSourceContentHash(sourceID, hash); err != nil {
+			logger.Error("Error storing content hash", "source_id", sourceID, "error", err)
 		}
 	}
 
-	// Clean up old stories (keep 3x the display count)
-	s.db.DeleteOldStories(topicID, settings.StoriesPerTopic*3)
+	// Clean up old stories
+	s.applyRetention(ctx, topic, settings)
+
+	// Back off when refreshes keep turning up nothing new
+	if history.StoriesCreated == 0 {
+		status.EmptyRefreshes++
+	} else {
+		status.EmptyRefreshes = 0
+	}
+	status.BackoffMultiplier = backoffMultiplier(status.EmptyRefreshes)
 
 	// Update status to completed
+	now := time.Now()
+	status.LastRefresh = now
+	status.NextRefresh = s.nextRefreshTime(topic, now, status.BackoffMultiplier)
+	status.Status = "completed"
+	status.ProgressStage = ""
+	status.ProgressPercent = 0
+	s.updateStatus(status)
+
+	history.Status = "completed"
+	notifyWebhook(logger, settings.WebhookURL, topic, history.StoriesCreated)
+	logger.Info("Completed refresh", "topic_id", topicID, "topic", topic.Name, "stories", len(stories), "new", history.StoriesCreated)
+	if status.BackoffMultiplier > 1 {
+		logger.Info("No new stories, backing off", "topic_id", topicID, "topic", topic.Name, "empty_refreshes", status.EmptyRefreshes, "multiplier", status.BackoffMultiplier)
+	}
+	return nil
+}
+
+// storyImage picks the preview image for a story from what was scraped: the image of the
+// feed item or page the story links to, or failing that, the image of a scraped page on
+// the same site. It returns "" if none fits.
+func storyImage(storyURL string, scraped []gemini.ScrapedContent) string {
+	for _, content := range scraped {
+		for link, image := range content.Images {
+			if scraper.SameSource(link, storyURL) {
+				return image
+			}
+		}
+	}
+	host := siteHost(storyURL)
+	if host == "" {
+		return ""
+	}
+	for _, content := range scraped {
+		if image := content.Images[content.URL]; image != "" && siteHost(content.URL) == host {
+			return image
+		}
+	}
+	return ""
+}
+
+// storyPublished returns when the feed item a story links to was published, or now if
+// the story didn't come from a dated feed item. Dates in the future are taken as now.
+func storyPublished(storyURL string, scraped []gemini.ScrapedContent, now time.Time) time.Time {
+	for _, content := range scraped {
+		for link, published := range content.Published {
+			if scraper.SameSource(link, storyURL) && published.Before(now) {
+				return published
+			}
+		}
+	}
+	return now
+}
+
+// siteHost returns a URL's lowercased host without a leading "www.", or "" if it has none
+func siteHost(urlStr string) string {
+	parsed, err := url.Parse(strings.TrimSpace(urlStr))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// newRefreshID returns a short random ID that tags the log lines of one refresh
+func newRefreshID() string {
+	return fmt.Sprintf("%08x", rand.Uint32())
+}
+
+// contentHash returns the hex SHA-256 of scraped content, for spotting unchanged pages
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// applyRetention deletes a topic's old stories according to the retention settings.
+// A topic's own StoryRetentionCount wins over the global settings, with 0 keeping every
+// story. Otherwise age-based retention replaces the count limit when StoryRetentionDays
+// is set, and StoryRetentionCount stories are kept, defaulting to 3x the display count.
+func (s *Scheduler) applyRetention(ctx context.Context, topic *models.Topic, settings *models.Settings) {
+	logger := logging.FromContext(ctx)
+	topicID := topic.ID
+	if settings.ArchiveStories && settings.ArchiveRetentionDays > 0 {
+		if err := s.db.DeleteArchivedStoriesOlderThan(topicID, settings.ArchiveRetentionDays); err != nil {
+			logger.Error("Error pruning archived stories", "topic_id", topicID, "error", err)
+		}
+	}
+
+	if topic.StoryRetentionCount != nil {
+		if keep := *topic.StoryRetentionCount; keep > 0 {
+			if err := s.db.DeleteOldStories(topicID, keep, settings.ArchiveStories); err != nil {
+				logger.Error("Error deleting old stories", "topic_id", topicID, "error", err)
+			}
+		}
+		return
+	}
+
+	if settings.StoryRetentionDays > 0 {
+		if err := s.db.DeleteStoriesOlderThan(topicID, settings.StoryRetentionDays, settings.ArchiveStories); err != nil {
+			logger.Error("Error deleting old stories", "topic_id", topicID, "error", err)
+		}
+		return
+	}
+
+	keep := settings.StoryRetentionCount
+	if keep <= 0 {
+		keep = settings.StoriesPerTopic * 3
+	}
+	if err := s.db.DeleteOldStories(topicID, keep, settings.ArchiveStories); err != nil {
+		logger.Error("Error deleting old stories", "topic_id", topicID, "error", err)
+	}
+}
+
+const (
+	// backoffThreshold is how many refreshes in a row must find no new stories before backing off
+	backoffThreshold = 2
+	// maxBackoffMultiplier caps how far the refresh interval is stretched
+	maxBackoffMultiplier = 8
+)
+
+// backoffMultiplier returns how much to stretch the refresh interval after the given
+// number of consecutive refreshes without new stories: 1 below the threshold, then
+// doubling with each further empty refresh up to maxBackoffMultiplier
+func backoffMultiplier(emptyRefreshes int) int {
+	multiplier := 1
+	for i := backoffThreshold; i <= emptyRefreshes && multiplier < maxBackoffMultiplier; i++ {
+		multiplier *= 2
+	}
+	return multiplier
+}
+
+// nextRefreshTime returns when a topic should next be refreshed after a refresh at the given time.
+// Topics with a cron schedule follow it; all others use the global interval stretched by
+// the backoff multiplier, with random jitter.
+func (s *Scheduler) nextRefreshTime(topic *models.Topic, from time.Time, backoff int) time.Time {
+	if topic.CronSchedule != "" {
+		schedule, err := cron.ParseStandard(topic.CronSchedule)
+		if err == nil {
+			return schedule.Next(from)
+		}
+		slog.Warn("Invalid cron schedule, falling back to interval", "topic_id", topic.ID, "topic", topic.Name, "cron", topic.CronSchedule, "error", err)
+	}
+
 	s.mu.Lock()
 	interval := s.interval
 	s.mu.Unlock()
+	if backoff > 1 {
+		interval *= time.Duration(backoff)
+	}
+	return from.Add(jitter(interval))
+}
+
+// refreshJitter is the fraction by which interval-based refresh times are randomly
+// moved earlier or later, so topics spread out instead of refreshing back-to-back
+const refreshJitter = 0.2
+
+// jitter returns d randomly adjusted by up to ±refreshJitter
+func jitter(d time.Duration) time.Duration {
+	factor := 1 + refreshJitter*(2*rand.Float64()-1)
+	return time.Duration(float64(d) * factor)
+}
+
+// RescheduleTopic recomputes the pending next refresh time for a topic,
+// e.g. after its cron schedule has been changed
+func (s *Scheduler) RescheduleTopic(topicID int64) error {
+	topic, err := s.db.GetTopic(topicID)
+	if err != nil || topic == nil {
+		return fmt.Errorf("topic not found: %d", topicID)
+	}
+
+	status, err := s.db.GetRefreshStatus(topicID)
+	if err != nil {
+		return err
+	}
+	if status == nil || status.Status == "in_progress" {
+		// Nothing scheduled yet, or the running refresh will schedule the next one
+		return nil
+	}
+
+	from := status.LastRefresh
+	if topic.CronSchedule != "" || from.IsZero() {
+		from = time.Now()
+	}
+	status.NextRefresh = s.nextRefreshTime(topic, from, status.BackoffMultiplier)
+	return s.updateStatus(status)
+}
+
+// SetTopicEnabled enables or disables a topic. A disabled topic keeps its stories but
+// isn't refreshed until it's enabled again, when it's refreshed at the next check.
+func (s *Scheduler) SetTopicEnabled(topicID int64, enabled bool) error {
+	if err := s.db.SetTopicEnabled(topicID, enabled); err != nil {
+		return err
+	}
 
-	status = &models.RefreshStatus{
-		TopicID:     topicID,
-		LastRefresh: time.Now(),
-		NextRefresh: time.Now().Add(interval),
-		Status:      "completed",
+	status := s.currentStatus(topicID)
+	if status.Status == "in_progress" {
+		// The running refresh finishes; the next one is skipped or scheduled as usual
+		return nil
+	}
+	if enabled {
+		status.Status = "pending"
+		status.NextRefresh = time.Now()
+	} else {
+		status.Status = "disabled"
 	}
-	s.db.UpdateRefreshStatus(status)
+	status.ErrorMessage = ""
+	if err := s.updateStatus(status); err != nil {
+		return err
+	}
+	if enabled {
+		s.wake()
+	}
+	return nil
+}
 
-	log.Printf("Completed refresh for topic: %s (%d stories)", topic.Name, len(stories))
+// ValidateCronSchedule checks that expr is a valid standard 5-field cron expression
+func ValidateCronSchedule(expr string) error {
+	if _, err := cron.ParseStandard(expr); err != nil {
+		return fmt.Errorf("invalid cron schedule %q: %w", expr, err)
+	}
 	return nil
 }
 
-// handleRefreshError updates status and schedules a retry
-func (s *Scheduler) handleRefreshError(topicID int64, err error) error {
-	log.Printf("Refresh error for topic %d: %v", topicID, err)
+// NextRunTimes returns the next n times a cron expression fires after from
+func NextRunTimes(expr string, from time.Time, n int) ([]time.Time, error) {
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make([]time.Time, 0, n)
+	next := from
+	for i := 0; i < n; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		times = append(times, next)
+	}
+	return times, nil
+}
+
+// sourcingPrompt returns the topic's sourcing prompt, falling back to the global one
+func sourcingPrompt(topic *models.Topic, settings *models.Settings) string {
+	if topic.SourcingPrompt != "" {
+		return topic.SourcingPrompt
+	}
+	return settings.GlobalSourcingPrompt
+}
+
+// summarizingPrompt returns the topic's summarizing prompt, falling back to the global one,
+// followed by the instruction to write in the topic's story language if it has one
+func summarizingPrompt(topic *models.Topic, settings *models.Settings) string {
+	prompt := settings.GlobalSummarizingPrompt
+	if topic.SummarizingPrompt != "" {
+		prompt = topic.SummarizingPrompt
+	}
+	if instruction := gemini.SummaryLengthInstruction(summaryLength(topic, settings)); instruction != "" {
+		prompt = strings.TrimSpace(prompt + "\n\n" + instruction)
+	}
+	if instruction := gemini.LanguageInstruction(storyLanguage(topic, settings)); instruction != "" {
+		prompt = strings.TrimSpace(prompt + "\n\n" + instruction)
+	}
+	return prompt
+}
+
+// summaryLength returns the summary length preset for the topic's stories, the topic's
+// own setting winning over the global one
+func summaryLength(topic *models.Topic, settings *models.Settings) string {
+	if topic.SummaryLength != "" {
+		return topic.SummaryLength
+	}
+	if settings.SummaryLength != "" {
+		return settings.SummaryLength
+	}
+	return gemini.SummaryLengthStandard
+}
+
+// MaxDiscoverSources is the most sources discovery can be set to ask for, globally or per topic
+const MaxDiscoverSources = 20
 
-	status := &models.RefreshStatus{
-		TopicID:      topicID,
-		NextRefresh:  time.Now().Add(5 * time.Minute), // Retry in 5 minutes
-		Status:       "failed",
-		ErrorMessage: err.Error(),
+// sourceCount returns how many sources discovery asks for and keeps for a topic: the
+// topic's own count if it has one, else the global range
+func sourceCount(topic *models.Topic, settings *models.Settings) (minSources, maxSources int) {
+	if topic.DiscoverSourceCount != nil {
+		return *topic.DiscoverSourceCount, *topic.DiscoverSourceCount
 	}
-	s.db.UpdateRefreshStatus(status)
+	return settings.MinSources, settings.MaxSources
+}
+
+// setSummaryLength sets the summary word range of an AI client to the topic's length preset
+func setSummaryLength(client llm.Summarizer, topic *models.Topic, settings *models.Settings) {
+	client.SetSummaryLength(gemini.SummaryWords(summaryLength(topic, settings), settings.SummaryMinWords, settings.SummaryMaxWords))
+}
 
+// storyLanguage returns the language code the topic's stories are written in, the topic's
+// own setting winning over the global one; "" leaves stories in their sources' language
+func storyLanguage(topic *models.Topic, settings *models.Settings) string {
+	if topic.StoryLanguage != "" {
+		return topic.StoryLanguage
+	}
+	return settings.StoryLanguage
+}
+
+// handleRefreshError updates status and schedules a retry. ctx is the refresh's context,
+// whose logger is used.
+func (s *Scheduler) handleRefreshError(ctx context.Context, topicID int64, err error) error {
+	logger := logging.FromContext(ctx)
+	if s.ctx.Err() != nil {
+		// Shutting down; the refresh is retried soon after the next start
+		logger.Info("Refresh interrupted by shutdown", "topic_id", topicID)
+		s.markFailed(topicID, fmt.Errorf("interrupted by shutdown"))
+		return err
+	}
+	logger.Error("Refresh failed", "topic_id", topicID, "error", err)
+	s.markFailed(topicID, err)
 	return err
 }
 
+// markFailed sets a topic's status to failed and schedules a retry in 5 minutes,
+// keeping its last refresh time and backoff state
+func (s *Scheduler) markFailed(topicID int64, err error) {
+	status := s.currentStatus(topicID)
+	status.NextRefresh = time.Now().Add(5 * time.Minute) // Retry in 5 minutes
+	status.Status = "failed"
+	status.ErrorMessage = err.Error()
+	status.ProgressStage = ""
+	status.ProgressPercent = 0
+	s.updateStatus(status)
+}
+
+// currentStatus returns a topic's stored refresh status, or a new one if none exists
+func (s *Scheduler) currentStatus(topicID int64) *models.RefreshStatus {
+	status, err := s.db.GetRefreshStatus(topicID)
+	if err != nil {
+		slog.Error("Error getting refresh status", "topic_id", topicID, "error", err)
+	}
+	if status == nil {
+		status = &models.RefreshStatus{TopicID: topicID, BackoffMultiplier: 1}
+	}
+	return status
+}
+
 // DiscoverSources triggers source discovery for a topic
 func (s *Scheduler) DiscoverSources(topicID int64) error {
-	return s.discoverSources(topicID)
+	return s.discoverSources(s.ctx, topicID)
 }
 
-// SafeDiscoverSources triggers source discovery with panic recovery (for background use)
-func (s *Scheduler) SafeDiscoverSources(topicID int64) {
+// DiscoverSourcesInBackground starts source discovery for a topic without waiting for it.
+// Stop cancels it.
+func (s *Scheduler) DiscoverSourcesInBackground(topicID int64) {
+	s.goBackground("discoverSources", func() {
+		if err := s.discoverSources(s.ctx, topicID); err != nil {
+			slog.Error("Error discovering sources", "topic_id", topicID, "error", err)
+		}
+	})
+}
+
+// SetupTopicInBackground starts setting up a newly created topic without waiting for it.
+// Stop cancels it.
+func (s *Scheduler) SetupTopicInBackground(topicID int64) {
+	s.goBackground("setupTopic", func() {
+		s.setupTopic(topicID)
+	})
+}
+
+// setupTopic discovers sources for a newly created topic and then queues its first
+// refresh ahead of any waiting jobs, so stories show up without waiting for the scheduler
+// loop. The topic's status moves through discovering, queued, in_progress and completed;
+// a discovery failure or panic is recorded as a failed status. A topic with automatic
+// discovery off is left pending until the user adds its sources.
+func (s *Scheduler) setupTopic(topicID int64) {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("[SCHEDULER PANIC] Recovered from panic in DiscoverSources for topic %d: %v\n%s", topicID, r, debug.Stack())
+			slog.Error("Recovered from panic in SetupTopic", "topic_id", topicID, "panic", r, "stack", string(debug.Stack()))
+			s.markFailed(topicID, fmt.Errorf("panic: %v", r))
 		}
 	}()
-	if err := s.discoverSources(topicID); err != nil {
-		log.Printf("Error discovering sources for topic %d: %v", topicID, err)
+
+	status := s.currentStatus(topicID)
+	if topic, err := s.db.GetTopic(topicID); err == nil && topic != nil && !topic.AutoDiscover {
+		status.Status = "pending"
+		s.updateStatus(status)
+		return
+	}
+	status.Status = "discovering"
+	status.ErrorMessage = ""
+	s.updateStatus(status)
+
+	if err := s.discoverSources(s.ctx, topicID); err != nil {
+		slog.Error("Error discovering sources", "topic_id", topicID, "error", err)
+		s.markFailed(topicID, fmt.Errorf("source discovery failed: %w", err))
+		return
+	}
+
+	topic, err := s.db.GetTopic(topicID)
+	if err != nil || topic == nil {
+		return
+	}
+	if !topic.AutoRefresh {
+		// Manual-only topics wait to be refreshed by hand
+		status.Status = "pending"
+		s.updateStatus(status)
+		return
+	}
+
+	if _, added := s.jobs.enqueueFront(topicID, TriggerCreated); added {
+		s.markQueued(topicID)
 	}
 }
 
-// discoverSources uses AI to find sources for a topic
-func (s *Scheduler) discoverSources(topicID int64) error {
+// discoverSources uses AI to find sources for a topic. ctx bounds the run and carries
+// the logger it uses.
+func (s *Scheduler) discoverSources(ctx context.Context, topicID int64) error {
+	logger := logging.FromContext(ctx)
+
 	topic, err := s.db.GetTopic(topicID)
 	if err != nil || topic == nil {
 		return fmt.Errorf("topic not found: %d", topicID)
@@ -421,39 +1438,140 @@ func (s *Scheduler) discoverSources(topicID int64) error {
 		return fmt.Errorf("failed to get settings: %w", err)
 	}
 
-	if settings.GeminiAPIKey == "" {
-		return fmt.Errorf("Gemini API key not configured")
-	}
-
-	geminiClient, err := gemini.New(settings.GeminiAPIKey)
+	sources, err := s.suggestSources(ctx, topic, settings)
 	if err != nil {
-		return fmt.Errorf("failed to create Gemini client: %w", err)
+		return err
 	}
-	defer geminiClient.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-	defer cancel()
+	reachable := make([]string, len(sources))
+	passed := 0
+	for i, check := range s.checkSuggestedSources(ctx, sources, settings.VerifyDiscoveredSources) {
+		if check.err != nil {
+			logger.Warn("Rejected discovered source", "topic_id", topicID, "source_url", sources[i].URL, "error", check.err)
+			continue
+		}
+		reachable[i] = check.url
+		passed++
+	}
 
-	sources, err := geminiClient.DiscoverSources(ctx, topic.Name, topic.Description, settings.GlobalSourcingPrompt)
-	if err != nil {
-		return fmt.Errorf("failed to discover sources: %w", err)
+	// With nothing to replace them, for example while the Pi is offline, the current
+	// sources are better than none
+	if passed == 0 {
+		return fmt.Errorf("none of the %d discovered sources passed the checks, keeping the existing sources", len(sources))
 	}
 
 	// Clear existing AI sources and add new ones
-	s.db.ClearAISources(topicID)
+	if err := s.db.ClearAISources(topicID); err != nil {
+		return fmt.Errorf("failed to clear AI sources: %w", err)
+	}
 
-	for _, source := range sources {
-		if err := scraper.ValidateURL(source.URL); err != nil {
-			log.Printf("Skipping invalid source URL %s: %v", source.URL, err)
+	// Manual sources are kept, so new sources must not repeat them or each other
+	existing, err := s.db.GetSourcesForTopic(topicID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing sources: %w", err)
+	}
+	var known []string
+	for _, source := range existing {
+		known = append(known, source.URL)
+	}
+
+	added := 0
+	for i, source := range sources {
+		sourceURL := reachable[i]
+		if sourceURL == "" {
+			continue
+		}
+		if slices.ContainsFunc(known, func(u string) bool { return scraper.SameSource(u, sourceURL) }) {
 			continue
 		}
 
-		_, err := s.db.AddSource(topicID, source.URL, source.Name, false)
-		if err != nil {
-			log.Printf("Error adding source: %v", err)
+		if _, err := s.db.AddSource(topicID, sourceURL, source.Name, false); errors.Is(err, database.ErrSourceExists) {
+			continue
+		} else if err != nil {
+			logger.Error("Error adding source", "topic_id", topicID, "source_url", sourceURL, "error", err)
+			continue
 		}
+		known = append(known, sourceURL)
+		added++
 	}
 
-	log.Printf("Discovered %d sources for topic: %s", len(sources), topic.Name)
+	logger.Info("Discovered sources", "topic_id", topicID, "topic", topic.Name, "sources", len(sources), "new", added)
 	return nil
 }
+
+// suggestSources asks the AI for sources for a topic, keeping at most the number set for
+// it (see sourceCount). ctx bounds the call along with the AI timeout setting.
+func (s *Scheduler) suggestSources(ctx context.Context, topic *models.Topic, settings *models.Settings) ([]gemini.DiscoveredSource, error) {
+	if err := llm.CheckConfigured(settings); err != nil {
+		return nil, err
+	}
+	if err := s.checkBudget(settings); err != nil {
+		return nil, err
+	}
+
+	aiClient, err := s.newAIClient(settings, topic.ID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI client: %w", err)
+	}
+	defer aiClient.Close()
+	minSources, maxSources := sourceCount(topic, settings)
+	aiClient.SetSourceCount(minSources, maxSources)
+
+	ctx, cancel := context.WithTimeout(ctx, llm.Timeout(settings))
+	defer cancel()
+
+	sources, err := aiClient.DiscoverSources(ctx, topic.Name, topic.Description, sourcingPrompt(topic, settings))
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover sources: %w", err)
+	}
+	if len(sources) > maxSources {
+		logging.FromContext(ctx).Info("Dropping extra discovered sources", "topic_id", topic.ID, "sources", len(sources), "max_sources", maxSources)
+		sources = sources[:maxSources]
+	} else if len(sources) < minSources {
+		logging.FromContext(ctx).Warn("AI suggested fewer sources than asked for", "topic_id", topic.ID, "sources", len(sources), "min_sources", minSources)
+	}
+	return sources, nil
+}
+
+// CheckSourceURL checks that a URL answers with a 2xx or 3xx status, as discovered
+// sources are checked before they're stored. ctx bounds the check.
+func (s *Scheduler) CheckSourceURL(ctx context.Context, urlStr string) error {
+	_, err := s.scraper.CheckURL(ctx, urlStr)
+	return err
+}
+
+// sourceCheck is the outcome of checking a suggested source: its normalized URL, and
+// why it can't be used if it can't
+type sourceCheck struct {
+	url string
+	err error
+}
+
+// checkSuggestedSources normalizes and validates each suggested URL and, with verify,
+// checks that it answers with a 2xx or 3xx status. Models still suggest URLs that don't
+// exist, so this runs before anything is stored. A slow site can take the whole check
+// timeout, so the checks run in parallel.
+func (s *Scheduler) checkSuggestedSources(ctx context.Context, sources []gemini.DiscoveredSource, verify bool) []sourceCheck {
+	checks := make([]sourceCheck, len(sources))
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		sourceURL := scraper.NormalizeURL(source.URL)
+		checks[i].url = sourceURL
+		if err := scraper.ValidateURL(sourceURL); err != nil {
+			checks[i].err = fmt.Errorf("invalid URL: %w", err)
+			continue
+		}
+		if !verify {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.scraper.CheckURL(ctx, sourceURL); err != nil {
+				checks[i].err = fmt.Errorf("unreachable: %w", err)
+			}
+		}()
+	}
+	wg.Wait()
+	return checks
+}
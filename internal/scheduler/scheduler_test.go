@@ -0,0 +1,189 @@
+package scheduler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/thinkscotty/maggpi_go/internal/database"
+	"github.com/thinkscotty/maggpi_go/internal/models"
+)
+
+// newTestScheduler returns a scheduler backed by a fresh database in a temporary directory
+func newTestScheduler(t *testing.T) (*Scheduler, *database.DB) {
+	t.Helper()
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return New(db), db
+}
+
+func TestJitterBounds(t *testing.T) {
+	for _, d := range []time.Duration{time.Minute, time.Hour, 24 * time.Hour} {
+		low := time.Duration(float64(d) * (1 - refreshJitter))
+		high := time.Duration(float64(d) * (1 + refreshJitter))
+		for i := 0; i < 1000; i++ {
+			if got := jitter(d); got < low || got > high {
+				t.Fatalf("jitter(%v) = %v, want within [%v, %v]", d, got, low, high)
+			}
+		}
+	}
+}
+
+func TestJitterZero(t *testing.T) {
+	if got := jitter(0); got != 0 {
+		t.Errorf("jitter(0) = %v, want 0", got)
+	}
+}
+
+func TestNextRefreshTimeJitter(t *testing.T) {
+	s := &Scheduler{interval: time.Hour}
+	topic := &models.Topic{ID: 1, Name: "test"}
+	from := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 100; i++ {
+		got := s.nextRefreshTime(topic, from, 1).Sub(from)
+		if got < 48*time.Minute || got > 72*time.Minute {
+			t.Fatalf("next refresh %v after the last one, want within [48m, 72m]", got)
+		}
+	}
+}
+
+func TestUpdateConcurrencyStagger(t *testing.T) {
+	tests := []struct {
+		name    string
+		seconds int
+		want    time.Duration
+	}{
+		{"zero is allowed", 0, 0},
+		{"negative is clamped to zero", -5, 0},
+		{"seconds", 45, 45 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Keep the worker count unchanged so the job queue is not woken
+			s := &Scheduler{workers: 1, stagger: 30 * time.Second}
+			s.UpdateConcurrency(1, tt.seconds)
+			if s.stagger != tt.want {
+				t.Errorf("stagger = %v, want %v", s.stagger, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateIntervalReschedules(t *testing.T) {
+	tests := []struct {
+		name        string
+		from, to    time.Duration
+		lastRefresh time.Duration // how long ago the topic was last refreshed
+		// wantClamped means the new next refresh is already due, so it is moved to now
+		wantClamped bool
+	}{
+		{"shrinking makes an overdue topic due now", 240 * time.Minute, 30 * time.Minute, time.Hour, true},
+		{"growing pushes the next refresh out", 30 * time.Minute, 480 * time.Minute, 10 * time.Minute, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, db := newTestScheduler(t)
+			s.interval = tt.from
+
+			newTopic := models.NewTopic("Test", "")
+			topic, err := db.CreateTopic(&newTopic)
+			if err != nil {
+				t.Fatalf("create topic: %v", err)
+			}
+			lastRefresh := time.Now().Add(-tt.lastRefresh).Truncate(time.Second)
+			if err := db.UpdateRefreshStatus(&models.RefreshStatus{
+				TopicID:     topic.ID,
+				LastRefresh: lastRefresh,
+				NextRefresh: lastRefresh.Add(tt.from),
+				Status:      "completed",
+			}); err != nil {
+				t.Fatalf("update refresh status: %v", err)
+			}
+
+			before := time.Now().Truncate(time.Second)
+			s.UpdateInterval(int(tt.to / time.Minute))
+			after := time.Now()
+
+			status, err := db.GetRefreshStatus(topic.ID)
+			if err != nil || status == nil {
+				t.Fatalf("get refresh status: %v", err)
+			}
+			next := status.NextRefresh
+			if tt.wantClamped {
+				if next.Before(before) || next.After(after) {
+					t.Errorf("next refresh = %v, want clamped to now (between %v and %v)", next, before, after)
+				}
+			} else {
+				low := lastRefresh.Add(time.Duration(float64(tt.to) * (1 - refreshJitter))).Add(-time.Second)
+				high := lastRefresh.Add(time.Duration(float64(tt.to) * (1 + refreshJitter)))
+				if next.Before(low) || next.After(high) {
+					t.Errorf("next refresh = %v, want last refresh + %v with jitter (between %v and %v)", next, tt.to, low, high)
+				}
+			}
+
+			select {
+			case <-s.wakeCh:
+			default:
+				t.Error("run loop was not woken after the interval changed")
+			}
+		})
+	}
+}
+
+func TestStopMidRefresh(t *testing.T) {
+	s, db := newTestScheduler(t)
+
+	// A source that never answers, so the refresh is stuck scraping until it is cancelled
+	scraping := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case scraping <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	settings, err := db.GetSettings()
+	if err != nil {
+		t.Fatalf("get settings: %v", err)
+	}
+	settings.GeminiAPIKey = "test-key"
+	settings.RefreshStaggerSeconds = 0
+	if err := db.UpdateSettings(settings); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	topic, err := db.CreateTopic(&models.Topic{Name: "Test"})
+	if err != nil {
+		t.Fatalf("create topic: %v", err)
+	}
+	if _, err := db.AddSource(topic.ID, srv.URL, "Slow source", true); err != nil {
+		t.Fatalf("add source: %v", err)
+	}
+
+	s.Start()
+	s.EnqueueRefresh(topic.ID)
+	select {
+	case <-scraping:
+	case <-time.After(10 * time.Second):
+		s.Stop()
+		t.Fatal("refresh never started scraping")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return within 5s of being called mid-refresh")
+	}
+}
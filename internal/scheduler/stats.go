@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/thinkscotty/maggpi_go/internal/models"
+)
+
+// recordRefresh counts a finished refresh in the stats kept since startup, noting its
+// error if it failed
+func (s *Scheduler) recordRefresh(history *models.RefreshHistory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.RefreshesAttempted++
+	if history.Status == "completed" {
+		s.stats.RefreshesSucceeded++
+		return
+	}
+	s.stats.RefreshesFailed++
+	at := history.FinishedAt
+	s.stats.LastFailureAt = &at
+	s.stats.LastFailure = history.Error
+	s.stats.LastFailureTopicID = history.TopicID
+}
+
+// recordSourceDisabled counts a source disabled after repeated failures
+func (s *Scheduler) recordSourceDisabled() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.SourcesDisabled++
+}
+
+// Stats returns the refresh counters kept in memory since the scheduler was created.
+// They start from zero on every restart; refresh_status and refresh_history hold the
+// persistent record.
+func (s *Scheduler) Stats() models.SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.Uptime = time.Since(stats.StartedAt).Round(time.Second).String()
+	return stats
+}
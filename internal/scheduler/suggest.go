@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/thinkscotty/maggpi_go/internal/database"
+	"github.com/thinkscotty/maggpi_go/internal/gemini"
+	"github.com/thinkscotty/maggpi_go/internal/llm"
+)
+
+// TopicSuggestions is how many topics SuggestTopics returns at most
+const TopicSuggestions = 5
+
+// topicSuggestionsAsked is how many topics the AI is asked for, a few more than are
+// returned so that dropping ones that repeat existing topics still leaves enough
+const topicSuggestionsAsked = TopicSuggestions + 3
+
+// SuggestTopics asks the AI for new topics based on the existing ones. Suggestions named
+// like an existing topic, ignoring case, are dropped. ctx bounds the call along with the
+// AI timeout setting.
+func (s *Scheduler) SuggestTopics(ctx context.Context) ([]gemini.SuggestedTopic, error) {
+	settings, err := s.db.GetSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	if err := llm.CheckConfigured(settings); err != nil {
+		return nil, err
+	}
+	if err := s.checkBudget(settings); err != nil {
+		return nil, err
+	}
+
+	topics, err := s.db.GetTopics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topics: %w", err)
+	}
+	existing := make([]gemini.SuggestedTopic, len(topics))
+	taken := make(map[string]bool, len(topics))
+	for i, topic := range topics {
+		existing[i] = gemini.SuggestedTopic{Name: topic.Name, Description: topic.Description}
+		taken[strings.ToLower(topic.Name)] = true
+	}
+
+	aiClient, err := s.newAIClient(settings, 0, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI client: %w", err)
+	}
+	defer aiClient.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, llm.Timeout(settings))
+	defer cancel()
+
+	suggested, err := aiClient.SuggestTopics(ctx, existing, topicSuggestionsAsked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest topics: %w", err)
+	}
+
+	suggestions := []gemini.SuggestedTopic{}
+	for _, topic := range suggested {
+		topic.Name = database.NormalizeTopicName(topic.Name)
+		topic.Description = strings.TrimSpace(topic.Description)
+		key := strings.ToLower(topic.Name)
+		if topic.Name == "" || taken[key] {
+			continue
+		}
+		taken[key] = true
+		suggestions = append(suggestions, topic)
+		if len(suggestions) == TopicSuggestions {
+			break
+		}
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/thinkscotty/maggpi_go/internal/gemini"
+	"github.com/thinkscotty/maggpi_go/internal/llm"
+	"github.com/thinkscotty/maggpi_go/internal/models"
+)
+
+// TranslateStory rewrites a stored story's title and summary in the language with the
+// given code and saves them. Scraped content isn't kept, so the story's own text is
+// summarized again, with the topic's summarizing prompt, as if it were the only source.
+func (s *Scheduler) TranslateStory(ctx context.Context, storyID int64, language string) (*models.Story, error) {
+	s.maintenanceMu.RLock()
+	defer s.maintenanceMu.RUnlock()
+
+	story, err := s.db.GetStory(storyID)
+	if err != nil || story == nil {
+		return nil, fmt.Errorf("story not found: %d", storyID)
+	}
+	topic, err := s.db.GetTopic(story.TopicID)
+	if err != nil || topic == nil {
+		return nil, fmt.Errorf("topic not found: %d", story.TopicID)
+	}
+
+	settings, err := s.db.GetSettings()
+	if err != nil || settings == nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	if err := llm.CheckConfigured(settings); err != nil {
+		return nil, err
+	}
+	if err := s.checkBudget(settings); err != nil {
+		return nil, err
+	}
+
+	aiClient, err := s.newAIClient(settings, topic.ID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI client: %w", err)
+	}
+	defer aiClient.Close()
+	setSummaryLength(aiClient, topic, settings)
+
+	// Summarize with the requested language in place of the topic's own
+	translated := *topic
+	translated.StoryLanguage = language
+	content := []gemini.ScrapedContent{{
+		URL:        story.SourceURL,
+		SourceName: story.SourceTitle,
+		Content:    story.Title + "\n\n" + story.Summary,
+	}}
+
+	aiCtx, cancel := context.WithTimeout(ctx, llm.Timeout(settings))
+	defer cancel()
+	stories, err := aiClient.SummarizeContent(aiCtx, topic.Name, content, summarizingPrompt(&translated, settings), 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate story: %w", err)
+	}
+	if len(stories) == 0 || stories[0].Title == "" || stories[0].Summary == "" {
+		return nil, fmt.Errorf("failed to translate story: the AI returned no story")
+	}
+
+	// Only the text changes, and the length preset it was written with; the story keeps its source and dates
+	length := summaryLength(topic, settings)
+	if err := s.db.UpdateStoryText(story.ID, stories[0].Title, stories[0].Summary, length); err != nil {
+		return nil, fmt.Errorf("failed to save translated story: %w", err)
+	}
+	story.Title = stories[0].Title
+	story.Summary = stories[0].Summary
+	story.SummaryLength = length
+	return story, nil
+}
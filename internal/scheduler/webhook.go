@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/thinkscotty/maggpi_go/internal/models"
+	"github.com/thinkscotty/maggpi_go/internal/safego"
+)
+
+// Webhook delivery limits. A failed delivery is retried once after webhookRetryDelay.
+const (
+	webhookTimeout    = 10 * time.Second
+	webhookRetryDelay = 5 * time.Second
+)
+
+// webhookPayload is the JSON body POSTed to the webhook after a refresh
+type webhookPayload struct {
+	TopicID    int64     `json:"topic_id"`
+	TopicName  string    `json:"topic_name"`
+	StoryCount int       `json:"story_count"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// notifyWebhook reports a completed refresh to the configured webhook in the background,
+// so a slow or unreachable endpoint never holds up the scheduler. Failures are logged to logger.
+func notifyWebhook(logger *slog.Logger, webhookURL string, topic *models.Topic, storyCount int) {
+	if webhookURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		TopicID:    topic.ID,
+		TopicName:  topic.Name,
+		StoryCount: storyCount,
+		Timestamp:  time.Now(),
+	})
+	if err != nil {
+		logger.Error("Error encoding webhook payload", "topic_id", topic.ID, "error", err)
+		return
+	}
+
+	safego.Go("notifyWebhook", func() {
+		err := postWebhook(webhookURL, body)
+		if err != nil {
+			time.Sleep(webhookRetryDelay)
+			err = postWebhook(webhookURL, body)
+		}
+		if err != nil {
+			logger.Warn("Webhook failed", "topic_id", topic.ID, "topic", topic.Name, "error", err)
+		}
+	})
+}
+
+// postWebhook sends a single webhook request
+func postWebhook(webhookURL string, body []byte) error {
+	resp, err := webhookClient.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
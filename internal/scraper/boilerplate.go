@@ -0,0 +1,71 @@
+package scraper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxBoilerplateLineLength is the longest line removed outright when it matches a
+// boilerplate pattern. Longer lines are likely real paragraphs, so only the match is cut.
+const maxBoilerplateLineLength = 300
+
+// defaultBoilerplate matches fragments that appear on almost every news page
+var defaultBoilerplate = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(subscribe|sign up) (to|for) (our|the) (daily |weekly )?newsletter`),
+	regexp.MustCompile(`(?i)accept (all )?cookies|we use cookies|cookie (policy|settings|preferences)`),
+	regexp.MustCompile(`(?i)share (this|on) (article|story|facebook|twitter|x|linkedin|reddit|whatsapp|email)`),
+	regexp.MustCompile(`(?i)follow us on (facebook|twitter|x|instagram|linkedin)`),
+	regexp.MustCompile(`(?i)all rights reserved`),
+	regexp.MustCompile(`(?i)^advertisement$|^skip to (main )?content$`),
+	regexp.MustCompile(`(?i)(please )?(enable|turn on) javascript`),
+	regexp.MustCompile(`(?i)this site is protected by recaptcha`),
+}
+
+// ParseBoilerplatePatterns parses user-supplied boilerplate patterns, one per line.
+// Lines wrapped in slashes (/like this/) are case-insensitive regular expressions;
+// anything else matches as a case-insensitive substring. Blank lines are ignored.
+func ParseBoilerplatePatterns(text string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		expr := regexp.QuoteMeta(line)
+		if len(line) > 2 && strings.HasPrefix(line, "/") && strings.HasSuffix(line, "/") {
+			expr = line[1 : len(line)-1]
+		}
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boilerplate pattern %q: %w", line, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// stripBoilerplate removes lines of scraped text matching any of the patterns
+func stripBoilerplate(text string, patterns []*regexp.Regexp) string {
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		for _, re := range patterns {
+			if !re.MatchString(trimmed) {
+				continue
+			}
+			if len(trimmed) <= maxBoilerplateLineLength {
+				trimmed = ""
+				break
+			}
+			trimmed = strings.TrimSpace(re.ReplaceAllString(trimmed, ""))
+		}
+		if trimmed == "" && strings.TrimSpace(line) != "" {
+			continue
+		}
+		kept = append(kept, trimmed)
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,58 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/thinkscotty/maggpi_go/internal/reddit"
+)
+
+// checkTimeout bounds a reachability check, redirects included
+const checkTimeout = 10 * time.Second
+
+// CheckURL makes sure a URL answers before it's stored as a source, so guessed URLs that
+// 404 are caught up front. It sends a HEAD request, or a GET if the server doesn't allow
+// HEAD, follows redirects, and returns the URL it ended up at. Reddit URLs are fetched
+// through its API rather than directly, so they're returned unchecked.
+func (s *Scraper) CheckURL(ctx context.Context, urlStr string) (string, error) {
+	if reddit.IsRedditURL(urlStr) {
+		return urlStr, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	resp, err := s.checkRequest(ctx, http.MethodHead, urlStr)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented ||
+		resp.StatusCode == http.StatusForbidden) {
+		// Some servers reject HEAD outright; a GET settles it
+		resp, err = s.checkRequest(ctx, http.MethodGet, urlStr)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s is unreachable: %w", urlStr, err)
+	}
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("%s returned status %d", urlStr, resp.StatusCode)
+	}
+	return resp.Request.URL.String(), nil
+}
+
+// checkRequest sends a request for CheckURL and discards the body
+func (s *Scraper) checkRequest(ctx context.Context, method, urlStr string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", s.userAgent)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	return resp, nil
+}
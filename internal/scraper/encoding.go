@@ -0,0 +1,54 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// acceptEncoding is sent with every scrape. Setting the header by hand turns off net/http's
+// transparent decompression, which only covers gzip and so garbled deflate responses; colly
+// gunzips responses itself and decodeResponse handles the rest.
+const acceptEncoding = "gzip, deflate"
+
+// decodeResponse decompresses a response body colly left encoded. Deflate bodies are
+// meant to be zlib-wrapped but some servers send raw DEFLATE, so both are tried.
+// Encodings that weren't asked for, like brotli, can't be read and return an error.
+func decodeResponse(r *colly.Response) error {
+	if r.Headers == nil {
+		return nil
+	}
+	encoding := strings.ToLower(strings.TrimSpace(r.Headers.Get("Content-Encoding")))
+	switch encoding {
+	case "", "identity", "gzip", "x-gzip":
+		// Plain, or already gunzipped by colly
+		return nil
+	case "deflate":
+		body, err := inflate(r.Body)
+		if err != nil {
+			return fmt.Errorf("failed to decompress deflate response: %w", err)
+		}
+		r.Body = body
+		return nil
+	default:
+		return fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
+
+// inflate decompresses a zlib-wrapped or raw DEFLATE body
+func inflate(body []byte) ([]byte, error) {
+	if zr, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+		defer zr.Close()
+		if out, err := io.ReadAll(zr); err == nil {
+			return out, nil
+		}
+	}
+	fr := flate.NewReader(bytes.NewReader(body))
+	defer fr.Close()
+	return io.ReadAll(fr)
+}
@@ -0,0 +1,274 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html/charset"
+)
+
+// maxFeedItemLength caps the description kept per feed item, so one long article
+// doesn't use up the source's whole share of the prompt
+const maxFeedItemLength = 2000
+
+// feedItem is one article of an RSS or Atom feed
+type feedItem struct {
+	Title       string
+	Link        string
+	Description string    // plain text
+	Published   time.Time // zero if the feed gives no date
+	Image       string    // absolute http(s) URL, or ""
+}
+
+// feedContentTypes are the media types feeds are served with when labelled properly
+var feedContentTypes = map[string]bool{
+	"application/rss+xml":  true,
+	"application/atom+xml": true,
+	"application/rdf+xml":  true,
+}
+
+// xmlFeed covers RSS 2.0 (items in the channel), RSS 1.0/RDF (items beside the channel)
+// and Atom (entries in the root)
+type xmlFeed struct {
+	XMLName xml.Name
+	Channel struct {
+		Items []xmlItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []xmlItem `xml:"item"`
+	Entries []xmlItem `xml:"entry"`
+}
+
+// xmlItem is an RSS item or Atom entry. Title and description are slices because
+// Media RSS has elements with the same local names, which must not replace the item's own.
+type xmlItem struct {
+	Titles       []string  `xml:"title"`
+	Links        []xmlLink `xml:"link"`
+	Descriptions []string  `xml:"description"`
+	Encoded      string    `xml:"encoded"` // content:encoded
+	Summary      string    `xml:"summary"`
+	Content      string    `xml:"http://www.w3.org/2005/Atom content"`
+	PubDate      string    `xml:"pubDate"`
+	Date         string    `xml:"date"` // dc:date, RSS 1.0's publish date
+	Published    string    `xml:"published"`
+	Updated      string    `xml:"updated"`
+
+	Thumbnails []xmlMedia `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Media      []xmlMedia `xml:"http://search.yahoo.com/mrss/ content"`
+	Group      struct {
+		Thumbnails []xmlMedia `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	} `xml:"http://search.yahoo.com/mrss/ group"`
+	Enclosures []xmlMedia `xml:"enclosure"`
+}
+
+// xmlLink is an RSS link, which holds the URL as text, or an Atom link, which holds it in href
+type xmlLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Text string `xml:",chardata"`
+}
+
+// xmlMedia is a Media RSS thumbnail or content element, or an RSS enclosure
+type xmlMedia struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Medium string `xml:"medium,attr"`
+}
+
+// isFeed reports whether a response is an RSS, RDF or Atom feed, going by its content
+// type or, since many servers label feeds text/xml or even text/html, by its root element
+func isFeed(contentType string, body []byte) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && feedContentTypes[mediaType] {
+		return true
+	}
+	decoder := newFeedDecoder(body)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			switch start.Name.Local {
+			case "rss", "RDF", "feed":
+				return true
+			}
+			return false
+		}
+	}
+}
+
+// parseFeed reads the items of an RSS, RDF or Atom feed fetched from base. Relative
+// links and images are resolved against it.
+func parseFeed(body []byte, base *url.URL) ([]feedItem, error) {
+	var feed xmlFeed
+	if err := newFeedDecoder(body).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("invalid feed XML: %w", err)
+	}
+
+	raw := feed.Channel.Items
+	raw = append(raw, feed.Items...)
+	raw = append(raw, feed.Entries...)
+
+	items := make([]feedItem, 0, len(raw))
+	for _, item := range raw {
+		title := htmlText(first(item.Titles))
+		if title == "" {
+			continue
+		}
+		description := first(item.Descriptions)
+		for _, alternative := range []string{item.Encoded, item.Summary, item.Content} {
+			if strings.TrimSpace(description) == "" {
+				description = alternative
+			}
+		}
+		items = append(items, feedItem{
+			Title:       title,
+			Link:        item.link(base),
+			Description: truncate(htmlText(description), maxFeedItemLength),
+			Published:   item.published(),
+			Image:       item.image(base),
+		})
+	}
+	return items, nil
+}
+
+// first returns the first of values, or "" if there are none
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// newFeedDecoder returns a lenient decoder for feed XML that converts legacy charsets
+func newFeedDecoder(body []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = charset.NewReaderLabel
+	return decoder
+}
+
+// link returns the item's article URL: the text of an RSS link, or the href of an Atom
+// link to the article itself rather than to comments, enclosures or the feed
+func (item xmlItem) link(base *url.URL) string {
+	for _, link := range item.Links {
+		ref := strings.TrimSpace(link.Text)
+		if ref == "" && (link.Rel == "" || link.Rel == "alternate") {
+			ref = strings.TrimSpace(link.Href)
+		}
+		if ref == "" {
+			continue
+		}
+		if parsed, err := url.Parse(ref); err == nil && base != nil {
+			return base.ResolveReference(parsed).String()
+		}
+		return ref
+	}
+	return ""
+}
+
+// feedDateLayouts are the date formats found in feeds: RFC 822 and its common
+// variations for RSS pubDate, RFC 3339 for Atom and dc:date
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// published returns when the item was published, or the zero time if it has no date
+// that parses. Atom's updated date is used only when there is no published one.
+func (item xmlItem) published() time.Time {
+	for _, value := range []string{item.PubDate, item.Published, item.Date, item.Updated} {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		for _, layout := range feedDateLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// image returns the item's picture: a Media RSS thumbnail, an image in media:content,
+// or an image enclosure, in that order
+func (item xmlItem) image(base *url.URL) string {
+	var candidates []string
+	for _, media := range append(item.Thumbnails, item.Group.Thumbnails...) {
+		candidates = append(candidates, media.URL)
+	}
+	for _, media := range item.Media {
+		if media.Medium == "image" || strings.HasPrefix(media.Type, "image/") {
+			candidates = append(candidates, media.URL)
+		}
+	}
+	for _, enclosure := range item.Enclosures {
+		if strings.HasPrefix(enclosure.Type, "image/") {
+			candidates = append(candidates, enclosure.URL)
+		}
+	}
+	for _, candidate := range candidates {
+		if image := imageURL(candidate, base); image != "" {
+			return image
+		}
+	}
+	return ""
+}
+
+// htmlText returns the text of an HTML fragment with its whitespace collapsed. Feed
+// descriptions are usually HTML, escaped or in CDATA.
+func htmlText(fragment string) string {
+	if !strings.ContainsAny(fragment, "<&") {
+		return cleanText(fragment)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fragment))
+	if err != nil {
+		return cleanText(fragment)
+	}
+	return cleanText(doc.Text())
+}
+
+// formatFeed writes feed items out as the text sent to the AI, one article per block
+func formatFeed(items []feedItem) string {
+	var content strings.Builder
+	for _, item := range items {
+		content.WriteString("ARTICLE: ")
+		content.WriteString(item.Title)
+		content.WriteString("\n")
+		if item.Link != "" {
+			content.WriteString("LINK: ")
+			content.WriteString(item.Link)
+			content.WriteString("\n")
+		}
+		if !item.Published.IsZero() {
+			content.WriteString("PUBLISHED: ")
+			content.WriteString(item.Published.UTC().Format("2006-01-02 15:04 MST"))
+			content.WriteString("\n")
+		}
+		if item.Description != "" {
+			content.WriteString(item.Description)
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+	}
+	return content.String()
+}
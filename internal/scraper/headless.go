@@ -0,0 +1,77 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// headlessTimeout bounds a single headless render on top of the caller's context
+const headlessTimeout = 45 * time.Second
+
+// browserCandidates are the headless-capable browsers looked up on PATH, in order
+var browserCandidates = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable"}
+
+// findBrowser returns the path of an installed Chromium-based browser, or "" if none is found
+func findBrowser() string {
+	for _, name := range browserCandidates {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// renderHeadless loads a page in headless Chromium so client-side rendered content is
+// present, and returns its main text. Used as a fallback when the static scrape finds
+// too little; it is slow and memory hungry on a Pi, so it's opt-in.
+func (s *Scraper) renderHeadless(ctx context.Context, pageURL string) (string, error) {
+	browser := findBrowser()
+	if browser == "" {
+		return "", fmt.Errorf("no Chromium browser found for headless rendering")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, headlessTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, browser,
+		"--headless",
+		"--disable-gpu",
+		"--no-sandbox",
+		"--disable-dev-shm-usage",
+		"--virtual-time-budget=10000", // let scripts run for 10s of virtual time
+		"--user-agent="+s.userAgent,
+		"--dump-dom",
+		pageURL,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("headless render of %s timed out", pageURL)
+		}
+		return "", fmt.Errorf("headless render of %s failed: %w", pageURL, err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(out)))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse rendered page: %w", err)
+	}
+	if text := extractReadable(doc.Selection); len(text) >= minReadableLength {
+		return text, nil
+	}
+
+	// Not article-like; fall back to all reasonably long paragraphs and headlines
+	var text strings.Builder
+	doc.Find("h1, h2, h3, p, li").Each(func(_ int, sel *goquery.Selection) {
+		block := cleanText(sel.Text())
+		if len(block) > 25 {
+			text.WriteString(block)
+			text.WriteString("\n")
+		}
+	})
+	return text.String(), nil
+}
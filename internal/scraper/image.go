@@ -0,0 +1,34 @@
+package scraper
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Meta tags naming a page's preview image, in order of preference. Sites use both
+// property and name for either kind.
+const (
+	openGraphImageSelector = `meta[property="og:image"], meta[name="og:image"]`
+	twitterImageSelector   = `meta[name="twitter:image"], meta[property="twitter:image"], meta[name="twitter:image:src"]`
+)
+
+// imageURL resolves an image reference found on the page at base into an absolute
+// http(s) URL, or returns "" if it can't be used: data: URIs, other schemes, and
+// references that don't parse are skipped.
+func imageURL(ref string, base *url.URL) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(strings.ToLower(ref), "data:") {
+		return ""
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		parsed = base.ResolveReference(parsed)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ""
+	}
+	return parsed.String()
+}
@@ -0,0 +1,126 @@
+package scraper
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// minReadableLength is the least text the readability extractor must find for it to be
+// used instead of the selector-based extraction
+const minReadableLength = 500
+
+// junkSelector matches elements that never hold article text
+const junkSelector = "script, style, noscript, nav, header, footer, aside, form, iframe, svg, button, " +
+	"[role=navigation], [role=banner], [role=contentinfo], [aria-hidden=true]"
+
+var (
+	// unlikelyCandidate matches class/id names of page furniture such as cookie banners and share bars
+	unlikelyCandidate = regexp.MustCompile(`(?i)banner|breadcrumb|comment|cookie|consent|footer|header|menu|modal|nav|newsletter|popup|promo|related|share|sidebar|social|sponsor|subscribe|widget|\bad-|\bads\b`)
+	// likelyCandidate matches class/id names of elements that usually hold the article
+	likelyCandidate = regexp.MustCompile(`(?i)article|body|content|entry|main|post|story|text`)
+)
+
+// extractReadable returns the main article text of an HTML document, or "" if no
+// block of the page looks like an article. It scores paragraph containers the way
+// readability does: longer paragraphs with more commas count for more, class and id
+// names nudge the score, and link-heavy blocks are penalised.
+func extractReadable(doc *goquery.Selection) string {
+	doc = doc.Clone()
+	doc.Find(junkSelector).Remove()
+	doc.Find("[class], [id]").Each(func(_ int, s *goquery.Selection) {
+		names := classAndID(s)
+		if unlikelyCandidate.MatchString(names) && !likelyCandidate.MatchString(names) && goquery.NodeName(s) != "body" {
+			s.Remove()
+		}
+	})
+
+	// Give each paragraph's score to its parent, and half of it to its grandparent
+	scores := make(map[*html.Node]float64)
+	var candidates []*goquery.Selection
+	addScore := func(s *goquery.Selection, score float64) {
+		if s.Length() == 0 {
+			return
+		}
+		node := s.Get(0)
+		if _, seen := scores[node]; !seen {
+			scores[node] = classWeight(s)
+			candidates = append(candidates, s)
+		}
+		scores[node] += score
+	}
+	doc.Find("p, pre").Each(func(_ int, p *goquery.Selection) {
+		text := cleanText(p.Text())
+		if len(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+		parent := p.Parent()
+		addScore(parent, score)
+		addScore(parent.Parent(), score/2)
+	})
+
+	var best *goquery.Selection
+	var bestScore float64
+	for _, candidate := range candidates {
+		score := scores[candidate.Get(0)] * (1 - linkDensity(candidate))
+		if score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	if best == nil {
+		return ""
+	}
+
+	var text strings.Builder
+	best.Find("h2, h3, p, pre, li, blockquote").Each(func(_ int, s *goquery.Selection) {
+		block := cleanText(s.Text())
+		if len(block) < 25 || linkDensity(s) > 0.5 {
+			return
+		}
+		text.WriteString(block)
+		text.WriteString("\n\n")
+	})
+	return strings.TrimSpace(text.String())
+}
+
+// classAndID returns an element's class and id attributes joined for pattern matching
+func classAndID(s *goquery.Selection) string {
+	class, _ := s.Attr("class")
+	id, _ := s.Attr("id")
+	return class + " " + id
+}
+
+// classWeight is the starting score of a candidate based on its class and id names
+func classWeight(s *goquery.Selection) float64 {
+	names := classAndID(s)
+	var weight float64
+	if likelyCandidate.MatchString(names) {
+		weight += 25
+	}
+	if unlikelyCandidate.MatchString(names) {
+		weight -= 25
+	}
+	switch goquery.NodeName(s) {
+	case "article", "main":
+		weight += 10
+	case "div":
+		weight += 5
+	}
+	return weight
+}
+
+// linkDensity returns the fraction of an element's text that is inside links
+func linkDensity(s *goquery.Selection) float64 {
+	textLength := len(cleanText(s.Text()))
+	if textLength == 0 {
+		return 0
+	}
+	linkLength := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		linkLength += len(cleanText(a.Text()))
+	})
+	return float64(linkLength) / float64(textLength)
+}
@@ -1,25 +1,42 @@
 package scraper
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"net/url"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gocolly/colly/v2"
 	"github.com/thinkscotty/maggpi_go/internal/gemini"
+	"github.com/thinkscotty/maggpi_go/internal/logging"
 	"github.com/thinkscotty/maggpi_go/internal/models"
 	"github.com/thinkscotty/maggpi_go/internal/reddit"
 )
 
-// Scraper handles web scraping operations
+// Scraper handles web scraping operations. One Scraper is shared by every refresh and
+// is safe for concurrent use:
+//   - each ScrapeSource call builds its own colly collector, so web scrapes share nothing
+//   - the options below mu are read once per call, so ApplySettings can run mid-refresh
+//   - all Reddit sources go through the one Reddit client, whose token bucket keeps the
+//     combined request rate of concurrent refreshes under Reddit's limit
+//
+// The parallelism limit applies per ScrapeSources call, so concurrent refreshes each
+// scrape up to that many sources at once.
 type Scraper struct {
 	userAgent      string
 	requestTimeout time.Duration
-	parallelLimit  int
-	redditClient   *reddit.Client
+	redditClient   *reddit.Client // shared by all scrapes; rate limited across them
+
+	mu            sync.Mutex
+	parallelLimit int
+	boilerplate   []*regexp.Regexp // lines of scraped text to drop
+	headless      bool             // render pages in headless Chromium when the static scrape finds too little
+	maxContent    int              // scraped text kept per source
 }
 
 // ScrapeResult represents the result of scraping a source
@@ -34,11 +51,38 @@ func New() *Scraper {
 	return &Scraper{
 		userAgent:      "MaggPi/1.0 (Raspberry Pi News Aggregator; +https://github.com/thinkscotty/maggpi_go)",
 		requestTimeout: 30 * time.Second,
-		parallelLimit:  2, // Keep low for Raspberry Pi
+		parallelLimit:  2, // Keep low for Raspberry Pi; overridden by settings
+		maxContent:     10000,
+		boilerplate:    defaultBoilerplate,
 		redditClient:   reddit.New(),
 	}
 }
 
+// ApplySettings updates scraping options from the application settings
+func (s *Scraper) ApplySettings(settings *models.Settings) {
+	if settings.ScrapeParallelism > 0 {
+		s.mu.Lock()
+		s.parallelLimit = settings.ScrapeParallelism
+		s.mu.Unlock()
+	}
+	s.mu.Lock()
+	s.headless = settings.HeadlessFallback
+	if settings.MaxSourceChars > 0 {
+		s.maxContent = settings.MaxSourceChars
+	}
+	s.mu.Unlock()
+	// Patterns are validated when settings are saved; fall back to the defaults if they don't parse
+	if extra, err := ParseBoilerplatePatterns(settings.BoilerplatePatterns); err == nil {
+		s.mu.Lock()
+		s.boilerplate = append(slices.Clip(defaultBoilerplate), extra...)
+		s.mu.Unlock()
+	}
+	s.redditClient.SetIncludeLinkPosts(settings.RedditIncludeLinkPosts)
+	s.redditClient.SetMinWordCount(settings.RedditMinWords)
+	s.redditClient.SetMinScore(settings.RedditMinScore)
+	s.redditClient.SetMaxAge(time.Duration(settings.RedditMaxAgeHours) * time.Hour)
+}
+
 // ScrapeSource scrapes content from a single source
 func (s *Scraper) ScrapeSource(ctx context.Context, source models.Source) (*gemini.ScrapedContent, error) {
 	// Route Reddit URLs to the Reddit client
@@ -49,13 +93,60 @@ func (s *Scraper) ScrapeSource(ctx context.Context, source models.Source) (*gemi
 	c := colly.NewCollector(
 		colly.UserAgent(s.userAgent),
 		colly.MaxDepth(1),
+		colly.StdlibContext(ctx), // abort the request when the batch deadline hits
 	)
 
 	c.SetRequestTimeout(s.requestTimeout)
 
 	var content strings.Builder
-	var title string
+	var title, article string
+	var openGraphImage, twitterImage string
+	var feedItems []feedItem
+	var feed bool
 	var mu sync.Mutex
+	var scrapeErr error
+
+	// Negotiate compression explicitly and decode what colly doesn't. OnResponse runs
+	// before the OnHTML callbacks, so they see the decoded body.
+	c.OnRequest(func(r *colly.Request) {
+		r.Headers.Set("Accept-Encoding", acceptEncoding)
+	})
+	c.OnResponse(func(r *colly.Response) {
+		if err := decodeResponse(r); err != nil {
+			r.Body = nil
+			scrapeErr = fmt.Errorf("scrape error for %s: %w", source.URL, err)
+			return
+		}
+
+		// RSS, RDF and Atom feeds are parsed as XML. The body is then dropped so the HTML
+		// callbacks below, which fire for feeds served as text/html, don't see it.
+		var contentType string
+		if r.Headers != nil {
+			contentType = r.Headers.Get("Content-Type")
+		}
+		if !isFeed(contentType, r.Body) {
+			return
+		}
+		items, err := parseFeed(r.Body, r.Request.URL)
+		r.Body = nil
+		if err != nil {
+			scrapeErr = fmt.Errorf("scrape error for %s: %w", source.URL, err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		feedItems = items
+		feed = true
+	})
+
+	// Extract the main article text, readability-style. Used instead of the
+	// selector-based content below whenever it finds enough text.
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		text := extractReadable(e.DOM)
+		mu.Lock()
+		defer mu.Unlock()
+		article = text
+	})
 
 	// Extract page title
 	c.OnHTML("title", func(e *colly.HTMLElement) {
@@ -66,7 +157,23 @@ func (s *Scraper) ScrapeSource(ctx context.Context, source models.Source) (*gemi
 		}
 	})
 
-	// Extract main content - try common content selectors
+	// Extract the page's preview image, shown with the stories that come from it
+	c.OnHTML(openGraphImageSelector, func(e *colly.HTMLElement) {
+		mu.Lock()
+		defer mu.Unlock()
+		if openGraphImage == "" {
+			openGraphImage = imageURL(e.Attr("content"), e.Request.URL)
+		}
+	})
+	c.OnHTML(twitterImageSelector, func(e *colly.HTMLElement) {
+		mu.Lock()
+		defer mu.Unlock()
+		if twitterImage == "" {
+			twitterImage = imageURL(e.Attr("content"), e.Request.URL)
+		}
+	})
+
+	// Fallback extraction: main content - try common content selectors
 	contentSelectors := []string{
 		"article",
 		"main",
@@ -113,35 +220,7 @@ func (s *Scraper) ScrapeSource(ctx context.Context, source models.Source) (*gemi
 		}
 	})
 
-	// Handle RSS/Atom feeds
-	c.OnHTML("item, entry", func(e *colly.HTMLElement) {
-		mu.Lock()
-		defer mu.Unlock()
-		itemTitle := e.ChildText("title")
-		itemDesc := e.ChildText("description, summary, content")
-		itemLink := e.ChildAttr("link", "href")
-		if itemLink == "" {
-			itemLink = e.ChildText("link")
-		}
-
-		if itemTitle != "" {
-			content.WriteString("ARTICLE: ")
-			content.WriteString(itemTitle)
-			content.WriteString("\n")
-			if itemLink != "" {
-				content.WriteString("LINK: ")
-				content.WriteString(itemLink)
-				content.WriteString("\n")
-			}
-			if itemDesc != "" {
-				content.WriteString(cleanText(itemDesc))
-				content.WriteString("\n\n")
-			}
-		}
-	})
-
 	// Error handling
-	var scrapeErr error
 	c.OnError(func(r *colly.Response, err error) {
 		scrapeErr = fmt.Errorf("scrape error for %s: %w (status: %d)", source.URL, err, r.StatusCode)
 	})
@@ -158,15 +237,32 @@ func (s *Scraper) ScrapeSource(ctx context.Context, source models.Source) (*gemi
 	}
 
 	contentStr := content.String()
+	if feed {
+		contentStr = formatFeed(feedItems)
+	} else if len(article) >= minReadableLength {
+		contentStr = article
+	}
+	s.mu.Lock()
+	boilerplate := s.boilerplate
+	headless := s.headless
+	maxContent := s.maxContent
+	s.mu.Unlock()
+
+	// Pages rendered client-side come back nearly empty; try a headless browser if enabled
+	if len(contentStr) < 100 && headless && !feed {
+		if rendered, err := s.renderHeadless(ctx, source.URL); err != nil {
+			logging.FromContext(ctx).Warn("Headless render failed", "source_url", source.URL, "error", err)
+		} else {
+			contentStr = rendered
+		}
+	}
+	contentStr = stripBoilerplate(contentStr, boilerplate)
 	if len(contentStr) < 100 {
 		return nil, fmt.Errorf("insufficient content scraped from %s", source.URL)
 	}
 
 	// Truncate if too long (to manage API costs and memory)
-	maxLength := 10000
-	if len(contentStr) > maxLength {
-		contentStr = contentStr[:maxLength] + "..."
-	}
+	contentStr = truncate(contentStr, maxContent)
 
 	sourceName := source.Name
 	if sourceName == "" {
@@ -179,68 +275,130 @@ func (s *Scraper) ScrapeSource(ctx context.Context, source models.Source) (*gemi
 		}
 	}
 
+	images := make(map[string]string)
+	published := make(map[string]time.Time)
+	for _, item := range feedItems {
+		if item.Link == "" {
+			continue
+		}
+		if item.Image != "" {
+			images[item.Link] = item.Image
+		}
+		if !item.Published.IsZero() {
+			published[item.Link] = item.Published
+		}
+	}
+	if image := cmp.Or(openGraphImage, twitterImage); image != "" {
+		images[source.URL] = image
+	}
+
 	return &gemini.ScrapedContent{
 		URL:        source.URL,
 		SourceName: sourceName,
 		Content:    contentStr,
+		Feed:       feed,
+		Images:     images,
+		Published:  published,
 	}, nil
 }
 
-// ScrapeSources scrapes multiple sources concurrently and returns results including errors
-func (s *Scraper) ScrapeSources(ctx context.Context, sources []models.Source) []ScrapeResult {
+// ScrapeSources scrapes multiple sources concurrently and returns results including errors.
+// If onProgress is non-nil it is called after each source finishes with the number done so far.
+//
+// When ctx is done ScrapeSources returns straight away with the results collected so far,
+// instead of waiting for slow sources. Sources still being scraped at that point are
+// reported as failed and their requests cancelled; sources not yet started are left out.
+func (s *Scraper) ScrapeSources(ctx context.Context, sources []models.Source, onProgress func(done, total int)) []ScrapeResult {
 	var results []ScrapeResult
 	var mu sync.Mutex
+	inFlight := make(map[int]models.Source)
+	closed := false // set once ScrapeSources has returned; later results are dropped
 
-	// Use a semaphore to limit concurrent scrapes
-	sem := make(chan struct{}, s.parallelLimit)
-	var wg sync.WaitGroup
+	record := func(i int, result ScrapeResult) {
+		mu.Lock()
+		if closed {
+			mu.Unlock()
+			return
+		}
+		delete(inFlight, i)
+		results = append(results, result)
+		done := len(results)
+		mu.Unlock()
 
-	for _, source := range sources {
-		select {
-		case <-ctx.Done():
-			return results
-		default:
+		if onProgress != nil {
+			onProgress(done, len(sources))
 		}
+	}
 
+	// Use a semaphore to limit concurrent scrapes
+	s.mu.Lock()
+	parallelLimit := s.parallelLimit
+	s.mu.Unlock()
+	sem := make(chan struct{}, parallelLimit)
+	var wg sync.WaitGroup
+
+	for i, source := range sources {
 		wg.Add(1)
-		go func(src models.Source) {
+		go func(i int, src models.Source) {
 			defer wg.Done()
 
 			// Panic recovery to prevent one bad source from crashing the scraper
 			defer func() {
 				if r := recover(); r != nil {
-					mu.Lock()
-					results = append(results, ScrapeResult{
+					record(i, ScrapeResult{
 						Source:  src,
 						Content: nil,
 						Error:   fmt.Errorf("panic while scraping: %v", r),
 					})
-					mu.Unlock()
-					fmt.Printf("Warning: panic while scraping %s: %v\n", src.URL, r)
+					logging.FromContext(ctx).Warn("Panic while scraping", "source_url", src.URL, "panic", r)
 				}
 			}()
 
-			sem <- struct{}{}        // Acquire
+			select {
+			case sem <- struct{}{}: // Acquire
+			case <-ctx.Done():
+				return
+			}
 			defer func() { <-sem }() // Release
 
+			mu.Lock()
+			inFlight[i] = src
+			mu.Unlock()
+
 			content, err := s.ScrapeSource(ctx, src)
 
-			mu.Lock()
-			results = append(results, ScrapeResult{
+			// Errors are returned with the result so callers can track per-source failures
+			record(i, ScrapeResult{
 				Source:  src,
 				Content: content,
 				Error:   err,
 			})
-			mu.Unlock()
+		}(i, source)
+	}
 
-			if err != nil {
-				// Log error but continue with other sources
-				fmt.Printf("Warning: failed to scrape %s: %v\n", src.URL, err)
-			}
-		}(source)
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-ctx.Done():
 	}
 
-	wg.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	closed = true
+	for _, src := range inFlight {
+		results = append(results, ScrapeResult{
+			Source: src,
+			Error:  fmt.Errorf("scrape of %s did not finish in time: %w", src.URL, ctx.Err()),
+		})
+	}
+	if skipped := len(sources) - len(results); skipped > 0 {
+		logging.FromContext(ctx).Warn("Scrape deadline reached", "skipped", skipped, "sources", len(sources))
+	}
 	return results
 }
 
@@ -271,34 +429,94 @@ func ValidateURL(urlStr string) error {
 	return nil
 }
 
+// NormalizeURL returns a canonical form of a source URL: lowercase scheme and host,
+// no default port, fragment, or trailing slash. URLs that can't be parsed are returned trimmed.
+func NormalizeURL(urlStr string) string {
+	urlStr = strings.TrimSpace(urlStr)
+	parsed, err := url.Parse(urlStr)
+	if err != nil || parsed.Host == "" {
+		return urlStr
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	if port := parsed.Port(); port != "" && !(parsed.Scheme == "http" && port == "80") && !(parsed.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	parsed.Host = host
+	parsed.Fragment = ""
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	parsed.RawPath = ""
+	return parsed.String()
+}
+
+// SameSource reports whether two source URLs point at the same place, ignoring
+// http/https, a leading "www.", and the differences NormalizeURL removes
+func SameSource(a, b string) bool {
+	return sourceKey(a) == sourceKey(b)
+}
+
+// sourceKey is a normalized URL without its scheme or "www." prefix
+func sourceKey(urlStr string) string {
+	key := NormalizeURL(urlStr)
+	if i := strings.Index(key, "://"); i >= 0 {
+		key = key[i+3:]
+	}
+	return strings.TrimPrefix(key, "www.")
+}
+
 // scrapeRedditSource fetches posts from a Reddit subreddit
 func (s *Scraper) scrapeRedditSource(ctx context.Context, source models.Source) (*gemini.ScrapedContent, error) {
-	posts, err := s.redditClient.FetchPosts(ctx, source.URL, source.Name)
+	sort, timeframe := reddit.ListingOptions(source.URL)
+	posts, err := s.redditClient.FetchPosts(ctx, source.URL, source.Name, sort, timeframe)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch Reddit posts: %w", err)
 	}
 
 	if len(posts) == 0 {
-		return nil, fmt.Errorf("no valid posts found in subreddit (text posts with >100 words)")
+		return nil, fmt.Errorf("no valid posts found in subreddit (posts below the minimum word count or score, or older than the maximum age, are skipped)")
 	}
 
 	// Format posts into content for Gemini
 	var content strings.Builder
-	for _, post := range posts {
+	linkedArticles := 0
+	for i, post := range posts {
 		content.WriteString(fmt.Sprintf("REDDIT POST: %s\n", post.Title))
 		content.WriteString(fmt.Sprintf("LINK: https://reddit.com%s\n", post.Permalink))
+		if post.URL != "" {
+			content.WriteString(fmt.Sprintf("LINKED URL: %s\n", post.URL))
+		}
 		content.WriteString(fmt.Sprintf("SCORE: %d | AUTHOR: u/%s\n", post.Score, post.Author))
 		content.WriteString(post.Body)
+
+		// Link posts have no body, so pull in the start of the linked article instead
+		if post.URL != "" && linkedArticles < maxLinkedArticles {
+			linkedArticles++
+			if article := s.fetchLinkedArticle(ctx, post.URL); article != "" {
+				content.WriteString("ARTICLE: ")
+				content.WriteString(article)
+			}
+		}
+
+		// Discussion often says more than the post itself, so add top comments for the leading posts
+		if i < commentedPosts {
+			comments, err := s.redditClient.FetchTopComments(ctx, post.Permalink, commentsPerPost)
+			if err == nil {
+				for _, comment := range comments {
+					content.WriteString(fmt.Sprintf("\nTOP COMMENT (score %d): %s", comment.Score, truncate(comment.Body, maxCommentLength)))
+				}
+			}
+		}
 		content.WriteString("\n\n---\n\n")
 	}
 
 	contentStr := content.String()
 
 	// Truncate if too long (same limit as web scraping)
-	maxLength := 10000
-	if len(contentStr) > maxLength {
-		contentStr = contentStr[:maxLength] + "..."
-	}
+	s.mu.Lock()
+	maxContent := s.maxContent
+	s.mu.Unlock()
+	contentStr = truncate(contentStr, maxContent)
 
 	sourceName := source.Name
 	if sourceName == "" {
@@ -312,12 +530,40 @@ func (s *Scraper) scrapeRedditSource(ctx context.Context, source models.Source)
 	}, nil
 }
 
+// Limits for fetching articles behind Reddit link posts and top comments, so one
+// subreddit doesn't turn into dozens of requests or crowd out the posts themselves
+const (
+	maxLinkedArticles      = 5
+	maxLinkedArticleLength = 1500
+	commentedPosts         = 3
+	commentsPerPost        = 3
+	maxCommentLength       = 500
+)
+
+// fetchLinkedArticle scrapes the article a Reddit link post points to and returns
+// the start of its text, or "" if it couldn't be fetched
+func (s *Scraper) fetchLinkedArticle(ctx context.Context, link string) string {
+	article, err := s.ScrapeSource(ctx, models.Source{URL: link})
+	if err != nil {
+		return ""
+	}
+	return truncate(article.Content, maxLinkedArticleLength)
+}
+
+// truncate shortens s to at most n bytes, marking the cut with "..."
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
+
 // extractSubredditName extracts just the subreddit name for display
 func extractSubredditName(url string) string {
 	// Simple extraction - look for /r/ and get the next segment
 	if idx := strings.Index(url, "/r/"); idx != -1 {
 		rest := url[idx+3:]
-		if slashIdx := strings.Index(rest, "/"); slashIdx != -1 {
+		if slashIdx := strings.IndexAny(rest, "/?"); slashIdx != -1 {
 			return rest[:slashIdx]
 		}
 		return rest
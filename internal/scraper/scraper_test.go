@@ -0,0 +1,94 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/thinkscotty/maggpi_go/internal/models"
+)
+
+const testArticle = "The city council voted on Tuesday to expand the bike lane network across the downtown core, " +
+	"adding twelve kilometres of protected lanes over the next two years. Supporters said the plan would make " +
+	"commuting safer, while local businesses asked for more loading zones along the affected streets."
+
+var testPage = `<!DOCTYPE html>
+<html><head><title>City News</title></head>
+<body><article><h1>Council expands bike lanes</h1><p>` + testArticle + `</p><p>` + testArticle + `</p></article></body></html>`
+
+// compress encodes body with the given Content-Encoding
+func compress(t *testing.T, encoding string, body []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	default:
+		t.Fatalf("unknown encoding %q", encoding)
+	}
+	if _, err := w.Write(body); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestScrapeSourceCompressed(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string // how the body is compressed
+		header   string // the Content-Encoding the server sends
+	}{
+		{"gzip", "gzip", "gzip"},
+		{"zlib deflate", "deflate", "deflate"},
+		{"raw deflate", "raw deflate", "deflate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+					t.Errorf("Accept-Encoding = %q, want gzip offered", r.Header.Get("Accept-Encoding"))
+				}
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.Header().Set("Content-Encoding", tt.header)
+				w.Write(compress(t, tt.encoding, []byte(testPage)))
+			}))
+			defer srv.Close()
+
+			got, err := New().ScrapeSource(context.Background(), models.Source{URL: srv.URL, Name: "City News"})
+			if err != nil {
+				t.Fatalf("ScrapeSource: %v", err)
+			}
+			if !strings.Contains(got.Content, "expand the bike lane network") {
+				t.Errorf("content is not the decoded article text: %q", got.Content)
+			}
+		})
+	}
+}
+
+func TestScrapeSourceUnsupportedEncoding(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Encoding", "br")
+		w.Write([]byte("\x0b\x02\x80not really brotli"))
+	}))
+	defer srv.Close()
+
+	if _, err := New().ScrapeSource(context.Background(), models.Source{URL: srv.URL}); err == nil {
+		t.Fatal("ScrapeSource succeeded on a brotli response, want an error")
+	}
+}